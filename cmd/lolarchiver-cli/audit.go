@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/audit"
+	"github.com/ivan9253/lolarchiver-cli/pkg/output"
+)
+
+func handleAudit() {
+	if len(os.Args) < 3 {
+		fmt.Println("Expected 'verify' or 'export' subcommand")
+		os.Exit(1)
+	}
+
+	cmd := flag.NewFlagSet(os.Args[2], flag.ExitOnError)
+	defaultPath, err := audit.DefaultPath()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	path := cmd.String("file", defaultPath, "Audit log file")
+
+	switch os.Args[2] {
+	case "verify":
+		if err := cmd.Parse(os.Args[3:]); err != nil {
+			fmt.Printf("Error parsing flags: %v\n", err)
+			cmd.PrintDefaults()
+			os.Exit(1)
+		}
+
+		n, head, err := audit.Verify(*path)
+		if err != nil {
+			var verr *audit.VerifyError
+			if errors.As(err, &verr) {
+				fmt.Printf("FAILED: %v\n", err)
+				fmt.Printf("%d entries verified before the break\n", n)
+			} else {
+				fmt.Printf("Error: %v\n", err)
+			}
+			os.Exit(1)
+		}
+		fmt.Printf("OK: %d entries verified\n", n)
+		if head != "" {
+			fmt.Printf("Head hash: %s\n", head)
+		}
+	case "export":
+		format := cmd.String("format", output.JSONL, "Output format (json, jsonl, csv, or table)")
+		since := cmd.String("since", "", "Only export entries on or after this date (YYYY-MM-DD)")
+		if err := cmd.Parse(os.Args[3:]); err != nil {
+			fmt.Printf("Error parsing flags: %v\n", err)
+			cmd.PrintDefaults()
+			os.Exit(1)
+		}
+
+		if _, err := output.ParseFormat(*format); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		var from time.Time
+		if *since != "" {
+			from, err = time.ParseInLocation(time.DateOnly, *since, time.Local)
+			if err != nil {
+				fmt.Printf("Error: invalid --since date: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		if _, _, err := audit.Verify(*path); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+
+		entries, err := audit.Read(*path)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		selected := audit.Entries{}
+		for _, e := range entries {
+			if e.Time.Before(from) {
+				continue
+			}
+			selected = append(selected, e)
+		}
+
+		// Only free text is scanned for patterns, so that the hashes and
+		// query hashes stay readable. The entry hash covers these fields
+		// too, so a redacted export can no longer be verified.
+		if globals.redactor != nil {
+			for i := range selected {
+				selected[i].Operator = globals.redactor.String(selected[i].Operator)
+				selected[i].Reason = globals.redactor.String(selected[i].Reason)
+				selected[i].Error = globals.redactor.String(selected[i].Error)
+			}
+		}
+		if err := output.Write(os.Stdout, *format, selected); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	default:
+		fmt.Printf("Unknown subcommand: %s\n", os.Args[2])
+		os.Exit(1)
+	}
+}
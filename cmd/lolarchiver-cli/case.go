@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/api"
+	"github.com/ivan9253/lolarchiver-cli/pkg/cases"
+	"github.com/ivan9253/lolarchiver-cli/pkg/config"
+)
+
+func handleCase() {
+	if len(os.Args) < 3 {
+		fmt.Println("Expected 'new', 'open', 'close', or 'ls' subcommand")
+		os.Exit(1)
+	}
+
+	switch os.Args[2] {
+	case "new":
+		name := caseArg()
+		if _, err := cases.Create(name); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := cases.SetCurrent(name); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Case %q created and opened\n", name)
+	case "open":
+		name := caseArg()
+		c, err := cases.Load(name)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if c.Status != cases.StatusOpen {
+			if err := c.Reopen(); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if err := cases.SetCurrent(name); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Case %q opened\n", name)
+	case "close":
+		current, err := cases.Current()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		name := current
+		if len(os.Args) > 3 {
+			name = os.Args[3]
+		}
+		if name == "" {
+			fmt.Println("Error: No case is open")
+			os.Exit(1)
+		}
+
+		c, err := cases.Load(name)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := c.Close(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if name == current {
+			if err := cases.SetCurrent(""); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		fmt.Printf("Case %q closed\n", name)
+	case "ls":
+		list, err := cases.List()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(list) == 0 {
+			fmt.Println("No cases found")
+			return
+		}
+
+		current, _ := cases.Current()
+		for _, c := range list {
+			entries, err := c.Entries()
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			marker := " "
+			if c.Name == current {
+				marker = "*"
+			}
+			fmt.Printf("%s %-24s %-6s  created %s  %d request(s)\n", marker, c.Name, c.Status, c.Created.Local().Format(time.DateTime), len(entries))
+		}
+	default:
+		fmt.Printf("Unknown subcommand: %s\n", os.Args[2])
+		os.Exit(1)
+	}
+}
+
+func caseArg() string {
+	if len(os.Args) < 4 || os.Args[3] == "" {
+		fmt.Println("Error: case name is required")
+		os.Exit(1)
+	}
+	return os.Args[3]
+}
+
+// activeCase returns the case selected with --case, falling back to the
+// case opened with 'case open'. It returns nil if no case is active.
+func activeCase() (*cases.Case, error) {
+	name := globals.caseName
+	if name == "" {
+		var err error
+		name, err = cases.Current()
+		if err != nil || name == "" {
+			return nil, err
+		}
+	}
+
+	c, err := cases.Load(name)
+	if err != nil {
+		return nil, err
+	}
+	if c.Status != cases.StatusOpen {
+		return nil, fmt.Errorf("case %q is closed; reopen it with 'lolarchiver-cli case open %s'", name, name)
+	}
+	return c, nil
+}
+
+// attachCase records every request made by client into c
+func attachCase(client *api.Client, c *cases.Case) {
+	profile := config.ProfileName()
+	client.AddObserver(func(ex api.Exchange) {
+		entry := cases.Entry{
+			Time:       ex.Started.UTC(),
+			Profile:    profile,
+			Method:     ex.Request.Method,
+			Endpoint:   ex.Request.Path,
+			Params:     ex.Request.Params(),
+			DurationMS: ex.Duration.Milliseconds(),
+		}
+		var body []byte
+		if ex.Err != nil {
+			entry.Error = ex.Err.Error()
+		} else {
+			entry.StatusCode = ex.Response.StatusCode
+			body = ex.Response.Body
+			// Failed requests are not charged, as in the audit log
+			if ex.Response.StatusCode == 200 {
+				entry.Credits = client.EstimatedCost(ex.Request.Path)
+			}
+		}
+		if err := c.Record(entry, body); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to record request in case %q: %v\n", c.Name, err)
+		}
+	})
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/api"
+	"github.com/ivan9253/lolarchiver-cli/pkg/chatlog"
+	"github.com/ivan9253/lolarchiver-cli/pkg/records"
+	"github.com/ivan9253/lolarchiver-cli/pkg/timeline"
+)
+
+// validateExport checks the export flags before any request is made
+func validateExport(format, outFile string, pages int) {
+	if format == "" {
+		if outFile != "" {
+			fmt.Println("Error: --output needs --export")
+			os.Exit(1)
+		}
+		return
+	}
+	if pages < 1 {
+		fmt.Println("Error: pages must be at least 1")
+		os.Exit(1)
+	}
+	for _, f := range chatlog.Formats {
+		if format == f {
+			return
+		}
+	}
+	fmt.Println("Error: export must be one of irc, csv, or html")
+	os.Exit(1)
+}
+
+// exportOrExit pages through a messages endpoint from offset and exports
+// the messages, exiting on any error. Messages fetched before a failed page
+// are still exported.
+func exportOrExit(platform, username string, offset, pages int, fetch func(offset int) (*api.Response, error), format, outFile string) {
+	recs, err := fetchPages(pages, func(o int) (*api.Response, error) { return fetch(offset + o) })
+	if err != nil {
+		if len(recs) == 0 {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: exporting the %d message(s) fetched before an error: %v\n", len(recs), err)
+	}
+	if err := exportMessages(platform, username, recs, format, outFile); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// exportMessages writes messages as a chat log to outFile, or to stdout
+// when outFile is empty
+func exportMessages(platform, username string, recs []records.Record, format, outFile string) error {
+	if globals.redactor != nil {
+		data, err := json.Marshal(recs)
+		if err != nil {
+			return fmt.Errorf("failed to marshal messages: %w", err)
+		}
+		if recs, err = records.Extract(globals.redactor.JSON(data)); err != nil {
+			return err
+		}
+	}
+	events := timeline.FromActivity(platform, timeline.KindMessage, username, recs)
+
+	if outFile == "" {
+		return chatlog.Write(os.Stdout, format, events)
+	}
+	f, err := os.OpenFile(outFile, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", outFile, err)
+	}
+	if err := chatlog.Write(f, format, events); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", outFile, err)
+	}
+	return nil
+}
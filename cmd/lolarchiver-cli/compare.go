@@ -0,0 +1,167 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/api"
+	"github.com/ivan9253/lolarchiver-cli/pkg/follows"
+	"github.com/ivan9253/lolarchiver-cli/pkg/graph"
+	"github.com/ivan9253/lolarchiver-cli/pkg/output"
+)
+
+func handleTwitchCompare(cmd *flag.FlagSet) {
+	usersFlag := cmd.String("users", "", "Comma-separated Twitch usernames to compare (at least two)")
+	direction := cmd.String("direction", graph.Followers, "List to compare (followers or followage)")
+	show := cmd.String("show", "all", "What to print (all, common, partial, only, or similarity)")
+	onlyFlag := cmd.String("only", "", "Comma-separated users whose lists must all contain an account")
+	notFlag := cmd.String("not", "", "Comma-separated users whose lists must not contain an account")
+	format := cmd.String("format", output.Table, "Output format (json, jsonl, csv, or table)")
+	budget := cmd.Int("budget", 0, "Maximum credits to spend (0 for no limit)")
+	cacheTTL := cmd.Duration("cache-ttl", 24*time.Hour, "Reuse cached follow lists younger than this (0 to disable)")
+
+	if err := cmd.Parse(os.Args[3:]); err != nil {
+		fmt.Printf("Error parsing flags: %v\n", err)
+		cmd.PrintDefaults()
+		os.Exit(1)
+	}
+
+	users := parseTwitchUsers(append(strings.Split(*usersFlag, ","), cmd.Args()...))
+	only := parseTwitchUsers(strings.Split(*onlyFlag, ","))
+	not := parseTwitchUsers(strings.Split(*notFlag, ","))
+	if len(not) > 0 && len(only) == 0 {
+		fmt.Println("Error: --not requires --only")
+		os.Exit(1)
+	}
+	// Users named in the selector are fetched even if --users omits them
+	for _, u := range append(append([]string{}, only...), not...) {
+		if !slices.Contains(users, u) {
+			users = append(users, u)
+		}
+	}
+	if len(users) < 2 {
+		fmt.Println("Error: at least two usernames are required")
+		cmd.PrintDefaults()
+		os.Exit(1)
+	}
+
+	if *direction != graph.Followers && *direction != graph.Followage {
+		fmt.Println("Error: direction must be followers or followage")
+		os.Exit(1)
+	}
+	switch *show {
+	case "all", "common", "partial", "only", "similarity":
+	default:
+		fmt.Println("Error: show must be one of all, common, partial, only, or similarity")
+		os.Exit(1)
+	}
+	if _, err := output.ParseFormat(*format); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	client, err := getClient()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	lister, err := newFollowLister(client, *cacheTTL, *budget)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	lists := make(map[string][]follows.Follow, len(users))
+	for _, u := range users {
+		list, err := lister.List(u, *direction)
+		if errors.Is(err, graph.ErrBudget) {
+			fmt.Printf("Error: credit budget of %d reached before fetching %s\n", *budget, u)
+			os.Exit(1)
+		}
+		if err != nil {
+			fmt.Printf("Error: failed to fetch %s of %s: %v\n", *direction, u, err)
+			os.Exit(1)
+		}
+		lists[u] = list
+	}
+
+	c := follows.Compare(users, lists)
+
+	// A selector replaces the --show sets
+	selecting := len(only) > 0
+	showAll := *show == "all" && !selecting
+
+	var members []follows.Member
+	switch {
+	case selecting:
+		members = c.Select(only, not)
+	case *show == "common":
+		members = c.Filter(follows.Common)
+	case *show == "partial":
+		members = c.Filter(follows.Partial)
+	case *show == "only":
+		for _, m := range c.Members {
+			if strings.HasPrefix(m.Set, follows.OnlyPrefix) {
+				members = append(members, m)
+			}
+		}
+	case *show == "similarity":
+		if err := writeOutput(*format, c.Similarities); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	default:
+		members = c.Members
+	}
+	if members == nil {
+		members = []follows.Member{}
+	}
+
+	switch {
+	case showAll && *format == output.JSON:
+		err = writeOutput(*format, c)
+	case *format == output.Table || *format == output.CSV:
+		err = writeOutput(*format, follows.MemberTable{Users: users, Members: members})
+	default:
+		err = writeOutput(*format, members)
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if showAll && *format == output.Table {
+		fmt.Println()
+		if err := writeOutput(*format, c.Similarities); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+}
+
+// parseTwitchUsers normalises a list of usernames, dropping blanks and
+// duplicates
+func parseTwitchUsers(list []string) []string {
+	var users []string
+	for _, u := range list {
+		if strings.TrimSpace(u) == "" {
+			continue
+		}
+		name, err := api.NormalizeUsername(api.PlatformTwitch, u)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if !slices.Contains(users, name) {
+			users = append(users, name)
+		}
+	}
+	return users
+}
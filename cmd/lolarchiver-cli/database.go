@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/breach"
+	"github.com/ivan9253/lolarchiver-cli/pkg/output"
+	"github.com/ivan9253/lolarchiver-cli/pkg/redact"
+)
+
+// printDatabaseResults parses a database lookup response, merges identical
+// records across sources and prints the summary and/or grouped view
+func printDatabaseResults(body []byte, summary bool, groupBy, format string) error {
+	hits, err := breach.Parse(body)
+	if err != nil {
+		return err
+	}
+	hits, removed := breach.Dedupe(hits)
+	if removed > 0 {
+		fmt.Fprintf(os.Stderr, "%d duplicate record(s) merged across sources\n", removed)
+	}
+
+	if summary {
+		// Counts hold no personal data but their columns are named after
+		// personal fields, so they bypass redaction
+		if err := output.Write(os.Stdout, format, breach.Summarise(hits)); err != nil {
+			return err
+		}
+	}
+
+	var grouped interface{}
+	switch groupBy {
+	case "source":
+		grouped = breach.GroupBySource(hits)
+	case "field":
+		values := breach.GroupByField(hits)
+		if globals.redactor != nil {
+			// The value column holds every category, so mask by category
+			// rather than by column name
+			for i := range values {
+				values[i].Value = globals.redactor.Field(redact.KindOf(values[i].Category), values[i].Value)
+			}
+		}
+		grouped = values
+	default:
+		return nil
+	}
+
+	if summary && format == output.Table {
+		fmt.Println()
+	}
+	return writeOutput(format, grouped)
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/api"
+	"github.com/ivan9253/lolarchiver-cli/pkg/archive"
+	"github.com/ivan9253/lolarchiver-cli/pkg/diff"
+)
+
+// diffResult is the JSON form of a diff run
+type diffResult struct {
+	Kind     string        `json:"kind"`
+	Key      string        `json:"key"`
+	Previous *time.Time    `json:"previous,omitempty"`
+	Current  time.Time     `json:"current"`
+	Changes  []diff.Change `json:"changes"`
+}
+
+func handleDiff() {
+	if len(os.Args) < 3 {
+		fmt.Println("Expected 'twitter' or 'twitch' subcommand")
+		os.Exit(1)
+	}
+
+	cmd := flag.NewFlagSet("diff", flag.ExitOnError)
+	asJSON := cmd.Bool("json", false, "Print changes as JSON")
+	noSave := cmd.Bool("no-save", false, "Do not archive the current response")
+
+	var kind, key string
+	var fetch func(client *api.Client) (*api.Response, error)
+
+	switch os.Args[2] {
+	case "twitter":
+		handle := cmd.String("handle", "", "Twitter handle")
+		id := cmd.Int64("id", 0, "Twitter user ID")
+		byOld := cmd.Bool("by-old", false, "Search by old usernames")
+		parseDiffFlags(cmd)
+
+		if *handle == "" && *id == 0 {
+			fmt.Println("Error: Either handle or id must be provided")
+			cmd.PrintDefaults()
+			os.Exit(1)
+		}
+
+		// By-old lookups return different results and must not be diffed
+		// against normal ones
+		kind, key = "twitter", twitterArchiveKey(*handle)
+		if *byOld {
+			kind = "twitter-by-old"
+		}
+		if *id != 0 {
+			key = strconv.FormatInt(*id, 10)
+		}
+		fetch = func(client *api.Client) (*api.Response, error) {
+			return client.TwitterHistoryLookup(*handle, *id, *byOld)
+		}
+	case "twitch":
+		username := cmd.String("username", "", "Twitch username")
+		mode := cmd.String("mode", "username", "Mode (username, utype, or btype)")
+		parseDiffFlags(cmd)
+
+		if *username == "" {
+			fmt.Println("Error: username is required")
+			cmd.PrintDefaults()
+			os.Exit(1)
+		}
+
+		historyMode, err := api.ParseHistoryMode(*mode)
+		if err != nil || historyMode == api.HistoryModeAll {
+			fmt.Println("Error: mode must be one of username, utype, or btype")
+			os.Exit(1)
+		}
+
+		// Normalised so that "@Foo" and "foo" share one archive slot
+		name, err := api.NormalizeUsername(api.PlatformTwitch, *username)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		kind, key = "twitch-history-"+string(historyMode), name
+		fetch = func(client *api.Client) (*api.Response, error) {
+			return client.TwitchUserHistory(*username, historyMode)
+		}
+	default:
+		fmt.Printf("Unknown subcommand: %s\n", os.Args[2])
+		os.Exit(1)
+	}
+
+	client, err := getClient()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	previous, err := archive.Latest(kind, key)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	resp, err := fetch(client)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if resp.StatusCode != 200 {
+		fmt.Printf("Error: Unexpected response (Status %d)\n", resp.StatusCode)
+		if len(resp.Body) > 0 {
+			printBody(resp.Body)
+		}
+		os.Exit(1)
+	}
+
+	result := diffResult{Kind: kind, Key: key, Current: time.Now().UTC()}
+	if previous != nil {
+		result.Previous = &previous.Time
+		result.Changes, err = diff.Bodies(previous.Body, resp.Body)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if !*noSave {
+		snap, err := archive.Save(kind, key, resp.Body)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		result.Current = snap.Time
+	}
+
+	if *asJSON {
+		if result.Changes == nil {
+			result.Changes = []diff.Change{}
+		}
+		data, _ := json.MarshalIndent(redactValue(result), "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+
+	if previous == nil {
+		fmt.Println("No previous snapshot found; the current response is now the baseline")
+		return
+	}
+
+	fmt.Printf("Comparing %s snapshot from %s with current response\n", key, previous.Time.Local().Format(time.DateTime))
+	if len(result.Changes) == 0 {
+		fmt.Println("No changes")
+		return
+	}
+	for _, change := range result.Changes {
+		switch change.Op {
+		case diff.Added:
+			fmt.Printf("+ %s: %s\n", change.Path, compactJSON(change.New))
+		case diff.Removed:
+			fmt.Printf("- %s: %s\n", change.Path, compactJSON(change.Old))
+		case diff.Changed:
+			fmt.Printf("~ %s: %s -> %s\n", change.Path, compactJSON(change.Old), compactJSON(change.New))
+		}
+	}
+}
+
+func parseDiffFlags(cmd *flag.FlagSet) {
+	if err := cmd.Parse(os.Args[3:]); err != nil {
+		fmt.Printf("Error parsing flags: %v\n", err)
+		cmd.PrintDefaults()
+		os.Exit(1)
+	}
+}
+
+func compactJSON(v interface{}) string {
+	data, err := json.Marshal(redactValue(v))
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// twitterArchiveKey normalises a handle so that "@Foo" and "foo" share one
+// archive slot
+func twitterArchiveKey(handle string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(handle), "@"))
+}
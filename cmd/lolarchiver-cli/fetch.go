@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/api"
+	"github.com/ivan9253/lolarchiver-cli/pkg/records"
+)
+
+// fetchBody runs a lookup and returns its body, treating any status other
+// than 200 as an error
+func fetchBody(fetch func() (*api.Response, error)) ([]byte, error) {
+	resp, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("unexpected response (Status %d)", resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+// fetchRecords runs a lookup and extracts its records, treating any status
+// other than 200 as an error
+func fetchRecords(fetch func() (*api.Response, error)) ([]records.Record, error) {
+	body, err := fetchBody(fetch)
+	if err != nil {
+		return nil, err
+	}
+	return records.Extract(body)
+}
+
+// fetchPages collects up to pages pages of an offset-paginated endpoint,
+// dropping duplicate records. It stops early once a page is empty or only
+// repeats records already seen. Records fetched before an error are
+// returned along with it.
+func fetchPages(pages int, fetch func(offset int) (*api.Response, error)) ([]records.Record, error) {
+	var all []records.Record
+	seen := make(map[string]bool)
+	offset := 0
+	for page := 0; page < pages; page++ {
+		var resp *api.Response
+		recs, err := fetchRecords(func() (*api.Response, error) {
+			var err error
+			resp, err = fetch(offset)
+			return resp, err
+		})
+		if err != nil {
+			return all, err
+		}
+		added := 0
+		for _, rec := range recs {
+			if key := rec.Key(); !seen[key] {
+				seen[key] = true
+				all = append(all, rec)
+				added++
+			}
+		}
+		if added == 0 {
+			break
+		}
+		offset = resp.NextOffset(offset, len(recs))
+	}
+	return all, nil
+}
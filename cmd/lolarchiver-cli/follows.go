@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/api"
+	"github.com/ivan9253/lolarchiver-cli/pkg/cache"
+	"github.com/ivan9253/lolarchiver-cli/pkg/follows"
+	"github.com/ivan9253/lolarchiver-cli/pkg/graph"
+)
+
+// followLister fetches Twitch follow lists through the cache, spending at
+// most budget credits (no limit when zero)
+type followLister struct {
+	client *api.Client
+	cache  *cache.Cache
+	budget int
+	spent  int
+}
+
+func newFollowLister(client *api.Client, cacheTTL time.Duration, budget int) (*followLister, error) {
+	c, err := cache.Open("twitch-follows", cacheTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &followLister{client: client, cache: c, budget: budget}, nil
+}
+
+// List returns the followers or followage list of user
+func (l *followLister) List(user, direction string) ([]follows.Follow, error) {
+	key := direction + ":" + user
+	if body, ok := l.cache.Get(key); ok {
+		return follows.Parse(body)
+	}
+
+	path := api.PathTwitchFollowers
+	if direction == graph.Followage {
+		path = api.PathTwitchFollowage
+	}
+	cost := l.client.EstimatedCost(path)
+	if l.budget > 0 && l.spent+cost > l.budget {
+		return nil, graph.ErrBudget
+	}
+
+	var resp *api.Response
+	var err error
+	if direction == graph.Followage {
+		resp, err = l.client.TwitchFollowage(user)
+	} else {
+		resp, err = l.client.TwitchFollowers(user)
+	}
+	if err != nil {
+		return nil, err
+	}
+	l.spent += cost
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("unexpected response (Status %d)", resp.StatusCode)
+	}
+
+	// The list was paid for, so a cache failure only costs a refetch
+	if err := l.cache.Put(key, resp.Body); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to cache %s of %s: %v\n", direction, user, err)
+	}
+	return follows.Parse(resp.Body)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/api"
+	"github.com/ivan9253/lolarchiver-cli/pkg/graph"
+)
+
+func handleTwitchGraph(cmd *flag.FlagSet) {
+	username := cmd.String("username", "", "Seed Twitch username")
+	depth := cmd.Int("depth", 1, "Number of hops to expand from the seed")
+	direction := cmd.String("direction", "both", "Lists to follow (followers, followage, or both)")
+	budget := cmd.Int("budget", 50, "Maximum credits to spend (0 for no limit)")
+	maxNodes := cmd.Int("max-nodes", 5000, "Maximum number of nodes (0 for no limit)")
+	format := cmd.String("format", graph.GraphML, "Export format (graphml, gexf, dot, or csv)")
+	outFile := cmd.String("output", "", "Write the graph to this file instead of stdout")
+	cacheTTL := cmd.Duration("cache-ttl", 24*time.Hour, "Reuse cached follow lists younger than this (0 to disable)")
+
+	if err := cmd.Parse(os.Args[3:]); err != nil {
+		fmt.Printf("Error parsing flags: %v\n", err)
+		cmd.PrintDefaults()
+		os.Exit(1)
+	}
+
+	if *username == "" {
+		fmt.Println("Error: username is required")
+		cmd.PrintDefaults()
+		os.Exit(1)
+	}
+
+	// Follow lists name accounts in lowercase, so the seed must match
+	seed, err := api.NormalizeUsername(api.PlatformTwitch, *username)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Check the format before crawling rather than after spending credits
+	if err := graph.ParseFormat(*format); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var directions []string
+	switch *direction {
+	case "both":
+		directions = []string{graph.Followers, graph.Followage}
+	case graph.Followers, graph.Followage:
+		directions = []string{*direction}
+	default:
+		fmt.Println("Error: direction must be one of followers, followage, or both")
+		os.Exit(1)
+	}
+
+	if *depth < 1 {
+		fmt.Println("Error: depth must be at least 1")
+		os.Exit(1)
+	}
+
+	client, err := getClient()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	lister, err := newFollowLister(client, *cacheTTL, *budget)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	opts := graph.CrawlOptions{
+		Depth:      *depth,
+		MaxNodes:   *maxNodes,
+		Directions: directions,
+		OnError: func(user, dir string, err error) {
+			fmt.Fprintf(os.Stderr, "Warning: skipping %s of %s: %v\n", dir, user, err)
+		},
+	}
+	g, err := graph.Crawl(seed, opts, lister.List)
+	if errors.Is(err, graph.ErrBudget) {
+		fmt.Fprintf(os.Stderr, "Credit budget of %d reached, exporting partial graph\n", *budget)
+	} else if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if g.Truncated {
+		fmt.Fprintf(os.Stderr, "Node limit of %d reached, some accounts were left out\n", *maxNodes)
+	}
+
+	if *outFile == "" {
+		err = g.Write(os.Stdout, *format)
+	} else {
+		err = writeGraphFile(g, *outFile, *format)
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "%d nodes, %d edges, %d credit(s) spent\n", len(g.Nodes()), len(g.Edges()), lister.spent)
+}
+
+// writeGraphFile exports g to path. A failed close may mean the export was
+// not fully written, so it is reported like a write error.
+func writeGraphFile(g *graph.Graph, path, format string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = g.Write(f, format)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
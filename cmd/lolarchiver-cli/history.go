@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/api"
+	"github.com/ivan9253/lolarchiver-cli/pkg/records"
+	"github.com/ivan9253/lolarchiver-cli/pkg/timeline"
+)
+
+// historyReport combines every history mode of a Twitch account
+type historyReport struct {
+	Username string           `json:"username"`
+	Sections []historySection `json:"sections"`
+}
+
+// historySection holds the entries returned for one mode
+type historySection struct {
+	Mode        api.HistoryMode  `json:"mode"`
+	Description string           `json:"description"`
+	StatusCode  int              `json:"status_code,omitempty"`
+	Error       string           `json:"error,omitempty"`
+	Entries     []historyEntry   `json:"entries"`
+	Records     []records.Record `json:"records"`
+}
+
+// historyEntry is a single value the account had, with its date if known
+type historyEntry struct {
+	Time  *time.Time `json:"time,omitempty"`
+	Value string     `json:"value"`
+}
+
+// buildHistoryReport turns the responses of TwitchUserHistoryAll into a
+// report. Modes missing from results are marked as failed.
+func buildHistoryReport(username string, results map[api.HistoryMode]*api.Response) historyReport {
+	report := historyReport{Username: username}
+	for _, mode := range api.HistoryModes {
+		section := historySection{
+			Mode:        mode,
+			Description: mode.Description(),
+			Entries:     []historyEntry{},
+			Records:     []records.Record{},
+		}
+		resp := results[mode]
+		if resp == nil {
+			section.Error = "request failed"
+			report.Sections = append(report.Sections, section)
+			continue
+		}
+		section.StatusCode = resp.StatusCode
+		if resp.StatusCode == 200 {
+			recs, err := records.Extract(resp.Body)
+			if err == nil && recs != nil {
+				section.Records = recs
+			}
+			keys := historyValueKeys(mode)
+			for _, rec := range section.Records {
+				entry := historyEntry{Value: rec.String(keys...)}
+				if entry.Value == "" {
+					entry.Value = compactJSON(rec)
+				}
+				if t, ok := rec.Time(records.TimeKeys...); ok {
+					entry.Time = &t
+				}
+				section.Entries = append(section.Entries, entry)
+			}
+		}
+		report.Sections = append(report.Sections, section)
+	}
+	return report
+}
+
+// historyValueKeys returns the fields holding the value of a history entry,
+// preferring the ones specific to the mode
+func historyValueKeys(mode api.HistoryMode) []string {
+	switch mode {
+	case api.HistoryModeUserType:
+		return append([]string{"utype", "user_type", "type"}, timeline.NameKeys...)
+	case api.HistoryModeBroadcasterType:
+		return append([]string{"btype", "broadcaster_type", "type"}, timeline.NameKeys...)
+	}
+	return timeline.NameKeys
+}
+
+func printHistoryReport(report historyReport, asJSON bool) {
+	if asJSON {
+		data, _ := json.MarshalIndent(redactValue(report), "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+
+	fmt.Printf("Twitch history for %s\n", report.Username)
+	for _, section := range report.Sections {
+		fmt.Printf("\n%s:\n", section.Description)
+		switch {
+		case section.Error != "":
+			fmt.Printf("  Error: %s\n", section.Error)
+		case section.StatusCode != 200:
+			fmt.Printf("  Error: Unexpected response (Status %d)\n", section.StatusCode)
+		case len(section.Entries) == 0:
+			fmt.Println("  No data found")
+		default:
+			for _, entry := range section.Entries {
+				when := "unknown date"
+				if entry.Time != nil {
+					when = entry.Time.Format(time.DateOnly)
+				}
+				fmt.Printf("  %-12s  %s\n", when, redactValue(entry.Value))
+			}
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/api"
+	"github.com/ivan9253/lolarchiver-cli/pkg/records"
+)
+
+func TestHistoryValueKeys(t *testing.T) {
+	rec := records.Record{"username": "foo", "utype": "staff", "broadcaster_type": "affiliate", "type": "generic"}
+	tests := []struct {
+		mode api.HistoryMode
+		want string
+	}{
+		{api.HistoryModeUsername, "foo"},
+		{api.HistoryModeUserType, "staff"},
+		{api.HistoryModeBroadcasterType, "affiliate"},
+	}
+	for _, tt := range tests {
+		if got := rec.String(historyValueKeys(tt.mode)...); got != tt.want {
+			t.Errorf("value for %s = %q, want %q", tt.mode, got, tt.want)
+		}
+	}
+
+	// Without a mode-specific field the name fields are used
+	if got := (records.Record{"name": "bar"}).String(historyValueKeys(api.HistoryModeUserType)...); got != "bar" {
+		t.Errorf("utype value = %q, want the name", got)
+	}
+}
+
+func TestBuildHistoryReport(t *testing.T) {
+	// utype is missing, as when its request failed
+	results := map[api.HistoryMode]*api.Response{
+		api.HistoryModeUsername:        {StatusCode: 200, Body: []byte(`{"results": [{"date": "2020-01-01", "username": "old"}, {"note": "undated"}]}`)},
+		api.HistoryModeBroadcasterType: {StatusCode: 402, Body: []byte(`{"error": "no credits"}`)},
+	}
+
+	report := buildHistoryReport("foo", results)
+	if len(report.Sections) != len(api.HistoryModes) {
+		t.Fatalf("report has %d sections, want %d", len(report.Sections), len(api.HistoryModes))
+	}
+
+	username := report.Sections[0]
+	var values []string
+	for _, e := range username.Entries {
+		values = append(values, e.Value)
+	}
+	if want := []string{"old", `{"note":"undated"}`}; !reflect.DeepEqual(values, want) {
+		t.Errorf("username values = %v, want %v", values, want)
+	}
+	if username.Entries[0].Time == nil || username.Entries[1].Time != nil {
+		t.Errorf("only the first entry should be dated: %+v", username.Entries)
+	}
+
+	if s := report.Sections[1]; s.Mode != api.HistoryModeUserType || s.Error == "" || len(s.Entries) != 0 {
+		t.Errorf("utype section = %+v, want a failed section", s)
+	}
+	if s := report.Sections[2]; s.StatusCode != 402 || s.Error != "" || len(s.Entries) != 0 || s.Records == nil {
+		t.Errorf("btype section = %+v, want status 402 and no entries", s)
+	}
+}
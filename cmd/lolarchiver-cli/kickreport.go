@@ -0,0 +1,83 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/api"
+	"github.com/ivan9253/lolarchiver-cli/pkg/kickreport"
+	"github.com/ivan9253/lolarchiver-cli/pkg/output"
+	"github.com/ivan9253/lolarchiver-cli/pkg/records"
+)
+
+func handleKickReport(cmd *flag.FlagSet) {
+	username := cmd.String("username", "", "Kick username")
+	pages := cmd.Int("pages", 5, "Maximum pages of messages to fetch")
+	format := cmd.String("format", output.Table, "Output format (json, jsonl, csv, or table)")
+
+	if err := cmd.Parse(os.Args[3:]); err != nil {
+		fmt.Printf("Error parsing flags: %v\n", err)
+		cmd.PrintDefaults()
+		os.Exit(1)
+	}
+
+	if *username == "" {
+		fmt.Println("Error: username is required")
+		cmd.PrintDefaults()
+		os.Exit(1)
+	}
+
+	if _, err := output.ParseFormat(*format); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	client, err := getClient()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	report := kickreport.New(*username)
+	lookups := []struct {
+		name  string
+		fetch func() ([]records.Record, error)
+		apply func(recs []records.Record)
+	}{
+		{"mods", func() ([]records.Record, error) {
+			return fetchRecords(func() (*api.Response, error) { return client.KickUserModChannels(*username) })
+		}, report.AddMods},
+		{"subscriptions", func() ([]records.Record, error) {
+			return fetchRecords(func() (*api.Response, error) { return client.KickUserSubscribers(*username) })
+		}, report.AddSubscriptions},
+		{"timeouts", func() ([]records.Record, error) {
+			return fetchRecords(func() (*api.Response, error) { return client.KickUserTimeouts(*username) })
+		}, report.AddTimeouts},
+		{"messages", func() ([]records.Record, error) {
+			return fetchPages(*pages, func(offset int) (*api.Response, error) {
+				return client.KickUserMessages(*username, offset)
+			})
+		}, report.AddMessages},
+	}
+
+	for _, l := range lookups {
+		recs, err := l.fetch()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s lookup failed: %v\n", l.name, err)
+		}
+		l.apply(recs)
+		report.AddSource(l.name, len(recs), err)
+	}
+	report.Sort()
+
+	if *format == output.JSON {
+		err = writeOutput(*format, report)
+	} else {
+		err = writeOutput(*format, report.Channels)
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
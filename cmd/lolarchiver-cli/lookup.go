@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/target"
+)
+
+// lookupRoute lists the commands a resolved target can be sent to. The
+// first endpoint is the default.
+type lookupRoute struct {
+	endpoints []string
+	args      func(t target.Target, endpoint string) []string
+}
+
+// lookupRoutes maps "platform/kind" to its route
+var lookupRoutes = map[string]lookupRoute{
+	target.Twitter + "/" + target.Handle: {
+		endpoints: []string{"history"},
+		args: func(t target.Target, _ string) []string {
+			return []string{"twitter", "--handle", t.Value}
+		},
+	},
+	target.Twitter + "/" + target.ID: {
+		endpoints: []string{"history"},
+		args: func(t target.Target, _ string) []string {
+			return []string{"twitter", "--id", t.Value}
+		},
+	},
+	target.Twitch + "/" + target.Username: {
+		endpoints: []string{"messages", "timeouts", "history", "followage", "followers", "graph"},
+		args: func(t target.Target, endpoint string) []string {
+			return []string{"twitch", endpoint, "--username", t.Value}
+		},
+	},
+	target.Kick + "/" + target.Username: {
+		endpoints: []string{"messages", "timeouts", "mods", "subscribers", "report"},
+		args: func(t target.Target, endpoint string) []string {
+			return []string{"kick", endpoint, "--username", t.Value}
+		},
+	},
+	target.YouTube + "/" + target.ChannelID: {
+		endpoints: []string{"comments"},
+		args: func(t target.Target, _ string) []string {
+			return []string{"youtube", "comments", "--channel-id", t.Value}
+		},
+	},
+	target.YouTube + "/" + target.Handle: {
+		endpoints: []string{"comments"},
+		args: func(t target.Target, _ string) []string {
+			return []string{"youtube", "comments", "--handle", t.Value}
+		},
+	},
+	target.YouTube + "/" + target.UserID: {
+		endpoints: []string{"comments"},
+		args: func(t target.Target, _ string) []string {
+			return []string{"youtube", "comments", "--user-id", t.Value}
+		},
+	},
+	target.YouTube + "/" + target.CommentID: {
+		endpoints: []string{"replies"},
+		args: func(t target.Target, _ string) []string {
+			return []string{"youtube", "replies", "--comment-id", t.Value}
+		},
+	},
+}
+
+// lookupArgs holds the arguments of the lookup command
+type lookupArgs struct {
+	url      string
+	endpoint string
+	dryRun   bool
+	rest     []string
+}
+
+// parseLookupArgs splits the lookup arguments into its own options
+// (--endpoint, --dry-run), the URL and the options passed on to the
+// command. The URL is the first argument that looks like one, so options
+// and their values may appear anywhere.
+func parseLookupArgs(args []string) (lookupArgs, error) {
+	var parsed lookupArgs
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		switch {
+		case strings.HasPrefix(arg, "-") && name == "endpoint":
+			if !hasValue {
+				if i+1 >= len(args) {
+					return parsed, fmt.Errorf("--endpoint requires a value")
+				}
+				i++
+				value = args[i]
+			}
+			parsed.endpoint = value
+		case strings.HasPrefix(arg, "-") && name == "dry-run" && !hasValue:
+			parsed.dryRun = true
+		case !strings.HasPrefix(arg, "-") && parsed.url == "" && target.LooksLikeURL(arg):
+			parsed.url = arg
+		default:
+			parsed.rest = append(parsed.rest, arg)
+		}
+	}
+	if parsed.url == "" {
+		return parsed, fmt.Errorf("a URL is required")
+	}
+	return parsed, nil
+}
+
+// handleLookup resolves a pasted URL and runs the matching command
+func handleLookup() {
+	args, err := parseLookupArgs(os.Args[2:])
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		fmt.Println("Usage: lolarchiver-cli lookup URL [--endpoint NAME] [--dry-run] [command options]")
+		os.Exit(1)
+	}
+
+	t, err := target.Parse(args.url)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	route, ok := lookupRoutes[t.Platform+"/"+t.Kind]
+	if !ok {
+		fmt.Printf("Error: no command accepts a %s\n", t)
+		os.Exit(1)
+	}
+	endpoint := args.endpoint
+	if endpoint == "" {
+		endpoint = route.endpoints[0]
+	}
+	valid := false
+	for _, e := range route.endpoints {
+		valid = valid || e == endpoint
+	}
+	if !valid {
+		fmt.Printf("Error: endpoint for a %s must be one of %s\n", t, strings.Join(route.endpoints, ", "))
+		os.Exit(1)
+	}
+
+	cmdArgs := append(route.args(t, endpoint), args.rest...)
+	fmt.Fprintf(os.Stderr, "Resolved %s\n", t)
+	if args.dryRun {
+		fmt.Printf("lolarchiver-cli %s\n", shellJoin(cmdArgs))
+		return
+	}
+
+	os.Args = append([]string{os.Args[0]}, cmdArgs...)
+	run()
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLookupArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want lookupArgs
+		ok   bool
+	}{
+		{
+			"url first",
+			[]string{"https://twitch.tv/x", "--format", "json"},
+			lookupArgs{url: "https://twitch.tv/x", rest: []string{"--format", "json"}},
+			true,
+		},
+		{
+			"options before the url",
+			[]string{"--format", "json", "https://twitch.tv/x"},
+			lookupArgs{url: "https://twitch.tv/x", rest: []string{"--format", "json"}},
+			true,
+		},
+		{
+			"own options anywhere",
+			[]string{"--offset", "100", "--endpoint", "timeouts", "kick.com/x", "--dry-run"},
+			lookupArgs{url: "kick.com/x", endpoint: "timeouts", dryRun: true, rest: []string{"--offset", "100"}},
+			true,
+		},
+		{
+			"endpoint with equals",
+			[]string{"--endpoint=followers", "twitch.tv/x"},
+			lookupArgs{url: "twitch.tv/x", endpoint: "followers"},
+			true,
+		},
+		{"no url", []string{"--format", "json"}, lookupArgs{}, false},
+		{"endpoint without value", []string{"twitch.tv/x", "--endpoint"}, lookupArgs{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseLookupArgs(tt.args)
+			if (err == nil) != tt.ok {
+				t.Fatalf("parseLookupArgs(%q) error = %v, want ok %v", tt.args, err, tt.ok)
+			}
+			if tt.ok && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseLookupArgs(%q) = %+v, want %+v", tt.args, got, tt.want)
+			}
+		})
+	}
+}
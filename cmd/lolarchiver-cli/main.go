@@ -1,22 +1,113 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/ivan9253/lolarchiver-cli/pkg/api"
+	"github.com/ivan9253/lolarchiver-cli/pkg/audit"
 	"github.com/ivan9253/lolarchiver-cli/pkg/config"
+	"github.com/ivan9253/lolarchiver-cli/pkg/output"
+	"github.com/ivan9253/lolarchiver-cli/pkg/records"
+	"github.com/ivan9253/lolarchiver-cli/pkg/redact"
+	"github.com/ivan9253/lolarchiver-cli/pkg/target"
+	"github.com/ivan9253/lolarchiver-cli/pkg/timeline"
 )
 
 const (
 	version = "1.0.0"
 )
 
+// globalFlags holds the options accepted by every command
+type globalFlags struct {
+	caseName string
+	reason   string
+	redactor *redact.Redactor
+}
+
+var globals globalFlags
+
+// parseGlobalFlags extracts the global options from os.Args so that the
+// per-command flag sets never see them
+func parseGlobalFlags() error {
+	args := []string{os.Args[0]}
+	for i := 1; i < len(os.Args); i++ {
+		arg := os.Args[i]
+		if arg == "--" {
+			args = append(args, os.Args[i:]...)
+			break
+		}
+		if !strings.HasPrefix(arg, "-") {
+			args = append(args, arg)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		switch name {
+		case "case":
+			if !hasValue {
+				if i+1 >= len(os.Args) {
+					return fmt.Errorf("flag needs an argument: -%s", name)
+				}
+				i++
+				value = os.Args[i]
+			}
+			globals.caseName = value
+		case "reason":
+			if !hasValue {
+				if i+1 >= len(os.Args) {
+					return fmt.Errorf("flag needs an argument: -%s", name)
+				}
+				i++
+				value = os.Args[i]
+			}
+			globals.reason = value
+		case "redact":
+			if !hasValue {
+				value = redact.Full
+			}
+			var key []byte
+			if value == redact.Hashed {
+				dir, err := config.Dir()
+				if err != nil {
+					return err
+				}
+				if key, err = redact.LoadKey(dir); err != nil {
+					return err
+				}
+			}
+			r, err := redact.New(value, key)
+			if err != nil {
+				return err
+			}
+			globals.redactor = r
+		default:
+			args = append(args, arg)
+		}
+	}
+	os.Args = args
+	return nil
+}
+
 func main() {
+	if err := parseGlobalFlags(); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(os.Args) < 2 {
+		printUsage()
+		os.Exit(1)
+	}
+
+	run()
+}
+
+// run dispatches os.Args to the matching command
+func run() {
 	creditsCmd := flag.NewFlagSet("credits", flag.ExitOnError)
 	youtubeCmd := flag.NewFlagSet("youtube", flag.ExitOnError)
 	twitterCmd := flag.NewFlagSet("twitter", flag.ExitOnError)
@@ -26,11 +117,6 @@ func main() {
 	databaseCmd := flag.NewFlagSet("database", flag.ExitOnError)
 	configCmd := flag.NewFlagSet("config", flag.ExitOnError)
 
-	if len(os.Args) < 2 {
-		printUsage()
-		os.Exit(1)
-	}
-
 	switch os.Args[1] {
 	case "credits":
 		creditsCmd.Parse(os.Args[2:])
@@ -39,10 +125,15 @@ func main() {
 		youtubeCmd.Parse(os.Args[2:])
 		handleYouTube(youtubeCmd)
 	case "twitter":
+		if len(os.Args) > 2 && os.Args[2] == "map" {
+			handleTwitterMap()
+			return
+		}
+
 		handle := twitterCmd.String("handle", "", "Twitter handle")
 		id := twitterCmd.Int64("id", 0, "Twitter user ID")
 		byOld := twitterCmd.Bool("by-old", false, "Search by old usernames")
-		
+
 		if err := twitterCmd.Parse(os.Args[2:]); err != nil {
 			fmt.Printf("Error parsing flags: %v\n", err)
 			twitterCmd.PrintDefaults()
@@ -90,7 +181,7 @@ func main() {
 		if len(resp.Body) == 0 {
 			fmt.Println("No data found")
 		} else {
-			fmt.Println(string(resp.Body))
+			printBody(resp.Body)
 		}
 	case "twitch":
 		handleTwitch(twitchCmd)
@@ -101,6 +192,28 @@ func main() {
 		handleReverse(reverseCmd)
 	case "database":
 		handleDatabase(databaseCmd)
+	case "watch":
+		handleWatch()
+	case "notify":
+		handleNotify()
+	case "diff":
+		handleDiff()
+	case "timeline":
+		handleTimeline()
+	case "case":
+		handleCase()
+	case "audit":
+		handleAudit()
+	case "validate":
+		handleValidate()
+	case "lookup":
+		handleLookup()
+	case "report":
+		handleReport()
+	case "stats":
+		handleStats()
+	case "moderation-history":
+		handleModerationHistory()
 	case "config":
 		configCmd.Parse(os.Args[2:])
 		handleConfig(configCmd)
@@ -127,9 +240,25 @@ func printUsage() {
 	fmt.Println("  kick        Kick-related operations")
 	fmt.Println("  reverse     Reverse lookup operations (email/phone)")
 	fmt.Println("  database    Database search operations")
+	fmt.Println("  watch       Poll for new messages or timeouts")
+	fmt.Println("  notify      List or test configured notifiers")
+	fmt.Println("  diff        Compare account history with the last snapshot")
+	fmt.Println("  timeline    Merge activity across platforms into one timeline")
+	fmt.Println("  case        Manage investigation cases (new, open, close, ls)")
+	fmt.Println("  audit       Verify or export the audit log")
+	fmt.Println("  validate    Check and normalise phones, emails or usernames offline")
+	fmt.Println("  lookup      Resolve a pasted profile or comment URL and query it")
+	fmt.Println("  report      Render a case or archived results as HTML or Markdown")
+	fmt.Println("  stats       Activity heatmap, channels, terms and emotes for a user")
+	fmt.Println("  moderation-history")
+	fmt.Println("              Summarise Twitch and Kick timeouts and bans by channel and month")
 	fmt.Println("  config      Configuration operations")
 	fmt.Println("  version     Show version information")
 	fmt.Println("  help        Show this help message")
+	fmt.Println("\nGlobal options:")
+	fmt.Println("  --case NAME      Record requests and results in the given case")
+	fmt.Println("  --reason TEXT    Justification recorded in the audit log")
+	fmt.Println("  --redact[=STYLE] Mask personal data in output (full, partial, or hashed)")
 	fmt.Println("\nUse 'lolarchiver-cli [command] --help' for more information about a command")
 }
 
@@ -138,7 +267,45 @@ func getClient() (*api.Client, error) {
 	if err != nil {
 		return nil, err
 	}
-	return api.NewClient(apiKey), nil
+
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	client := api.NewClient(apiKey)
+	client.SetCreditCosts(cfg.CreditCosts)
+	client.SetDefaultRegion(cfg.Region)
+
+	c, err := activeCase()
+	if err != nil {
+		return nil, err
+	}
+
+	auditPath, err := audit.DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+	configDir, err := config.Dir()
+	if err != nil {
+		return nil, err
+	}
+	auditKey, err := audit.LoadKey(configDir)
+	if err != nil {
+		return nil, err
+	}
+	auditCtx := api.AuditContext{
+		Operator: cfg.OperatorName(),
+		Profile:  config.ProfileName(),
+		Reason:   globals.reason,
+	}
+	if c != nil {
+		auditCtx.Case = c.Name
+		attachCase(client, c)
+	}
+	client.SetAudit(audit.Open(auditPath, auditKey), auditCtx)
+	client.SetGuard(policyGuard(cfg.LookupPolicy(), auditCtx.Case))
+	return client, nil
 }
 
 func handleCredits(cmd *flag.FlagSet) {
@@ -154,7 +321,7 @@ func handleCredits(cmd *flag.FlagSet) {
 		os.Exit(1)
 	}
 
-	fmt.Println(string(resp.Body))
+	printBody(resp.Body)
 }
 
 func handleYouTube(cmd *flag.FlagSet) {
@@ -168,10 +335,8 @@ func handleYouTube(cmd *flag.FlagSet) {
 
 	switch os.Args[2] {
 	case "comments":
-		commentsCmd.Parse(os.Args[3:])
 		handleYouTubeComments(commentsCmd)
 	case "replies":
-		repliesCmd.Parse(os.Args[3:])
 		handleYouTubeReplies(repliesCmd)
 	default:
 		fmt.Printf("Unknown subcommand: %s\n", os.Args[2])
@@ -179,11 +344,61 @@ func handleYouTube(cmd *flag.FlagSet) {
 	}
 }
 
+// resolveYouTubeFlags fills in the user-id, handle or channel-id flag from
+// a pasted identifier, exiting if it cannot be resolved or the flags were
+// also given. An empty identifier leaves the flags unchanged.
+func resolveYouTubeFlags(identifier string, userID, handle, channelID *string) {
+	if identifier == "" {
+		return
+	}
+	if *userID != "" || *handle != "" || *channelID != "" {
+		fmt.Println("Error: Give either an identifier or user-id, handle, and channel-id, not both")
+		os.Exit(1)
+	}
+	t, err := target.ParseYouTube(identifier)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	switch t.Kind {
+	case target.ChannelID:
+		*channelID = t.Value
+	case target.Handle:
+		*handle = t.Value
+	case target.UserID:
+		*userID = t.Value
+	}
+	fmt.Fprintf(os.Stderr, "Resolved %s\n", t)
+}
+
 func handleYouTubeComments(cmd *flag.FlagSet) {
 	userID := cmd.String("user-id", "", "YouTube user ID")
 	handle := cmd.String("handle", "", "YouTube handle")
 	channelID := cmd.String("channel-id", "", "YouTube channel ID")
 	offset := cmd.Int("offset", 0, "Pagination offset")
+	withReplies := cmd.Bool("with-replies", false, "Fetch the replies to each comment and print full threads")
+	format := cmd.String("format", threadTree, "Thread output format with --with-replies (tree or json)")
+	concurrency := cmd.Int("concurrency", 4, "Reply lookups to run in parallel")
+	rate := cmd.Float64("rate", 5, "Maximum reply lookups per second")
+	cacheTTL := cmd.Duration("cache-ttl", 24*time.Hour, "Reuse cached replies younger than this (0 to disable)")
+	tuiMode := cmd.Bool("tui", false, "Browse the results interactively, loading further pages on demand")
+
+	// Accept the identifier before or after the flags
+	args := os.Args[3:]
+	var identifier string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		identifier, args = args[0], args[1:]
+	}
+	if err := cmd.Parse(args); err != nil {
+		fmt.Printf("Error parsing flags: %v\n", err)
+		cmd.PrintDefaults()
+		os.Exit(1)
+	}
+	if identifier == "" && cmd.NArg() > 0 {
+		identifier = cmd.Arg(0)
+	}
+
+	resolveYouTubeFlags(identifier, userID, handle, channelID)
 
 	if *userID == "" && *handle == "" && *channelID == "" {
 		fmt.Println("Error: At least one of user-id, handle, or channel-id must be provided")
@@ -191,23 +406,73 @@ func handleYouTubeComments(cmd *flag.FlagSet) {
 		os.Exit(1)
 	}
 
+	if *withReplies && *format != threadTree && *format != threadJSON {
+		fmt.Println("Error: format must be tree or json")
+		os.Exit(1)
+	}
+	if *tuiMode && *withReplies {
+		fmt.Println("Error: --tui cannot be combined with --with-replies")
+		os.Exit(1)
+	}
+
 	client, err := getClient()
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
 
+	if *tuiMode {
+		browseOrExit(client, "youtube comments "+firstNonEmpty(*handle, *channelID, *userID), *offset, func(offset int) (*api.Response, error) {
+			return client.YouTubeUserComments(*userID, *handle, *channelID, offset)
+		})
+		return
+	}
+
 	resp, err := client.YouTubeUserComments(*userID, *handle, *channelID, *offset)
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	fmt.Println(string(resp.Body))
+	if !*withReplies {
+		printBody(resp.Body)
+		return
+	}
+
+	if resp.StatusCode != 200 {
+		fmt.Printf("Error: Unexpected response (Status %d)\n", resp.StatusCode)
+		if len(resp.Body) > 0 {
+			printBody(resp.Body)
+		}
+		os.Exit(1)
+	}
+	comments, err := records.Extract(resp.Body)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	threads, err := buildThreads(client, comments, threadOptions{
+		concurrency: *concurrency,
+		rate:        *rate,
+		cacheTTL:    *cacheTTL,
+	})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	printThreads(threads, *format)
 }
 
 func handleYouTubeReplies(cmd *flag.FlagSet) {
 	commentID := cmd.String("comment-id", "", "YouTube comment ID")
+
+	if err := cmd.Parse(os.Args[3:]); err != nil {
+		fmt.Printf("Error parsing flags: %v\n", err)
+		cmd.PrintDefaults()
+		os.Exit(1)
+	}
+
 	if *commentID == "" {
 		fmt.Println("Error: comment-id is required")
 		cmd.PrintDefaults()
@@ -226,7 +491,7 @@ func handleYouTubeReplies(cmd *flag.FlagSet) {
 		os.Exit(1)
 	}
 
-	fmt.Println(string(resp.Body))
+	printBody(resp.Body)
 }
 
 func handleTwitch(cmd *flag.FlagSet) {
@@ -235,9 +500,11 @@ func handleTwitch(cmd *flag.FlagSet) {
 	historyCmd := flag.NewFlagSet("history", flag.ExitOnError)
 	followageCmd := flag.NewFlagSet("followage", flag.ExitOnError)
 	followersCmd := flag.NewFlagSet("followers", flag.ExitOnError)
+	graphCmd := flag.NewFlagSet("graph", flag.ExitOnError)
+	compareCmd := flag.NewFlagSet("compare", flag.ExitOnError)
 
 	if len(os.Args) < 3 {
-		fmt.Println("Expected 'messages', 'timeouts', 'history', 'followage', or 'followers' subcommand")
+		fmt.Println("Expected 'messages', 'timeouts', 'history', 'followage', 'followers', 'graph', or 'compare' subcommand")
 		os.Exit(1)
 	}
 
@@ -252,6 +519,10 @@ func handleTwitch(cmd *flag.FlagSet) {
 		handleTwitchFollowage(followageCmd)
 	case "followers":
 		handleTwitchFollowers(followersCmd)
+	case "graph":
+		handleTwitchGraph(graphCmd)
+	case "compare":
+		handleTwitchCompare(compareCmd)
 	default:
 		fmt.Printf("Unknown subcommand: %s\n", os.Args[2])
 		os.Exit(1)
@@ -260,14 +531,29 @@ func handleTwitch(cmd *flag.FlagSet) {
 
 func handleTwitchMessages(cmd *flag.FlagSet) {
 	username := cmd.String("username", "", "Twitch username")
-	server := cmd.String("server", "superserver2", "Server (superserver2 or main)")
+	server := cmd.String("server", "superserver2", "Server (superserver2, main, or auto to query both)")
 	offset := cmd.Int("offset", 0, "Pagination offset")
+	export := cmd.String("export", "", "Write the messages as a chat log (irc, csv, or html)")
+	outFile := cmd.String("output", "", "Write the chat log to this file instead of stdout")
+	pages := cmd.Int("pages", 10, "Maximum pages to fetch with --export, starting at --offset")
+	tuiMode := cmd.Bool("tui", false, "Browse the results interactively, loading further pages on demand")
 
 	if err := cmd.Parse(os.Args[3:]); err != nil {
 		fmt.Printf("Error parsing flags: %v\n", err)
 		cmd.PrintDefaults()
 		os.Exit(1)
 	}
+	validateExport(*export, *outFile, *pages)
+	if *tuiMode && *export != "" {
+		fmt.Println("Error: --tui cannot be combined with --export")
+		os.Exit(1)
+	}
+
+	twitchServer, err := api.ParseTwitchServer(*server)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
 
 	if *username == "" {
 		fmt.Println("Error: username is required")
@@ -281,18 +567,32 @@ func handleTwitchMessages(cmd *flag.FlagSet) {
 		os.Exit(1)
 	}
 
-	resp, err := client.TwitchUserMessages(*username, *server, *offset)
+	if *tuiMode {
+		browseOrExit(client, "twitch messages "+*username, *offset, func(offset int) (*api.Response, error) {
+			return client.TwitchUserMessages(*username, twitchServer, offset)
+		})
+		return
+	}
+
+	if *export != "" {
+		exportOrExit(timeline.Twitch, *username, *offset, *pages, func(offset int) (*api.Response, error) {
+			return client.TwitchUserMessages(*username, twitchServer, offset)
+		}, *export, *outFile)
+		return
+	}
+
+	resp, err := client.TwitchUserMessages(*username, twitchServer, *offset)
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
-
-	fmt.Println(string(resp.Body))
+	printBody(resp.Body)
 }
 
 func handleTwitchTimeouts(cmd *flag.FlagSet) {
 	username := cmd.String("username", "", "Twitch username")
 	offset := cmd.Int("offset", 0, "Pagination offset")
+	tuiMode := cmd.Bool("tui", false, "Browse the results interactively, loading further pages on demand")
 
 	if err := cmd.Parse(os.Args[3:]); err != nil {
 		fmt.Printf("Error parsing flags: %v\n", err)
@@ -312,18 +612,26 @@ func handleTwitchTimeouts(cmd *flag.FlagSet) {
 		os.Exit(1)
 	}
 
+	if *tuiMode {
+		browseOrExit(client, "twitch timeouts "+*username, *offset, func(offset int) (*api.Response, error) {
+			return client.TwitchUserTimeouts(*username, offset)
+		})
+		return
+	}
+
 	resp, err := client.TwitchUserTimeouts(*username, *offset)
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	fmt.Println(string(resp.Body))
+	printBody(resp.Body)
 }
 
 func handleTwitchHistory(cmd *flag.FlagSet) {
 	username := cmd.String("username", "", "Twitch username")
-	mode := cmd.String("mode", "", "Mode (username, utype, or btype)")
+	mode := cmd.String("mode", "username", "Mode (username, utype, btype, or all)")
+	asJSON := cmd.Bool("json", false, "Print the combined report as JSON (with --mode all)")
 
 	if err := cmd.Parse(os.Args[3:]); err != nil {
 		fmt.Printf("Error parsing flags: %v\n", err)
@@ -337,19 +645,39 @@ func handleTwitchHistory(cmd *flag.FlagSet) {
 		os.Exit(1)
 	}
 
+	historyMode, err := api.ParseHistoryMode(*mode)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		cmd.PrintDefaults()
+		os.Exit(1)
+	}
+
 	client, err := getClient()
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	resp, err := client.TwitchUserHistory(*username, *mode)
+	if historyMode == api.HistoryModeAll {
+		results, err := client.TwitchUserHistoryAll(*username)
+		if err != nil {
+			if len(results) == 0 {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		printHistoryReport(buildHistoryReport(*username, results), *asJSON)
+		return
+	}
+
+	resp, err := client.TwitchUserHistory(*username, historyMode)
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	fmt.Println(string(resp.Body))
+	printBody(resp.Body)
 }
 
 func handleTwitchFollowage(cmd *flag.FlagSet) {
@@ -379,7 +707,7 @@ func handleTwitchFollowage(cmd *flag.FlagSet) {
 		os.Exit(1)
 	}
 
-	fmt.Println(string(resp.Body))
+	printBody(resp.Body)
 }
 
 func handleTwitchFollowers(cmd *flag.FlagSet) {
@@ -409,7 +737,7 @@ func handleTwitchFollowers(cmd *flag.FlagSet) {
 		os.Exit(1)
 	}
 
-	fmt.Println(string(resp.Body))
+	printBody(resp.Body)
 }
 
 func handleKick(cmd *flag.FlagSet) {
@@ -417,9 +745,10 @@ func handleKick(cmd *flag.FlagSet) {
 	timeoutsCmd := flag.NewFlagSet("timeouts", flag.ExitOnError)
 	modsCmd := flag.NewFlagSet("mods", flag.ExitOnError)
 	subscribersCmd := flag.NewFlagSet("subscribers", flag.ExitOnError)
+	reportCmd := flag.NewFlagSet("report", flag.ExitOnError)
 
 	if len(os.Args) < 3 {
-		fmt.Println("Expected 'messages', 'timeouts', 'mods', or 'subscribers' subcommand")
+		fmt.Println("Expected 'messages', 'timeouts', 'mods', 'subscribers', or 'report' subcommand")
 		os.Exit(1)
 	}
 
@@ -432,6 +761,8 @@ func handleKick(cmd *flag.FlagSet) {
 		handleKickMods(modsCmd)
 	case "subscribers":
 		handleKickSubscribers(subscribersCmd)
+	case "report":
+		handleKickReport(reportCmd)
 	default:
 		fmt.Printf("Unknown subcommand: %s\n", os.Args[2])
 		os.Exit(1)
@@ -441,12 +772,21 @@ func handleKick(cmd *flag.FlagSet) {
 func handleKickMessages(cmd *flag.FlagSet) {
 	username := cmd.String("username", "", "Kick username")
 	offset := cmd.Int("offset", 0, "Pagination offset")
+	export := cmd.String("export", "", "Write the messages as a chat log (irc, csv, or html)")
+	outFile := cmd.String("output", "", "Write the chat log to this file instead of stdout")
+	pages := cmd.Int("pages", 10, "Maximum pages to fetch with --export, starting at --offset")
+	tuiMode := cmd.Bool("tui", false, "Browse the results interactively, loading further pages on demand")
 
 	if err := cmd.Parse(os.Args[3:]); err != nil {
 		fmt.Printf("Error parsing flags: %v\n", err)
 		cmd.PrintDefaults()
 		os.Exit(1)
 	}
+	validateExport(*export, *outFile, *pages)
+	if *tuiMode && *export != "" {
+		fmt.Println("Error: --tui cannot be combined with --export")
+		os.Exit(1)
+	}
 
 	if *username == "" {
 		fmt.Println("Error: username is required")
@@ -460,13 +800,26 @@ func handleKickMessages(cmd *flag.FlagSet) {
 		os.Exit(1)
 	}
 
+	if *tuiMode {
+		browseOrExit(client, "kick messages "+*username, *offset, func(offset int) (*api.Response, error) {
+			return client.KickUserMessages(*username, offset)
+		})
+		return
+	}
+
+	if *export != "" {
+		exportOrExit(timeline.Kick, *username, *offset, *pages, func(offset int) (*api.Response, error) {
+			return client.KickUserMessages(*username, offset)
+		}, *export, *outFile)
+		return
+	}
+
 	resp, err := client.KickUserMessages(*username, *offset)
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
-
-	fmt.Println(string(resp.Body))
+	printBody(resp.Body)
 }
 
 func handleKickTimeouts(cmd *flag.FlagSet) {
@@ -496,7 +849,7 @@ func handleKickTimeouts(cmd *flag.FlagSet) {
 		os.Exit(1)
 	}
 
-	fmt.Println(string(resp.Body))
+	printBody(resp.Body)
 }
 
 func handleKickMods(cmd *flag.FlagSet) {
@@ -526,7 +879,7 @@ func handleKickMods(cmd *flag.FlagSet) {
 		os.Exit(1)
 	}
 
-	fmt.Println(string(resp.Body))
+	printBody(resp.Body)
 }
 
 func handleKickSubscribers(cmd *flag.FlagSet) {
@@ -556,7 +909,7 @@ func handleKickSubscribers(cmd *flag.FlagSet) {
 		os.Exit(1)
 	}
 
-	fmt.Println(string(resp.Body))
+	printBody(resp.Body)
 }
 
 func handleReverse(cmd *flag.FlagSet) {
@@ -570,10 +923,8 @@ func handleReverse(cmd *flag.FlagSet) {
 
 	switch os.Args[2] {
 	case "phone":
-		phoneCmd.Parse(os.Args[3:])
 		handleReversePhone(phoneCmd)
 	case "email":
-		emailCmd.Parse(os.Args[3:])
 		handleReverseEmail(emailCmd)
 	default:
 		fmt.Printf("Unknown subcommand: %s\n", os.Args[2])
@@ -584,6 +935,7 @@ func handleReverse(cmd *flag.FlagSet) {
 func handleReversePhone(cmd *flag.FlagSet) {
 	phone := cmd.String("phone", "", "Phone number")
 	insecureMode := cmd.Bool("insecure", false, "Use insecure mode")
+	pivots := cmd.Bool("pivots", false, "Suggest follow-up lookups for identifiers found in the results")
 
 	if err := cmd.Parse(os.Args[3:]); err != nil {
 		fmt.Printf("Error parsing flags: %v\n", err)
@@ -601,6 +953,8 @@ func handleReversePhone(cmd *flag.FlagSet) {
 		os.Exit(1)
 	}
 
+	justify(api.PathReversePhoneLookup, *insecureMode)
+
 	done := make(chan bool)
 	go func() {
 		startTime := time.Now()
@@ -646,11 +1000,9 @@ func handleReversePhone(cmd *flag.FlagSet) {
 		if len(resp.Body) == 0 || string(resp.Body) == "[]" {
 			fmt.Println("No data found for this phone number")
 		} else {
-			var prettyJSON bytes.Buffer
-			if err := json.Indent(&prettyJSON, resp.Body, "", "  "); err == nil {
-				fmt.Println(prettyJSON.String())
-			} else {
-				fmt.Println(string(resp.Body))
+			printPrettyBody(resp.Body)
+			if *pivots {
+				printPivots(client, resp.Body, *phone)
 			}
 		}
 	case 401:
@@ -682,7 +1034,7 @@ func handleReversePhone(cmd *flag.FlagSet) {
 	default:
 		fmt.Printf("Error: Unexpected response (Status %d)\n", resp.StatusCode)
 		if len(resp.Body) > 0 {
-			fmt.Println(string(resp.Body))
+			printBody(resp.Body)
 		}
 	}
 }
@@ -690,6 +1042,17 @@ func handleReversePhone(cmd *flag.FlagSet) {
 func handleReverseEmail(cmd *flag.FlagSet) {
 	email := cmd.String("email", "", "Email address")
 	insecureMode := cmd.Bool("insecure", false, "Use insecure mode")
+	pivots := cmd.Bool("pivots", false, "Suggest follow-up lookups for identifiers found in the results")
+
+	if err := cmd.Parse(os.Args[3:]); err != nil {
+		fmt.Printf("Error parsing flags: %v\n", err)
+		cmd.PrintDefaults()
+		os.Exit(1)
+	}
+
+	if *email == "" && len(cmd.Args()) > 0 {
+		*email = cmd.Args()[0]
+	}
 
 	if *email == "" {
 		fmt.Println("Error: email is required")
@@ -697,6 +1060,8 @@ func handleReverseEmail(cmd *flag.FlagSet) {
 		os.Exit(1)
 	}
 
+	justify(api.PathReverseEmailLookup, *insecureMode)
+
 	client, err := getClient()
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
@@ -709,12 +1074,19 @@ func handleReverseEmail(cmd *flag.FlagSet) {
 		os.Exit(1)
 	}
 
-	fmt.Println(string(resp.Body))
+	printBody(resp.Body)
+	if *pivots && resp.StatusCode == 200 {
+		printPivots(client, resp.Body, *email)
+	}
 }
 
 func handleDatabase(cmd *flag.FlagSet) {
 	query := cmd.String("query", "", "Search query")
 	exact := cmd.Bool("exact", false, "Exact match")
+	summary := cmd.Bool("summary", false, "Print hits and field counts per source")
+	groupBy := cmd.String("group-by", "", "Group records by source or by field")
+	format := cmd.String("format", output.Table, "Output format for --summary and --group-by (json, jsonl, csv, or table)")
+	pivots := cmd.Bool("pivots", false, "Suggest follow-up lookups for identifiers found in the results")
 
 	if err := cmd.Parse(os.Args[2:]); err != nil {
 		fmt.Printf("Error parsing flags: %v\n", err)
@@ -732,6 +1104,17 @@ func handleDatabase(cmd *flag.FlagSet) {
 		os.Exit(1)
 	}
 
+	if *groupBy != "" && *groupBy != "source" && *groupBy != "field" {
+		fmt.Println("Error: group-by must be source or field")
+		os.Exit(1)
+	}
+	if _, err := output.ParseFormat(*format); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	justify(api.PathDatabaseLookup, false)
+
 	done := make(chan bool)
 	go func() {
 		startTime := time.Now()
@@ -775,14 +1158,16 @@ func handleDatabase(cmd *flag.FlagSet) {
 	case 200:
 		if len(resp.Body) == 0 || string(resp.Body) == "[]" {
 			fmt.Println("No data found for this query")
-		} else {
-			// Try to pretty print the JSON if possible
-			var prettyJSON bytes.Buffer
-			if err := json.Indent(&prettyJSON, resp.Body, "", "  "); err == nil {
-				fmt.Println(prettyJSON.String())
-			} else {
-				fmt.Println(string(resp.Body))
+		} else if *summary || *groupBy != "" {
+			if err := printDatabaseResults(resp.Body, *summary, *groupBy, *format); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
 			}
+		} else {
+			printPrettyBody(resp.Body)
+		}
+		if *pivots && len(resp.Body) > 0 && string(resp.Body) != "[]" {
+			printPivots(client, resp.Body, *query)
 		}
 	case 401:
 		if config.APIKey == "" {
@@ -807,7 +1192,7 @@ func handleDatabase(cmd *flag.FlagSet) {
 	default:
 		fmt.Printf("Error: Unexpected response (Status %d)\n", resp.StatusCode)
 		if len(resp.Body) > 0 {
-			fmt.Println(string(resp.Body))
+			printBody(resp.Body)
 		}
 	}
 }
@@ -839,4 +1224,4 @@ func handleConfig(cmd *flag.FlagSet) {
 		fmt.Printf("Unknown subcommand: %s\n", os.Args[2])
 		os.Exit(1)
 	}
-} 
\ No newline at end of file
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/api"
+	"github.com/ivan9253/lolarchiver-cli/pkg/moderation"
+	"github.com/ivan9253/lolarchiver-cli/pkg/output"
+	"github.com/ivan9253/lolarchiver-cli/pkg/records"
+	"github.com/ivan9253/lolarchiver-cli/pkg/timeline"
+)
+
+func handleModerationHistory() {
+	cmd := flag.NewFlagSet("moderation-history", flag.ExitOnError)
+	twitchUser := cmd.String("twitch", "", "Twitch username")
+	kickUser := cmd.String("kick", "", "Kick username")
+	server := cmd.String("server", "superserver2", "Server for Twitch messages (superserver2, main, or auto)")
+	pages := cmd.Int("pages", 5, "Maximum pages of Twitch timeouts, and of messages with --context, to fetch")
+	context := cmd.Int("context", 0, "Attach up to this many of the user's last messages in the channel to each action")
+	show := cmd.String("show", "all", "What to print (all, channels, periods, or actions)")
+	format := cmd.String("format", output.Table, "Output format (json, jsonl, csv, or table)")
+
+	if err := cmd.Parse(os.Args[2:]); err != nil {
+		fmt.Printf("Error parsing flags: %v\n", err)
+		cmd.PrintDefaults()
+		os.Exit(1)
+	}
+
+	if *twitchUser == "" && *kickUser == "" {
+		fmt.Println("Error: At least one of twitch or kick must be provided")
+		cmd.PrintDefaults()
+		os.Exit(1)
+	}
+	switch *show {
+	case "all", "channels", "periods", "actions":
+	default:
+		fmt.Println("Error: show must be one of all, channels, periods, or actions")
+		os.Exit(1)
+	}
+	if _, err := output.ParseFormat(*format); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	twitchServer, err := api.ParseTwitchServer(*server)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	client, err := getClient()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	type source struct {
+		platform string
+		user     string
+		timeouts func() ([]records.Record, error)
+		messages func() ([]records.Record, error)
+	}
+	var sources []source
+	if *twitchUser != "" {
+		sources = append(sources, source{
+			platform: timeline.Twitch,
+			user:     *twitchUser,
+			timeouts: func() ([]records.Record, error) {
+				return fetchPages(*pages, func(offset int) (*api.Response, error) {
+					return client.TwitchUserTimeouts(*twitchUser, offset)
+				})
+			},
+			messages: func() ([]records.Record, error) {
+				return fetchPages(*pages, func(offset int) (*api.Response, error) {
+					return client.TwitchUserMessages(*twitchUser, twitchServer, offset)
+				})
+			},
+		})
+	}
+	if *kickUser != "" {
+		sources = append(sources, source{
+			platform: timeline.Kick,
+			user:     *kickUser,
+			timeouts: func() ([]records.Record, error) {
+				return fetchRecords(func() (*api.Response, error) { return client.KickUserTimeouts(*kickUser) })
+			},
+			messages: func() ([]records.Record, error) {
+				return fetchPages(*pages, func(offset int) (*api.Response, error) {
+					return client.KickUserMessages(*kickUser, offset)
+				})
+			},
+		})
+	}
+
+	var actions []moderation.Action
+	var messages timeline.Timeline
+	failed := 0
+	for _, src := range sources {
+		recs, err := src.timeouts()
+		if err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "Warning: %s timeouts lookup failed: %v\n", src.platform, err)
+		}
+		platformActions := moderation.FromRecords(src.platform, recs)
+		actions = append(actions, platformActions...)
+
+		// Only spend credits on messages when there is something to correlate
+		if *context <= 0 || len(platformActions) == 0 {
+			continue
+		}
+		recs, err = src.messages()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s messages lookup failed: %v\n", src.platform, err)
+		}
+		messages = append(messages, timeline.FromActivity(src.platform, timeline.KindMessage, src.user, recs)...)
+	}
+	if failed == len(sources) {
+		fmt.Println("Error: no timeouts could be fetched")
+		os.Exit(1)
+	}
+
+	history := moderation.Summarise(actions)
+	moderation.Correlate(history.Actions, messages, *context)
+
+	if *show == "all" && *format == output.JSON {
+		err = writeOutput(*format, history)
+	} else {
+		switch *show {
+		case "channels":
+			err = writeOutput(*format, history.Channels)
+		case "periods":
+			err = writeOutput(*format, history.Periods)
+		case "actions":
+			err = writeOutput(*format, history.Actions)
+		default:
+			err = printModerationHistory(*format, history)
+		}
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// printModerationHistory writes the totals followed by the channel and
+// monthly tables. Formats other than table only get the channel table.
+func printModerationHistory(format string, h *moderation.History) error {
+	if format != output.Table {
+		return writeOutput(format, h.Channels)
+	}
+
+	t := h.Totals
+	fmt.Printf("Actions:       %d (%d timeouts, %d bans)\n", t.Actions, t.Timeouts, t.Bans)
+	fmt.Printf("Timed out for: %s\n", moderation.FormatSeconds(t.TimeoutSeconds))
+	fmt.Printf("Channels:      %d (%d escalating)\n", t.Channels, t.Escalating)
+	if t.Actions == 0 {
+		return nil
+	}
+
+	fmt.Println()
+	if err := writeOutput(format, h.Channels); err != nil {
+		return err
+	}
+	fmt.Println()
+	return writeOutput(format, h.Periods)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/config"
+	"github.com/ivan9253/lolarchiver-cli/pkg/notify"
+)
+
+func handleNotify() {
+	if len(os.Args) < 3 {
+		fmt.Println("Expected 'ls' or 'test' subcommand")
+		os.Exit(1)
+	}
+
+	switch os.Args[2] {
+	case "ls":
+		cfg, err := config.Load()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		profile := cfg.Profile()
+		if len(profile.Notifiers) == 0 {
+			fmt.Printf("No notifiers configured for profile %q\n", config.ProfileName())
+			return
+		}
+		for i, n := range profile.Notifiers {
+			switch n.Type {
+			case "webhook":
+				fmt.Printf("%d  webhook  %s (signed: %t)\n", i, n.URL, n.Secret != "")
+			case "command":
+				fmt.Printf("%d  command  %s\n", i, strings.Join(n.Command, " "))
+			case "file":
+				fmt.Printf("%d  file     %s\n", i, n.Dir)
+			default:
+				fmt.Printf("%d  %s\n", i, n.Type)
+			}
+		}
+	case "test":
+		handleNotifyTest()
+	default:
+		fmt.Printf("Unknown subcommand: %s\n", os.Args[2])
+		os.Exit(1)
+	}
+}
+
+func handleNotifyTest() {
+	cmd := flag.NewFlagSet("test", flag.ExitOnError)
+	webhook := cmd.String("webhook", "", "Send to this webhook URL instead of the configured notifiers")
+	secret := cmd.String("secret", "", "HMAC secret for --webhook")
+
+	if err := cmd.Parse(os.Args[3:]); err != nil {
+		fmt.Printf("Error parsing flags: %v\n", err)
+		cmd.PrintDefaults()
+		os.Exit(1)
+	}
+
+	var notifiers notify.Multi
+	if *webhook != "" {
+		notifiers = notify.Multi{notify.NewWebhook(*webhook, *secret, nil, 1)}
+	} else {
+		var err error
+		notifiers, err = loadNotifiers()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(notifiers) == 0 {
+			fmt.Printf("No notifiers configured for profile %q\n", config.ProfileName())
+			os.Exit(1)
+		}
+	}
+
+	event := notify.Event{
+		Source: "test",
+		Time:   time.Now().UTC(),
+		Record: map[string]string{"message": "test notification from lolarchiver-cli"},
+	}
+	if err := notifiers.Notify(event); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Test notification delivered to %d notifier(s)\n", len(notifiers))
+}
+
+// loadNotifiers creates the notifiers configured for the active profile
+func loadNotifiers() (notify.Multi, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	return notify.FromProfile(cfg.Profile())
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/api"
+	"github.com/ivan9253/lolarchiver-cli/pkg/output"
+	"github.com/ivan9253/lolarchiver-cli/pkg/pivot"
+	"github.com/ivan9253/lolarchiver-cli/pkg/records"
+)
+
+// pivotCommand is a follow-up command suggested for an identifier
+type pivotCommand struct {
+	Kind    string `json:"kind"`
+	Value   string `json:"value"`
+	Credits int    `json:"credits"`
+	Command string `json:"command"`
+}
+
+// pivotCommands is the tabular form of the suggestions
+type pivotCommands []pivotCommand
+
+// Columns implements output.Tabular
+func (p pivotCommands) Columns() []string {
+	return []string{"KIND", "VALUE", "CREDITS", "COMMAND"}
+}
+
+// Rows implements output.Tabular
+func (p pivotCommands) Rows() [][]string {
+	rows := make([][]string, 0, len(p))
+	for _, c := range p {
+		rows = append(rows, []string{c.Kind, c.Value, strconv.Itoa(c.Credits), c.Command})
+	}
+	return rows
+}
+
+// reasonPlaceholder stands in for the --reason justification in suggested
+// commands, so that they still pass a lookup policy requiring one once
+// filled in
+const reasonPlaceholder = `--reason "<why>"`
+
+// pivotRoutes lists the lookups suggested for each kind of identifier
+var pivotRoutes = map[string][]struct {
+	path string
+	args func(value string) []string
+}{
+	pivot.Email: {
+		{api.PathReverseEmailLookup, func(v string) []string { return []string{"reverse", "email", "--email", v} }},
+		{api.PathDatabaseLookup, func(v string) []string { return []string{"database", "--query", v} }},
+	},
+	pivot.Phone: {
+		{api.PathReversePhoneLookup, func(v string) []string { return []string{"reverse", "phone", "--phone", v} }},
+	},
+	pivot.Username: {
+		{api.PathDatabaseLookup, func(v string) []string { return []string{"database", "--query", v} }},
+	},
+}
+
+// printPivots lists follow-up commands for the identifiers found in a
+// response body, with their estimated cost. Nothing is executed. The list
+// goes to stderr so that it never mixes with --format json or csv output.
+func printPivots(client *api.Client, body []byte, query string) {
+	recs, err := records.Extract(body)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+
+	var suggestions pivotCommands
+	total := 0
+	for _, c := range pivot.Extract(recs, client.DefaultRegion(), query) {
+		for _, route := range pivotRoutes[c.Kind] {
+			cost := client.EstimatedCost(route.path)
+			total += cost
+			suggestions = append(suggestions, pivotCommand{
+				Kind:    c.Kind,
+				Value:   c.Value,
+				Credits: cost,
+				Command: "lolarchiver-cli " + reasonPlaceholder + " " + shellJoin(route.args(c.Value)),
+			})
+		}
+	}
+
+	fmt.Fprintln(os.Stderr)
+	if len(suggestions) == 0 {
+		fmt.Fprintln(os.Stderr, "No pivots found")
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Pivots (not run; %d lookup(s), about %d credit(s) in total):\n", len(suggestions), total)
+	if err := writeOutputTo(os.Stderr, output.Table, suggestions); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	}
+}
+
+// shellJoin quotes arguments that a POSIX shell would otherwise split or
+// expand
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if arg != "" && strings.IndexFunc(arg, func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("@%+=:,./_-", r))
+		}) < 0 {
+			quoted[i] = arg
+			continue
+		}
+		quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/api"
+	"github.com/ivan9253/lolarchiver-cli/pkg/config"
+)
+
+// checkPolicy returns the error that blocks a request to path under p, or
+// nil if the request is allowed
+func checkPolicy(p config.Policy, path string, insecure bool, reason, caseName string) error {
+	if !p.Covers(path, api.SensitivePaths) {
+		return nil
+	}
+	if insecure && !p.AllowInsecure {
+		return fmt.Errorf("insecure mode is disabled by policy for %s", path)
+	}
+	if p.RequireCase && caseName == "" {
+		return fmt.Errorf("policy requires an open case for %s; use 'case new' or --case", path)
+	}
+	if p.RequireReason {
+		minLength := p.MinReasonLength
+		if minLength < 1 {
+			minLength = 1
+		}
+		if len(strings.TrimSpace(reason)) < minLength {
+			return fmt.Errorf("policy requires a justification of at least %d character(s) for %s; use --reason", minLength, path)
+		}
+	}
+	return nil
+}
+
+// justify checks a sensitive lookup against the policy before any request
+// is made. When a justification is required but missing and stdin is a
+// terminal, the operator is prompted for one.
+func justify(path string, insecure bool) {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	caseName := ""
+	if c, err := activeCase(); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	} else if c != nil {
+		caseName = c.Name
+	}
+
+	p := cfg.LookupPolicy()
+	err = checkPolicy(p, path, insecure, globals.reason, caseName)
+	if err != nil && p.RequireReason && strings.TrimSpace(globals.reason) == "" && isTerminal(os.Stdin) {
+		fmt.Fprint(os.Stderr, "Justification for this lookup: ")
+		line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		globals.reason = strings.TrimSpace(line)
+		err = checkPolicy(p, path, insecure, globals.reason, caseName)
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// policyGuard enforces the policy on every request made by the client, so
+// that no code path can reach a sensitive endpoint without a justification
+func policyGuard(p config.Policy, caseName string) api.Guard {
+	return func(req api.Request) error {
+		insecure := req.Headers["insecuremode"] == "true"
+		return checkPolicy(p, req.Path, insecure, globals.reason, caseName)
+	}
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/api"
+	"github.com/ivan9253/lolarchiver-cli/pkg/config"
+)
+
+func TestCheckPolicy(t *testing.T) {
+	strict := config.Policy{RequireReason: true, MinReasonLength: 5, RequireCase: true}
+	tests := []struct {
+		name     string
+		policy   config.Policy
+		path     string
+		insecure bool
+		reason   string
+		caseName string
+		allowed  bool
+	}{
+		{"default policy allows a plain lookup", config.DefaultPolicy, api.PathReverseEmailLookup, false, "", "", true},
+		{"default policy refuses insecure", config.DefaultPolicy, api.PathReverseEmailLookup, true, "", "", false},
+		{"default policy ignores other endpoints", config.DefaultPolicy, api.PathTwitchUserMessages, true, "", "", true},
+		{"insecure allowed by policy", config.Policy{AllowInsecure: true}, api.PathDatabaseLookup, true, "", "", true},
+		{"missing reason", strict, api.PathDatabaseLookup, false, "", "case1", false},
+		{"short reason", strict, api.PathDatabaseLookup, false, " ab ", "case1", false},
+		{"missing case", strict, api.PathDatabaseLookup, false, "ticket 1234", "", false},
+		{"reason and case", strict, api.PathDatabaseLookup, false, "ticket 1234", "case1", true},
+		{"custom endpoints", config.Policy{Endpoints: []string{api.PathTwitchUserMessages}, RequireReason: true}, api.PathTwitchUserMessages, false, "", "", false},
+		{"custom endpoints leave defaults out", config.Policy{Endpoints: []string{api.PathTwitchUserMessages}, RequireReason: true}, api.PathDatabaseLookup, false, "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkPolicy(tt.policy, tt.path, tt.insecure, tt.reason, tt.caseName)
+			if (err == nil) != tt.allowed {
+				t.Errorf("checkPolicy = %v, want allowed %v", err, tt.allowed)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/output"
+	"github.com/ivan9253/lolarchiver-cli/pkg/records"
+	"github.com/ivan9253/lolarchiver-cli/pkg/redact"
+)
+
+// printBody prints a raw response body, masking personal data when
+// --redact is set
+func printBody(body []byte) {
+	if globals.redactor != nil {
+		body = globals.redactor.JSON(body)
+	}
+	fmt.Println(string(body))
+}
+
+// printPrettyBody prints a response body as indented JSON when possible,
+// masking personal data when --redact is set
+func printPrettyBody(body []byte) {
+	if globals.redactor != nil {
+		body = globals.redactor.JSON(body)
+	}
+	var prettyJSON bytes.Buffer
+	if err := json.Indent(&prettyJSON, body, "", "  "); err == nil {
+		fmt.Println(prettyJSON.String())
+	} else {
+		fmt.Println(string(body))
+	}
+}
+
+// writeOutput renders v to stdout in the given format, masking personal
+// data when --redact is set
+func writeOutput(format string, v interface{}) error {
+	return writeOutputTo(os.Stdout, format, v)
+}
+
+// writeOutputTo is writeOutput for any writer
+func writeOutputTo(w io.Writer, format string, v interface{}) error {
+	if globals.redactor != nil {
+		if t, ok := v.(output.Tabular); ok && (format == output.CSV || format == output.Table) {
+			v = globals.redactor.Table(t)
+		} else {
+			v = redactValue(v)
+		}
+	}
+	return output.Write(w, format, v)
+}
+
+// redactValue masks personal data in any JSON-encodable value, or returns
+// v unchanged when --redact is not set. A value that cannot be re-encoded
+// is replaced entirely, so that redaction never fails open.
+func redactValue(v interface{}) interface{} {
+	if globals.redactor == nil {
+		return v
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return redact.Placeholder
+	}
+	var decoded interface{}
+	if err := records.Decode(data, &decoded); err != nil {
+		return redact.Placeholder
+	}
+	return globals.redactor.Value(decoded)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/cases"
+	"github.com/ivan9253/lolarchiver-cli/pkg/redact"
+	"github.com/ivan9253/lolarchiver-cli/pkg/report"
+)
+
+func handleReport() {
+	cmd := flag.NewFlagSet("report", flag.ExitOnError)
+	format := cmd.String("format", report.HTML, "Report format (html or md)")
+	archives := cmd.String("archive", "", "Comma-separated archived results to include, as KIND:KEY (e.g. twitter:HANDLE)")
+	templates := cmd.String("template", "", "Comma-separated template files overriding the built-in templates")
+	title := cmd.String("title", "", "Report title")
+	outFile := cmd.String("output", "", "Write the report to this file instead of stdout")
+
+	if err := cmd.Parse(os.Args[2:]); err != nil {
+		fmt.Printf("Error parsing flags: %v\n", err)
+		cmd.PrintDefaults()
+		os.Exit(1)
+	}
+
+	if *format != report.HTML && *format != report.Markdown {
+		fmt.Println("Error: format must be html or md")
+		os.Exit(1)
+	}
+
+	// --case is a global option; fall back to the open case
+	name := globals.caseName
+	if name == "" {
+		var err error
+		if name, err = cases.Current(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if name == "" && *archives == "" {
+		fmt.Println("Error: --case or --archive is required")
+		cmd.PrintDefaults()
+		os.Exit(1)
+	}
+
+	opts := report.Options{}
+	if globals.redactor != nil {
+		opts.Filter = globals.redactor.JSON
+		opts.FilterField = func(field, s string) string {
+			return globals.redactor.Field(redact.KindOf(field), s)
+		}
+	}
+
+	if *title == "" {
+		*title = "LoLArchiver report"
+		if name != "" {
+			*title = "LoLArchiver report: " + name
+		}
+	}
+	r := report.New(*title)
+
+	if name != "" {
+		c, err := cases.Load(name)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := r.AddCase(c, opts); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	for _, spec := range splitList(*archives) {
+		kind, key, ok := strings.Cut(spec, ":")
+		if !ok || kind == "" || key == "" {
+			fmt.Printf("Error: invalid archive %q: expected KIND:KEY\n", spec)
+			os.Exit(1)
+		}
+		if err := r.AddArchive(kind, key, opts); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *outFile == "" {
+		if err := r.Render(os.Stdout, *format, splitList(*templates)); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	f, err := os.OpenFile(*outFile, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	err = r.Render(f, *format, splitList(*templates))
+	// A failed close may mean the report was not fully written
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// splitList splits a comma-separated flag value, dropping empty items
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
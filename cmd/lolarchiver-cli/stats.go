@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/api"
+	"github.com/ivan9253/lolarchiver-cli/pkg/stats"
+	"github.com/ivan9253/lolarchiver-cli/pkg/timeline"
+)
+
+func handleStats() {
+	if len(os.Args) < 3 {
+		fmt.Println("Expected 'twitch', 'kick', or 'youtube' subcommand")
+		os.Exit(1)
+	}
+
+	platform := os.Args[2]
+	cmd := flag.NewFlagSet("stats", flag.ExitOnError)
+	pages := cmd.Int("pages", 1, "Maximum pages of messages to fetch")
+	top := cmd.Int("top", 20, "Number of channels, terms and emotes to list (0 for all)")
+	tz := cmd.String("tz", "UTC", "Time zone for the activity heatmap (e.g. Local or Europe/Berlin)")
+	format := cmd.String("format", "table", "Output format (table or json)")
+
+	var actor string
+	var fetch func(client *api.Client, offset int) (*api.Response, error)
+	switch platform {
+	case "twitch":
+		username := cmd.String("username", "", "Twitch username")
+		server := cmd.String("server", "superserver2", "Server (superserver2, main, or auto)")
+		parseStatsFlags(cmd)
+		twitchServer, err := api.ParseTwitchServer(*server)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		actor = *username
+		fetch = func(client *api.Client, offset int) (*api.Response, error) {
+			return client.TwitchUserMessages(*username, twitchServer, offset)
+		}
+	case "kick":
+		username := cmd.String("username", "", "Kick username")
+		parseStatsFlags(cmd)
+		actor = *username
+		fetch = func(client *api.Client, offset int) (*api.Response, error) {
+			return client.KickUserMessages(*username, offset)
+		}
+	case "youtube":
+		userID := cmd.String("user-id", "", "YouTube user ID")
+		handle := cmd.String("handle", "", "YouTube handle")
+		channelID := cmd.String("channel-id", "", "YouTube channel ID")
+		// Accept the identifier before or after the flags
+		var identifier string
+		if args := parseStatsFlags(cmd); len(args) > 0 {
+			identifier = args[0]
+		}
+		resolveYouTubeFlags(identifier, userID, handle, channelID)
+		actor = firstNonEmpty(*handle, *channelID, *userID)
+		if actor == "" {
+			fmt.Println("Error: At least one of user-id, handle, or channel-id must be provided")
+			cmd.PrintDefaults()
+			os.Exit(1)
+		}
+		fetch = func(client *api.Client, offset int) (*api.Response, error) {
+			return client.YouTubeUserComments(*userID, *handle, *channelID, offset)
+		}
+	default:
+		fmt.Printf("Unknown subcommand: %s\n", platform)
+		os.Exit(1)
+	}
+
+	if actor == "" {
+		fmt.Println("Error: username is required")
+		cmd.PrintDefaults()
+		os.Exit(1)
+	}
+	if *format != "table" && *format != "json" {
+		fmt.Println("Error: format must be table or json")
+		os.Exit(1)
+	}
+	loc, err := time.LoadLocation(*tz)
+	if err != nil {
+		fmt.Printf("Error: unknown time zone %q\n", *tz)
+		os.Exit(1)
+	}
+
+	client, err := getClient()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	recs, err := fetchPages(*pages, func(offset int) (*api.Response, error) {
+		return fetch(client, offset)
+	})
+	if err != nil {
+		if len(recs) == 0 {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: stopped after %d message(s): %v\n", len(recs), err)
+	}
+
+	kind := timeline.KindMessage
+	if platform == timeline.YouTube {
+		kind = timeline.KindComment
+	}
+	s := stats.Compute(timeline.FromActivity(platform, kind, actor, recs), loc, *top)
+
+	if *format == "json" {
+		data, err := json.MarshalIndent(redactValue(s), "", "  ")
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+		return
+	}
+	if err := redactStats(s).WriteText(os.Stdout); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// redactStats masks the emails, IP addresses and phone numbers kept as
+// channel, term or emote names when --redact is set
+func redactStats(s *stats.Stats) *stats.Stats {
+	if globals.redactor == nil {
+		return s
+	}
+	redacted := *s
+	for _, list := range []*[]stats.Count{&redacted.Channels, &redacted.Terms, &redacted.Emotes} {
+		counts := make([]stats.Count, len(*list))
+		for i, c := range *list {
+			counts[i] = stats.Count{Name: globals.redactor.String(c.Name), Count: c.Count}
+		}
+		*list = counts
+	}
+	return &redacted
+}
+
+// parseStatsFlags parses the flags after the platform and returns any
+// positional arguments
+func parseStatsFlags(cmd *flag.FlagSet) []string {
+	args, err := parseInterspersed(cmd, os.Args[3:])
+	if err != nil {
+		fmt.Printf("Error parsing flags: %v\n", err)
+		cmd.PrintDefaults()
+		os.Exit(1)
+	}
+	return args
+}
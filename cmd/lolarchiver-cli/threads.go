@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/api"
+	"github.com/ivan9253/lolarchiver-cli/pkg/cache"
+	"github.com/ivan9253/lolarchiver-cli/pkg/records"
+	"github.com/ivan9253/lolarchiver-cli/pkg/timeline"
+)
+
+// Thread output formats
+const (
+	threadTree = "tree"
+	threadJSON = "json"
+)
+
+// commentIDKeys are the fields checked, in order, for a comment's ID
+var commentIDKeys = []string{"comment_id", "id", "commentId"}
+
+// commentThread is a comment together with its replies
+type commentThread struct {
+	Comment records.Record   `json:"comment"`
+	Replies []records.Record `json:"replies"`
+	Error   string           `json:"error,omitempty"`
+}
+
+// replyCache stores reply bodies by comment ID; *cache.Cache implements it
+type replyCache interface {
+	Get(key string) ([]byte, bool)
+	Put(key string, data []byte) error
+}
+
+// threadOptions controls how replies are fetched
+type threadOptions struct {
+	concurrency int
+	rate        float64
+	cacheTTL    time.Duration
+}
+
+// buildThreads fetches the replies to every comment, running up to
+// concurrency lookups at once and starting at most rate lookups per second.
+// Cached replies do not count against the rate.
+func buildThreads(client *api.Client, comments []records.Record, opts threadOptions) ([]commentThread, error) {
+	c, err := cache.Open("youtube-replies", opts.cacheTTL)
+	if err != nil {
+		return nil, err
+	}
+	return fetchThreads(client.YouTubeCommentReplies, c, comments, opts), nil
+}
+
+// fetchThreads is buildThreads with the reply lookup and cache supplied by
+// the caller. Threads are returned in the order of comments.
+func fetchThreads(fetch func(commentID string) (*api.Response, error), c replyCache, comments []records.Record, opts threadOptions) []commentThread {
+	if opts.concurrency < 1 {
+		opts.concurrency = 1
+	}
+
+	var limiter <-chan time.Time
+	if opts.rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / opts.rate))
+		defer ticker.Stop()
+		limiter = ticker.C
+	}
+
+	threads := make([]commentThread, len(comments))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < opts.concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				threads[i] = fetchThread(fetch, c, comments[i], limiter)
+			}
+		}()
+	}
+	for i := range comments {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return threads
+}
+
+func fetchThread(fetch func(commentID string) (*api.Response, error), c replyCache, comment records.Record, limiter <-chan time.Time) commentThread {
+	thread := commentThread{Comment: comment, Replies: []records.Record{}}
+	id := comment.String(commentIDKeys...)
+	if id == "" {
+		thread.Error = "comment has no ID"
+		return thread
+	}
+
+	body, ok := c.Get(id)
+	if !ok {
+		if limiter != nil {
+			<-limiter
+		}
+		resp, err := fetch(id)
+		if err != nil {
+			thread.Error = err.Error()
+			return thread
+		}
+		if resp.StatusCode != 200 {
+			thread.Error = fmt.Sprintf("unexpected response (Status %d)", resp.StatusCode)
+			return thread
+		}
+		body = resp.Body
+		// The replies were fetched, so a cache failure only costs a refetch
+		if err := c.Put(id, body); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to cache replies of comment %s: %v\n", id, err)
+		}
+	}
+
+	replies, err := records.Extract(body)
+	if err != nil {
+		thread.Error = err.Error()
+		return thread
+	}
+	if replies != nil {
+		thread.Replies = replies
+	}
+	return thread
+}
+
+func printThreads(threads []commentThread, format string) {
+	if format == threadJSON {
+		data, _ := json.MarshalIndent(redactValue(threads), "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+
+	for i, thread := range threads {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Println(commentLine(thread.Comment))
+		if where := thread.Comment.String(timeline.ChannelKeys...); where != "" {
+			fmt.Printf("  on %s\n", where)
+		}
+		if thread.Error != "" {
+			fmt.Printf("  (replies unavailable: %s)\n", thread.Error)
+		}
+		for j, reply := range thread.Replies {
+			branch := "├─"
+			if j == len(thread.Replies)-1 {
+				branch = "└─"
+			}
+			fmt.Printf("  %s %s\n", branch, commentLine(reply))
+		}
+	}
+}
+
+// commentLine formats a comment as "[date] author: text"
+func commentLine(rec records.Record) string {
+	var parts []string
+	if t, ok := rec.Time(records.TimeKeys...); ok {
+		parts = append(parts, "["+t.Format(time.DateOnly)+"]")
+	}
+	text := strings.Join(strings.Fields(rec.String(timeline.TextKeys...)), " ")
+	if author := rec.String(timeline.ActorKeys...); author != "" {
+		parts = append(parts, author+":")
+	}
+	parts = append(parts, text)
+	return fmt.Sprint(redactValue(strings.Join(parts, " ")))
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/api"
+	"github.com/ivan9253/lolarchiver-cli/pkg/records"
+)
+
+// memCache is a replyCache kept in memory that can be made to fail writes
+type memCache struct {
+	mu      sync.Mutex
+	entries map[string][]byte
+	failPut bool
+}
+
+func (m *memCache) Get(key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.entries[key]
+	return data, ok
+}
+
+func (m *memCache) Put(key string, data []byte) error {
+	if m.failPut {
+		return errors.New("disk full")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[key] = data
+	return nil
+}
+
+// fakeReplies returns a fetch function answering with one reply per comment,
+// along with a counter of the calls made
+func fakeReplies(delay func(id string) time.Duration) (func(string) (*api.Response, error), *int) {
+	var mu sync.Mutex
+	calls := 0
+	return func(id string) (*api.Response, error) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		if delay != nil {
+			time.Sleep(delay(id))
+		}
+		if id == "broken" {
+			return &api.Response{StatusCode: 500}, nil
+		}
+		body := fmt.Sprintf(`{"data":[{"id":"re-%s","text":"reply"}]}`, id)
+		return &api.Response{StatusCode: 200, Body: []byte(body)}, nil
+	}, &calls
+}
+
+func commentRecords(ids ...string) []records.Record {
+	recs := make([]records.Record, 0, len(ids))
+	for _, id := range ids {
+		recs = append(recs, records.Record{"comment_id": id})
+	}
+	return recs
+}
+
+func TestFetchThreadsOrder(t *testing.T) {
+	// Earlier comments take longer, so workers finish out of order
+	ids := []string{"c1", "c2", "c3", "c4", "c5", "c6"}
+	fetch, _ := fakeReplies(func(id string) time.Duration {
+		return time.Duration(len(ids)-int(id[1]-'0')) * 5 * time.Millisecond
+	})
+	c := &memCache{entries: map[string][]byte{}}
+
+	threads := fetchThreads(fetch, c, commentRecords(ids...), threadOptions{concurrency: 4})
+	if len(threads) != len(ids) {
+		t.Fatalf("got %d threads, want %d", len(threads), len(ids))
+	}
+	for i, thread := range threads {
+		if got := thread.Comment.String(commentIDKeys...); got != ids[i] {
+			t.Errorf("thread %d is comment %s, want %s", i, got, ids[i])
+		}
+		if len(thread.Replies) != 1 || thread.Replies[0]["id"] != "re-"+ids[i] {
+			t.Errorf("thread %d replies = %v", i, thread.Replies)
+		}
+	}
+}
+
+func TestFetchThreadsRateLimit(t *testing.T) {
+	fetch, calls := fakeReplies(nil)
+	c := &memCache{entries: map[string][]byte{"cached": []byte(`{"data":[]}`)}}
+
+	// 20 lookups per second spaces the four uncached fetches 50ms apart,
+	// while the cached comment does not wait for the limiter
+	start := time.Now()
+	threads := fetchThreads(fetch, c, commentRecords("c1", "c2", "cached", "c3", "c4"), threadOptions{concurrency: 5, rate: 20})
+	elapsed := time.Since(start)
+
+	if *calls != 4 {
+		t.Errorf("fetched %d times, want 4", *calls)
+	}
+	if elapsed < 180*time.Millisecond {
+		t.Errorf("took %v, want at least 200ms for 4 fetches at 20/s", elapsed)
+	}
+	if threads[2].Error != "" || len(threads[2].Replies) != 0 {
+		t.Errorf("cached thread = %+v, want no replies and no error", threads[2])
+	}
+}
+
+func TestFetchThreadsFailingCache(t *testing.T) {
+	fetch, calls := fakeReplies(nil)
+	c := &memCache{entries: map[string][]byte{}, failPut: true}
+
+	threads := fetchThreads(fetch, c, commentRecords("c1", "broken", ""), threadOptions{concurrency: 2})
+	if threads[0].Error != "" || len(threads[0].Replies) != 1 {
+		t.Errorf("thread with failed cache write = %+v, want its replies", threads[0])
+	}
+	if threads[1].Error != "unexpected response (Status 500)" {
+		t.Errorf("failed fetch error = %q", threads[1].Error)
+	}
+	if threads[2].Error != "comment has no ID" {
+		t.Errorf("comment without ID error = %q", threads[2].Error)
+	}
+
+	// Nothing was cached, so a second run fetches again
+	fetchThreads(fetch, c, commentRecords("c1"), threadOptions{})
+	if *calls != 3 {
+		t.Errorf("fetched %d times, want 3", *calls)
+	}
+}
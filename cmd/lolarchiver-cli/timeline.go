@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/api"
+	"github.com/ivan9253/lolarchiver-cli/pkg/archive"
+	"github.com/ivan9253/lolarchiver-cli/pkg/output"
+	"github.com/ivan9253/lolarchiver-cli/pkg/records"
+	"github.com/ivan9253/lolarchiver-cli/pkg/timeline"
+)
+
+// timelineSource describes one fetch contributing events to the timeline.
+// fetch returns the body archived for the source.
+type timelineSource struct {
+	kind      string
+	key       string
+	fetch     func(client *api.Client) ([]byte, error)
+	normalise func(recs []records.Record) timeline.Timeline
+}
+
+func handleTimeline() {
+	cmd := flag.NewFlagSet("timeline", flag.ExitOnError)
+	twitter := cmd.String("twitter", "", "Twitter handle")
+	twitch := cmd.String("twitch", "", "Twitch username")
+	server := cmd.String("server", "superserver2", "Server for Twitch messages (superserver2, main, or auto)")
+	kick := cmd.String("kick", "", "Kick username")
+	ytUserID := cmd.String("youtube-user-id", "", "YouTube user ID")
+	ytHandle := cmd.String("youtube-handle", "", "YouTube handle")
+	ytChannelID := cmd.String("youtube-channel-id", "", "YouTube channel ID")
+	pages := cmd.Int("pages", 5, "Maximum pages of messages and comments to fetch per platform")
+	fromArchive := cmd.Bool("from-archive", false, "Use the latest archived snapshot when one exists instead of fetching")
+	format := cmd.String("format", output.Table, "Output format (json, jsonl, csv, or table)")
+
+	if err := cmd.Parse(os.Args[2:]); err != nil {
+		fmt.Printf("Error parsing flags: %v\n", err)
+		cmd.PrintDefaults()
+		os.Exit(1)
+	}
+
+	if _, err := output.ParseFormat(*format); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *pages < 1 {
+		fmt.Println("Error: pages must be at least 1")
+		os.Exit(1)
+	}
+
+	twitchServer, err := api.ParseTwitchServer(*server)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Archive keys are normalised like the requests, so that "@Foo" and
+	// "foo" share one archive slot
+	var twitchKey, kickKey string
+	if *twitch != "" {
+		if twitchKey, err = api.NormalizeUsername(api.PlatformTwitch, *twitch); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if *kick != "" {
+		if kickKey, err = api.NormalizeUsername(api.PlatformKick, *kick); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	var sources []timelineSource
+	if *twitter != "" {
+		sources = append(sources, timelineSource{
+			kind: "twitter",
+			key:  twitterArchiveKey(*twitter),
+			fetch: func(client *api.Client) ([]byte, error) {
+				return fetchBody(func() (*api.Response, error) { return client.TwitterHistoryLookup(*twitter, 0, false) })
+			},
+			normalise: func(recs []records.Record) timeline.Timeline {
+				return timeline.FromHistory(timeline.Twitter, timeline.KindUsername, *twitter, recs)
+			},
+		})
+	}
+	if *twitch != "" {
+		sources = append(sources, timelineSource{
+			kind: "twitch-history-username",
+			key:  twitchKey,
+			fetch: func(client *api.Client) ([]byte, error) {
+				return fetchBody(func() (*api.Response, error) { return client.TwitchUserHistory(*twitch, api.HistoryModeUsername) })
+			},
+			normalise: func(recs []records.Record) timeline.Timeline {
+				return timeline.FromHistory(timeline.Twitch, timeline.KindUsername, *twitch, recs)
+			},
+		}, timelineSource{
+			kind: "twitch-messages-" + string(twitchServer),
+			key:  twitchKey,
+			fetch: func(client *api.Client) ([]byte, error) {
+				return pagesBody(fetchPages(*pages, func(offset int) (*api.Response, error) {
+					return client.TwitchUserMessages(*twitch, twitchServer, offset)
+				}))
+			},
+			normalise: func(recs []records.Record) timeline.Timeline {
+				return timeline.FromActivity(timeline.Twitch, timeline.KindMessage, *twitch, recs)
+			},
+		})
+	}
+	if *kick != "" {
+		sources = append(sources, timelineSource{
+			kind: "kick-messages",
+			key:  kickKey,
+			fetch: func(client *api.Client) ([]byte, error) {
+				return pagesBody(fetchPages(*pages, func(offset int) (*api.Response, error) {
+					return client.KickUserMessages(*kick, offset)
+				}))
+			},
+			normalise: func(recs []records.Record) timeline.Timeline {
+				return timeline.FromActivity(timeline.Kick, timeline.KindMessage, *kick, recs)
+			},
+		})
+	}
+	if *ytUserID != "" || *ytHandle != "" || *ytChannelID != "" {
+		actor := firstNonEmpty(*ytHandle, *ytChannelID, *ytUserID)
+		sources = append(sources, timelineSource{
+			kind: "youtube-comments",
+			key:  actor,
+			fetch: func(client *api.Client) ([]byte, error) {
+				return pagesBody(fetchPages(*pages, func(offset int) (*api.Response, error) {
+					return client.YouTubeUserComments(*ytUserID, *ytHandle, *ytChannelID, offset)
+				}))
+			},
+			normalise: func(recs []records.Record) timeline.Timeline {
+				return timeline.FromActivity(timeline.YouTube, timeline.KindComment, actor, recs)
+			},
+		})
+	}
+
+	if len(sources) == 0 {
+		fmt.Println("Error: At least one platform identifier must be provided")
+		cmd.PrintDefaults()
+		os.Exit(1)
+	}
+
+	client, err := getClient()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var events timeline.Timeline
+	for _, src := range sources {
+		recs, err := fetchArchived(client, src.kind, src.key, *fromArchive, src.fetch)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s for %s incomplete: %v\n", src.kind, src.key, err)
+		}
+		events = append(events, src.normalise(recs)...)
+	}
+	events.Sort()
+
+	if err := writeOutput(*format, events); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// fetchArchived returns the records of a source, reading the latest
+// archived snapshot when useArchive is set and one exists. A freshly
+// fetched body is archived unchanged for later runs, so that single
+// lookups stay comparable with the snapshots of 'diff', unless the fetch
+// failed part way; records fetched before the error are returned with it.
+func fetchArchived(client *api.Client, kind, key string, useArchive bool, fetch func(client *api.Client) ([]byte, error)) ([]records.Record, error) {
+	if useArchive {
+		snap, err := archive.Latest(kind, key)
+		if err != nil {
+			return nil, err
+		}
+		if snap != nil {
+			return records.Extract(snap.Body)
+		}
+	}
+
+	body, err := fetch(client)
+	if err != nil {
+		recs, _ := records.Extract(body)
+		return recs, err
+	}
+
+	recs, err := records.Extract(body)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := archive.Save(kind, key, body); err != nil {
+		return recs, err
+	}
+	return recs, nil
+}
+
+// pagesBody encodes records collected from several pages as one JSON array,
+// keeping the error of a fetch that failed part way
+func pagesBody(recs []records.Record, fetchErr error) ([]byte, error) {
+	if recs == nil {
+		recs = []records.Record{}
+	}
+	body, err := json.Marshal(recs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal records: %w", err)
+	}
+	return body, fetchErr
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/api"
+	"github.com/ivan9253/lolarchiver-cli/pkg/archive"
+	"github.com/ivan9253/lolarchiver-cli/pkg/diff"
+)
+
+func TestFetchArchivedSharesDiffSnapshots(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	body := []byte(`{"handle": "foo", "results": [{"date": "2020-01-01", "username": "old"}, {"date": "2022-01-01", "username": "foo"}]}`)
+
+	fetch := func(client *api.Client) ([]byte, error) { return body, nil }
+	recs, err := fetchArchived(nil, "twitter", twitterArchiveKey("@Foo"), false, fetch)
+	if err != nil || len(recs) != 2 {
+		t.Fatalf("fetchArchived = %d record(s), %v; want 2", len(recs), err)
+	}
+
+	// diff reads the snapshot saved by the timeline and compares it with
+	// the raw body of the next lookup
+	snap, err := archive.Latest("twitter", twitterArchiveKey("foo"))
+	if err != nil || snap == nil {
+		t.Fatalf("Latest = %v, %v; want the timeline snapshot", snap, err)
+	}
+	changes, err := diff.Bodies(snap.Body, body)
+	if err != nil {
+		t.Fatalf("Bodies: %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("diff after timeline = %+v, want no changes", changes)
+	}
+
+	// --from-archive reads the same snapshot back
+	recs, err = fetchArchived(nil, "twitter", "foo", true, func(*api.Client) ([]byte, error) {
+		t.Fatal("fetched although a snapshot exists")
+		return nil, nil
+	})
+	if err != nil || len(recs) != 2 {
+		t.Errorf("fetchArchived from archive = %d record(s), %v; want 2", len(recs), err)
+	}
+}
+
+func TestFetchArchivedPartialPages(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	fetchErr := errors.New("rate limited")
+
+	fetch := func(client *api.Client) ([]byte, error) {
+		return pagesBody(fetchPages(3, func(offset int) (*api.Response, error) {
+			if offset > 0 {
+				return nil, fetchErr
+			}
+			return &api.Response{StatusCode: 200, Body: []byte(`[{"id": 1}, {"id": 2}]`)}, nil
+		}))
+	}
+	recs, err := fetchArchived(nil, "kick-messages", "foo", false, fetch)
+	if !errors.Is(err, fetchErr) || len(recs) != 2 {
+		t.Fatalf("fetchArchived = %d record(s), %v; want 2 and the fetch error", len(recs), err)
+	}
+	if snap, _ := archive.Latest("kick-messages", "foo"); snap != nil {
+		t.Errorf("incomplete pages were archived")
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/api"
+	"github.com/ivan9253/lolarchiver-cli/pkg/records"
+	"github.com/ivan9253/lolarchiver-cli/pkg/tui"
+)
+
+// browseOrExit opens the interactive browser on an offset-paginated
+// endpoint, starting at offset. Further pages are only fetched when asked
+// for.
+func browseOrExit(client *api.Client, title string, offset int, fetch func(offset int) (*api.Response, error)) {
+	t, err := tui.Open()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	// Restore the terminal before a panic is reported, so that a crash
+	// does not leave the shell in raw mode. os.Exit skips deferred calls,
+	// so the normal path closes the terminal itself.
+	defer func() {
+		if r := recover(); r != nil {
+			t.Close()
+			panic(r)
+		}
+	}()
+
+	// The spinner and warnings would draw over the screen
+	client.SetProgress(nil)
+	client.SetWarnings(nil)
+
+	b := tui.NewBrowser(title, recordPager(offset, fetch))
+	if _, err = b.Load(); err == nil {
+		err = b.Run(t)
+	}
+	if closeErr := t.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// maxDuplicatePages bounds the pages of already seen records skipped in a
+// row, so an endpoint that ignores the offset does not use up credits
+const maxDuplicatePages = 5
+
+// recordPager returns a tui.Pager walking an offset-paginated endpoint.
+// Records are redacted when --redact is given and records already returned
+// are dropped. A page holding only such duplicates is skipped, so that an
+// empty result means the API has no more records, or kept repeating itself
+// for maxDuplicatePages pages.
+func recordPager(offset int, fetch func(offset int) (*api.Response, error)) tui.Pager {
+	seen := make(map[string]bool)
+	return func() ([]records.Record, error) {
+		for skipped := 0; skipped < maxDuplicatePages; skipped++ {
+			resp, err := fetch(offset)
+			if err != nil {
+				return nil, err
+			}
+			if resp.StatusCode != 200 {
+				return nil, fmt.Errorf("unexpected response (Status %d)", resp.StatusCode)
+			}
+
+			body := resp.Body
+			if globals.redactor != nil {
+				body = globals.redactor.JSON(body)
+			}
+			recs, err := records.Extract(body)
+			if err != nil {
+				return nil, err
+			}
+			if len(recs) == 0 {
+				return nil, nil
+			}
+			offset = resp.NextOffset(offset, len(recs))
+
+			var fresh []records.Record
+			for _, rec := range recs {
+				if key := rec.Key(); !seen[key] {
+					seen[key] = true
+					fresh = append(fresh, rec)
+				}
+			}
+			if len(fresh) > 0 {
+				return fresh, nil
+			}
+		}
+		return nil, nil
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/output"
+	"github.com/ivan9253/lolarchiver-cli/pkg/twittermap"
+)
+
+// twitterMapResult is the JSON form of a mapping run
+type twitterMapResult struct {
+	Mappings twittermap.Map      `json:"mappings"`
+	Reused   map[string][]string `json:"reused_handles"`
+	Errors   map[string]string   `json:"errors,omitempty"`
+}
+
+func handleTwitterMap() {
+	cmd := flag.NewFlagSet("map", flag.ExitOnError)
+	file := cmd.String("file", "", "Read accounts from this file, one per line")
+	byOld := cmd.Bool("by-old", false, "Search handles by old usernames")
+	format := cmd.String("format", output.Table, "Output format (json, jsonl, csv, or table)")
+
+	inputs, err := parseInterspersed(cmd, os.Args[3:])
+	if err != nil {
+		fmt.Printf("Error parsing flags: %v\n", err)
+		cmd.PrintDefaults()
+		os.Exit(1)
+	}
+
+	if _, err := output.ParseFormat(*format); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *file != "" {
+		lines, err := readLines(*file)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		inputs = append(inputs, lines...)
+	}
+	if len(inputs) == 0 {
+		fmt.Println("Error: at least one account is required (id:ID, @handle or handle)")
+		cmd.PrintDefaults()
+		os.Exit(1)
+	}
+
+	// Every input is checked before any credits are spent
+	var accounts []twittermap.Input
+	seen := make(map[string]bool)
+	for _, raw := range inputs {
+		in, err := twittermap.ParseInput(raw)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if !seen[in.Key()] {
+			seen[in.Key()] = true
+			accounts = append(accounts, in)
+		}
+	}
+
+	client, err := getClient()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	result := twitterMapResult{Errors: map[string]string{}}
+	var sightings []twittermap.Sighting
+	for _, in := range accounts {
+		resp, err := client.TwitterHistoryLookup(in.Handle, in.ID, *byOld)
+		if err == nil && resp.StatusCode != 200 {
+			err = fmt.Errorf("unexpected response (Status %d)", resp.StatusCode)
+		}
+		var found []twittermap.Sighting
+		if err == nil {
+			found, err = twittermap.Parse(resp.Body, in.AccountID())
+		}
+		if err != nil {
+			result.Errors[in.String()] = err.Error()
+			fmt.Fprintf(os.Stderr, "Warning: skipping %s: %v\n", in, err)
+			continue
+		}
+		sightings = append(sightings, found...)
+	}
+
+	if len(result.Errors) == len(accounts) {
+		fmt.Println("Error: every lookup failed")
+		os.Exit(1)
+	}
+
+	result.Mappings = twittermap.Build(sightings)
+	result.Reused = result.Mappings.Reused()
+
+	if *format == output.JSON {
+		err = writeOutput(*format, result)
+	} else {
+		err = writeOutput(*format, result.Mappings)
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(result.Reused) > 0 {
+		fmt.Fprintf(os.Stderr, "%d handle(s) were used by more than one account\n", len(result.Reused))
+	}
+}
+
+// parseInterspersed parses flags that may appear before, between or after
+// positional arguments, returning the positional arguments
+func parseInterspersed(cmd *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := cmd.Parse(args); err != nil {
+			return nil, err
+		}
+		if cmd.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, cmd.Arg(0))
+		args = cmd.Args()[1:]
+	}
+}
+
+// readLines returns the non-empty lines of a file, skipping # comments
+func readLines(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return lines, nil
+}
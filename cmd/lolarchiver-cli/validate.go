@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/api"
+	"github.com/ivan9253/lolarchiver-cli/pkg/config"
+	"github.com/ivan9253/lolarchiver-cli/pkg/output"
+)
+
+// validationResult represents the outcome of checking one input
+type validationResult struct {
+	Input      string `json:"input"`
+	Valid      bool   `json:"valid"`
+	Normalized string `json:"normalized,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+type validationResults []validationResult
+
+// Columns implements output.Tabular
+func (vr validationResults) Columns() []string {
+	return []string{"STATUS", "INPUT", "NORMALIZED", "ERROR"}
+}
+
+// Rows implements output.Tabular
+func (vr validationResults) Rows() [][]string {
+	rows := make([][]string, 0, len(vr))
+	for _, r := range vr {
+		status := "OK"
+		if !r.Valid {
+			status = "INVALID"
+		}
+		rows = append(rows, []string{status, r.Input, r.Normalized, r.Error})
+	}
+	return rows
+}
+
+func handleValidate() {
+	cmd := flag.NewFlagSet("validate", flag.ExitOnError)
+	kind := cmd.String("type", "", "Input type (phone, email, twitter, twitch, or kick)")
+	region := cmd.String("region", "", "Default region for phone numbers without a country code (e.g. US)")
+	format := cmd.String("format", output.Table, "Output format (json, jsonl, csv, or table)")
+
+	if err := cmd.Parse(os.Args[2:]); err != nil {
+		fmt.Printf("Error parsing flags: %v\n", err)
+		cmd.PrintDefaults()
+		os.Exit(1)
+	}
+
+	if _, err := output.ParseFormat(*format); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *region == "" {
+		if cfg, err := config.Load(); err == nil {
+			*region = cfg.Region
+		}
+	}
+
+	var normalize func(string) (string, error)
+	switch *kind {
+	case "phone":
+		normalize = func(s string) (string, error) { return api.NormalizePhone(s, *region) }
+	case "email":
+		normalize = api.NormalizeEmail
+	case api.PlatformTwitter, api.PlatformTwitch, api.PlatformKick:
+		platform := *kind
+		normalize = func(s string) (string, error) { return api.NormalizeUsername(platform, s) }
+	default:
+		fmt.Println("Error: type must be one of phone, email, twitter, twitch, or kick")
+		cmd.PrintDefaults()
+		os.Exit(1)
+	}
+
+	var in io.Reader = os.Stdin
+	if cmd.NArg() > 0 && cmd.Arg(0) != "-" {
+		f, err := os.Open(cmd.Arg(0))
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	var results validationResults
+	invalid := 0
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		result := validationResult{Input: line}
+		normalized, err := normalize(line)
+		if err != nil {
+			result.Error = err.Error()
+			invalid++
+		} else {
+			result.Valid = true
+			result.Normalized = normalized
+		}
+		results = append(results, result)
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := writeOutput(*format, results); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "%d valid, %d invalid\n", len(results)-invalid, invalid)
+	if invalid > 0 {
+		os.Exit(1)
+	}
+}
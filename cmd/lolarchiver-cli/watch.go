@@ -0,0 +1,247 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/api"
+	"github.com/ivan9253/lolarchiver-cli/pkg/notify"
+	"github.com/ivan9253/lolarchiver-cli/pkg/records"
+	"github.com/ivan9253/lolarchiver-cli/pkg/watch"
+)
+
+// watchTarget describes an endpoint that can be polled for new records
+type watchTarget struct {
+	name     string
+	path     string
+	requests int
+	fetch    func(client *api.Client, offset int) (*api.Response, error)
+}
+
+func handleWatch() {
+	if len(os.Args) < 4 {
+		fmt.Println("Expected 'twitch messages', 'twitch timeouts', or 'kick messages'")
+		os.Exit(1)
+	}
+
+	platform, kind := os.Args[2], os.Args[3]
+	cmd := flag.NewFlagSet("watch", flag.ExitOnError)
+	username := cmd.String("username", "", "Username to watch")
+	server := cmd.String("server", "superserver2", "Server for Twitch messages (superserver2, main, or auto)")
+	interval := cmd.Duration("interval", 10*time.Minute, "Polling interval")
+	budget := cmd.Int("budget", 0, "Maximum credits to spend in this run (0 for no limit)")
+	once := cmd.Bool("once", false, "Poll once and exit")
+	baseline := cmd.Bool("baseline", false, "Mark current records as seen without printing them")
+	noNotify := cmd.Bool("no-notify", false, "Do not send new records to the profile's notifiers")
+	maxPages := cmd.Int("max-pages", 10, "Maximum pages to fetch per poll when every page holds new records")
+	retention := cmd.Duration("retention", 30*24*time.Hour, "Forget seen records the API has not returned for this long")
+
+	if err := cmd.Parse(os.Args[4:]); err != nil {
+		fmt.Printf("Error parsing flags: %v\n", err)
+		cmd.PrintDefaults()
+		os.Exit(1)
+	}
+
+	if *username == "" {
+		fmt.Println("Error: username is required")
+		cmd.PrintDefaults()
+		os.Exit(1)
+	}
+
+	if *interval < time.Minute {
+		fmt.Println("Error: interval must be at least 1m")
+		os.Exit(1)
+	}
+
+	if *maxPages < 1 {
+		fmt.Println("Error: max-pages must be at least 1")
+		os.Exit(1)
+	}
+
+	if *retention < *interval {
+		fmt.Println("Error: retention must be at least the interval")
+		os.Exit(1)
+	}
+
+	// The state file is named after the normalised username, so that
+	// "@Foo" and "foo" share their seen records
+	userPlatform := api.PlatformTwitch
+	if platform == "kick" {
+		userPlatform = api.PlatformKick
+	}
+	normalized, err := api.NormalizeUsername(userPlatform, *username)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	*username = strings.ToLower(normalized)
+
+	var target watchTarget
+	switch platform + " " + kind {
+	case "twitch messages":
+		twitchServer, err := api.ParseTwitchServer(*server)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		target = watchTarget{
+			name:     "twitch-messages-" + *server + "-" + *username,
+			path:     api.PathTwitchUserMessages,
+			requests: twitchServer.Requests(),
+			fetch: func(client *api.Client, offset int) (*api.Response, error) {
+				return client.TwitchUserMessages(*username, twitchServer, offset)
+			},
+		}
+	case "twitch timeouts":
+		target = watchTarget{
+			name: "twitch-timeouts-" + *username,
+			path: api.PathTwitchUserTimeouts,
+			fetch: func(client *api.Client, offset int) (*api.Response, error) {
+				return client.TwitchUserTimeouts(*username, offset)
+			},
+		}
+	case "kick messages":
+		target = watchTarget{
+			name: "kick-messages-" + *username,
+			path: api.PathKickUserMessages,
+			fetch: func(client *api.Client, offset int) (*api.Response, error) {
+				return client.KickUserMessages(*username, offset)
+			},
+		}
+	default:
+		fmt.Printf("Unknown watch target: %s %s\n", platform, kind)
+		os.Exit(1)
+	}
+
+	client, err := getClient()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	state, err := watch.Load(target.name)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var notifiers notify.Multi
+	if !*noNotify {
+		notifiers, err = loadNotifiers()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	spent := 0
+	cost := client.EstimatedCost(target.path) * max(target.requests, 1)
+	for {
+		if *budget > 0 && spent+cost > *budget {
+			fmt.Fprintf(os.Stderr, "Credit budget of %d reached, stopping\n", *budget)
+			return
+		}
+
+		// A watch that has never polled only takes the newest page as its
+		// starting point instead of paging through the whole history
+		pages := *maxPages
+		if state.LastPoll.IsZero() {
+			pages = 1
+		}
+		if *budget > 0 {
+			pages = min(pages, (*budget-spent)/max(cost, 1))
+		}
+
+		fresh, fetched, err := pollWatchTarget(client, target, state, pages)
+		spent += cost * fetched
+		state.CreditsUsed += cost * fetched
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		if *baseline {
+			state.MarkSeen(fresh...)
+		} else {
+			for _, rec := range fresh {
+				masked := redactValue(rec)
+				if state.MarkPrinted(rec) {
+					data, _ := json.Marshal(masked)
+					fmt.Println(string(data))
+				}
+
+				// Records whose notification failed are left unseen so
+				// that the next poll delivers them again, without printing
+				// them a second time or resending them to the notifiers
+				// that already have them
+				event := notify.Event{ID: target.name + ":" + rec.Key(), Source: target.name, Time: state.LastPoll, Record: masked}
+				delivered := state.Deliveries(rec)
+				err := notifiers.Deliver(event, delivered)
+				state.MarkDelivered(rec, delivered)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					continue
+				}
+				state.MarkSeen(rec)
+			}
+		}
+		if err == nil || len(fresh) > 0 {
+			fmt.Fprintf(os.Stderr, "[%s] %d new record(s)\n", state.LastPoll.Local().Format(time.DateTime), len(fresh))
+		}
+
+		state.Prune(*retention)
+		if err := state.Save(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		// The baseline only applies to the first poll
+		*baseline = false
+		if *once {
+			return
+		}
+		time.Sleep(*interval)
+	}
+}
+
+// pollWatchTarget fetches up to pages pages of the target, stopping at the
+// first page that holds no record missing from state. It returns the new
+// records, without marking them as seen, and the number of pages fetched.
+// Records found before an error are returned along with it.
+func pollWatchTarget(client *api.Client, target watchTarget, state *watch.State, pages int) ([]records.Record, int, error) {
+	var fresh []records.Record
+	batch := make(map[string]bool)
+	offset := 0
+	for page := 0; page < pages; page++ {
+		resp, err := target.fetch(client, offset)
+		if err != nil {
+			return fresh, page + 1, err
+		}
+		state.LastPoll = time.Now().UTC()
+
+		if resp.StatusCode != 200 {
+			return fresh, page + 1, fmt.Errorf("unexpected response (Status %d)", resp.StatusCode)
+		}
+
+		recs, err := records.Extract(resp.Body)
+		if err != nil {
+			return fresh, page + 1, err
+		}
+
+		added := 0
+		for _, rec := range state.Filter(recs) {
+			if key := rec.Key(); !batch[key] {
+				batch[key] = true
+				fresh = append(fresh, rec)
+				added++
+			}
+		}
+		if added == 0 {
+			return fresh, page + 1, nil
+		}
+		offset = resp.NextOffset(offset, len(recs))
+	}
+	return fresh, pages, nil
+}
@@ -0,0 +1,45 @@
+// Package jsonlog holds the helpers shared by the append-only JSON lines
+// logs (the audit log and the case logs): locking the file across
+// processes and reading its last entry.
+package jsonlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+)
+
+// chunk is the size of the blocks read backwards by Last
+const chunk = 4096
+
+// Last decodes the last entry of the log open in f into v. It reports false
+// when the log is empty. The file is read backwards so appending stays
+// cheap as it grows.
+func Last(f *os.File, v interface{}) (bool, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	var tail []byte
+	for pos := info.Size(); pos > 0; {
+		n := min(int64(chunk), pos)
+		pos -= n
+		buf := make([]byte, n)
+		if _, err := f.ReadAt(buf, pos); err != nil {
+			return false, err
+		}
+		tail = append(buf, tail...)
+
+		trimmed := bytes.TrimRight(tail, " \t\r\n")
+		i := bytes.LastIndexByte(trimmed, '\n')
+		if len(trimmed) == 0 || (i < 0 && pos > 0) {
+			continue
+		}
+		if err := json.Unmarshal(trimmed[i+1:], v); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	return false, nil
+}
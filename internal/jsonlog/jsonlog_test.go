@@ -0,0 +1,69 @@
+package jsonlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type entry struct {
+	Seq  int    `json:"seq"`
+	Text string `json:"text"`
+}
+
+func TestLast(t *testing.T) {
+	long := strings.Repeat("x", 3*chunk)
+	tests := []struct {
+		name  string
+		data  string
+		found bool
+		want  entry
+	}{
+		{"empty", "", false, entry{}},
+		{"blank lines", "\n \n", false, entry{}},
+		{"single entry", `{"seq": 1}` + "\n", true, entry{Seq: 1}},
+		{"trailing blank lines", `{"seq": 1}` + "\n" + `{"seq": 2}` + "\n\n", true, entry{Seq: 2}},
+		{"no final newline", `{"seq": 1}` + "\n" + `{"seq": 2}`, true, entry{Seq: 2}},
+		// The last entry spans several chunks
+		{"long entry", `{"seq": 1}` + "\n" + `{"seq": 2, "text": "` + long + `"}` + "\n", true, entry{Seq: 2, Text: long}},
+		{"long entry first", `{"seq": 1, "text": "` + long + `"}` + "\n", true, entry{Seq: 1, Text: long}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "log")
+			if err := os.WriteFile(path, []byte(tt.data), 0600); err != nil {
+				t.Fatalf("WriteFile: %v", err)
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			defer f.Close()
+
+			var got entry
+			found, err := Last(f, &got)
+			if err != nil || found != tt.found || got != tt.want {
+				t.Errorf("Last = %v, %+v, %v; want %v, %+v", found, got, err, tt.found, tt.want)
+			}
+		})
+	}
+}
+
+func TestLastCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log")
+	if err := os.WriteFile(path, []byte(`{"seq": 1}`+"\n"+`{"seq": `+"\n"), 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer f.Close()
+
+	var got entry
+	if _, err := Last(f, &got); err == nil {
+		t.Errorf("Last of a truncated entry succeeded")
+	}
+}
@@ -0,0 +1,16 @@
+//go:build !unix
+
+package jsonlog
+
+import "os"
+
+// Lock is a no-op where flock is unavailable, leaving appends from
+// concurrent processes unserialised
+func Lock(f *os.File) error {
+	return nil
+}
+
+// Unlock is a no-op where flock is unavailable
+func Unlock(f *os.File) error {
+	return nil
+}
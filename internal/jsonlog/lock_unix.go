@@ -0,0 +1,19 @@
+//go:build unix
+
+package jsonlog
+
+import (
+	"os"
+	"syscall"
+)
+
+// Lock takes an exclusive lock on f that other processes appending to the
+// same log wait for
+func Lock(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+}
+
+// Unlock releases the lock taken by Lock
+func Unlock(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
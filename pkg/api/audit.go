@@ -0,0 +1,53 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/audit"
+)
+
+// AuditContext describes who is making requests and why
+type AuditContext struct {
+	Operator string
+	Profile  string
+	Case     string
+	Reason   string
+}
+
+// SetAudit makes the client append an entry to log for every request
+func (c *Client) SetAudit(log *audit.Log, ctx AuditContext) {
+	c.auditLog = log
+	c.auditCtx = ctx
+}
+
+// SetReason sets the justification recorded with subsequent requests
+func (c *Client) SetReason(reason string) {
+	c.auditCtx.Reason = reason
+}
+
+// writeAudit appends the audit entry for a completed request
+func (c *Client) writeAudit(ex Exchange) error {
+	entry := audit.Entry{
+		Time:      ex.Started.UTC(),
+		Operator:  c.auditCtx.Operator,
+		Profile:   c.auditCtx.Profile,
+		Case:      c.auditCtx.Case,
+		Endpoint:  ex.Request.Path,
+		QueryHash: c.auditLog.HashQuery(ex.Request.Params()),
+		Reason:    c.auditCtx.Reason,
+	}
+	if ex.Err != nil {
+		entry.Error = ex.Err.Error()
+	} else {
+		entry.StatusCode = ex.Response.StatusCode
+		// Failed requests are not charged
+		if ex.Response.StatusCode == 200 {
+			entry.Credits = c.EstimatedCost(ex.Request.Path)
+		}
+	}
+
+	if err := c.auditLog.Append(entry); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
@@ -7,27 +7,100 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/audit"
 )
 
 const (
 	baseURL = "https://api.lolarchiver.com"
 )
 
+// SensitivePaths lists the endpoints returning personal data, which are
+// subject to the lookup policy by default
+var SensitivePaths = []string{
+	PathReversePhoneLookup,
+	PathReverseEmailLookup,
+	PathDatabaseLookup,
+}
+
+// API endpoint paths
+const (
+	PathCreditsLeft           = "/credits_left"
+	PathYouTubeUserComments   = "/youtube/user_all_comments"
+	PathYouTubeCommentReplies = "/youtube/comment_replies"
+	PathReversePhoneLookup    = "/reverse_phone_lookup"
+	PathReverseEmailLookup    = "/reverse_email_lookup"
+	PathTwitterHistoryLookup  = "/twitter_history_lookup"
+	PathDatabaseLookup        = "/database_lookup"
+	PathTwitchUserMessages    = "/twitch/user_all_messages"
+	PathTwitchUserTimeouts    = "/twitch/user_all_timeouts"
+	PathTwitchUserHistory     = "/twitch/user_history"
+	PathTwitchFollowage       = "/twitch/followage"
+	PathTwitchFollowers       = "/twitch/followers"
+	PathKickUserMessages      = "/kick/user_all_messages"
+	PathKickUserTimeouts      = "/kick/user_all_timeouts"
+	PathKickUserModChannels   = "/kick/user_channel_mods_in"
+	PathKickUserSubscribers   = "/kick/user_subscribers_list"
+)
+
 // Client represents the API client
 type Client struct {
-	apiKey string
-	client *http.Client
+	apiKey    string
+	client    *http.Client
+	costs     map[string]int
+	observers []Observer
+	auditLog  *audit.Log
+	auditCtx  AuditContext
+	guard     Guard
+	region    string
+	progress  io.Writer
+	warnings  io.Writer
+	// mu serialises guards and observers when requests run concurrently
+	mu sync.Mutex
 }
 
 // NewClient creates a new API client
 func NewClient(apiKey string) *Client {
 	return &Client{
-		apiKey: apiKey,
-		client: &http.Client{},
+		apiKey:   apiKey,
+		client:   &http.Client{},
+		progress: os.Stderr,
+		warnings: os.Stderr,
 	}
 }
 
+// SetProgress sets where the progress spinner is drawn while a request is
+// in flight. A nil writer disables it.
+func (c *Client) SetProgress(w io.Writer) {
+	if w == nil {
+		w = io.Discard
+	}
+	c.progress = w
+}
+
+// SetWarnings sets where warnings about partially failed lookups are
+// written. A nil writer disables them.
+func (c *Client) SetWarnings(w io.Writer) {
+	if w == nil {
+		w = io.Discard
+	}
+	c.warnings = w
+}
+
+// SetDefaultRegion sets the ISO 3166 region used to interpret phone numbers
+// given without a country code
+func (c *Client) SetDefaultRegion(region string) {
+	c.region = region
+}
+
+// DefaultRegion returns the region set with SetDefaultRegion
+func (c *Client) DefaultRegion() string {
+	return c.region
+}
+
 // Request represents a generic API request
 type Request struct {
 	Method  string
@@ -36,14 +109,109 @@ type Request struct {
 	Body    interface{}
 }
 
+// Params returns the headers and body fields of the request as one map
+func (r Request) Params() map[string]interface{} {
+	params := map[string]interface{}{}
+	for key, value := range r.Headers {
+		params[key] = value
+	}
+	switch body := r.Body.(type) {
+	case map[string]interface{}:
+		for key, value := range body {
+			params[key] = value
+		}
+	case map[string]string:
+		for key, value := range body {
+			params[key] = value
+		}
+	}
+	return params
+}
+
 // Response represents a generic API response
 type Response struct {
 	StatusCode int
 	Body       []byte
+
+	// Advance is how far the offset moves to reach the next page when it
+	// differs from the number of records in Body; see NextOffset
+	Advance int
+}
+
+// NextOffset returns the offset of the page after the one fetched at offset,
+// given the number of records extracted from the response
+func (r *Response) NextOffset(offset, count int) int {
+	if r.Advance > 0 {
+		return offset + r.Advance
+	}
+	return offset + count
+}
+
+// Exchange describes a completed API request
+type Exchange struct {
+	Request  Request
+	Response *Response
+	Started  time.Time
+	Duration time.Duration
+	Err      error
+}
+
+// Observer is called after every request performed by the client
+type Observer func(ex Exchange)
+
+// Guard is consulted before every request; returning an error aborts the
+// request without contacting the API
+type Guard func(req Request) error
+
+// SetGuard installs a guard for all subsequent requests
+func (c *Client) SetGuard(g Guard) {
+	c.guard = g
 }
 
-// Do performs an API request
+// AddObserver registers an observer for all subsequent requests
+func (c *Client) AddObserver(o Observer) {
+	c.observers = append(c.observers, o)
+}
+
+// Do performs an API request, records it in the audit log when one is set
+// and notifies the registered observers. A failure to write the audit log
+// is only warned about. It is safe for concurrent use.
 func (c *Client) Do(req Request) (*Response, error) {
+	started := time.Now()
+	var resp *Response
+	var err error
+	if c.guard != nil {
+		c.mu.Lock()
+		err = c.guard(req)
+		c.mu.Unlock()
+	}
+	if err == nil {
+		resp, err = c.do(req)
+	}
+
+	ex := Exchange{
+		Request:  req,
+		Response: resp,
+		Started:  started,
+		Duration: time.Since(started),
+		Err:      err,
+	}
+	// The request has been made and charged by now, so a failure to audit
+	// it is reported without withholding the response
+	if c.auditLog != nil {
+		if auditErr := c.writeAudit(ex); auditErr != nil {
+			fmt.Fprintf(c.warnings, "Warning: %v\n", auditErr)
+		}
+	}
+	c.mu.Lock()
+	for _, o := range c.observers {
+		o(ex)
+	}
+	c.mu.Unlock()
+	return resp, err
+}
+
+func (c *Client) do(req Request) (*Response, error) {
 	// Start spinner on stderr
 	done := make(chan bool)
 	go func() {
@@ -54,7 +222,7 @@ func (c *Client) Do(req Request) (*Response, error) {
 				return
 			default:
 				elapsed := time.Since(startTime).Round(time.Second)
-				fmt.Fprintf(os.Stderr, "\rProcessing (%v)...", elapsed)
+				fmt.Fprintf(c.progress, "\rProcessing (%v)...", elapsed)
 				time.Sleep(100 * time.Millisecond)
 			}
 		}
@@ -64,7 +232,7 @@ func (c *Client) Do(req Request) (*Response, error) {
 		jsonBody, err := json.Marshal(req.Body)
 		if err != nil {
 			done <- true
-			fmt.Fprint(os.Stderr, "\r")
+			fmt.Fprint(c.progress, "\r")
 			return nil, fmt.Errorf("failed to marshal request body: %w", err)
 		}
 		bodyReader = bytes.NewReader(jsonBody)
@@ -73,7 +241,7 @@ func (c *Client) Do(req Request) (*Response, error) {
 	httpReq, err := http.NewRequest(req.Method, baseURL+req.Path, bodyReader)
 	if err != nil {
 		done <- true
-		fmt.Fprint(os.Stderr, "\r")
+		fmt.Fprint(c.progress, "\r")
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
@@ -89,7 +257,7 @@ func (c *Client) Do(req Request) (*Response, error) {
 	resp, err := c.client.Do(httpReq)
 	if err != nil {
 		done <- true
-		fmt.Fprint(os.Stderr, "\r")
+		fmt.Fprint(c.progress, "\r")
 		return nil, fmt.Errorf("failed to perform request: %w", err)
 	}
 	defer resp.Body.Close()
@@ -97,7 +265,7 @@ func (c *Client) Do(req Request) (*Response, error) {
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		done <- true
-		fmt.Fprint(os.Stderr, "\r")
+		fmt.Fprint(c.progress, "\r")
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
@@ -107,7 +275,7 @@ func (c *Client) Do(req Request) (*Response, error) {
 		Body:       body,
 	}
 	done <- true
-	fmt.Fprint(os.Stderr, "\r")
+	fmt.Fprint(c.progress, "\r")
 	return result, nil
 }
 
@@ -115,7 +283,7 @@ func (c *Client) Do(req Request) (*Response, error) {
 func (c *Client) CheckCredits() (*Response, error) {
 	return c.Do(Request{
 		Method: "POST",
-		Path:   "/credits_left",
+		Path:   PathCreditsLeft,
 	})
 }
 
@@ -136,7 +304,7 @@ func (c *Client) YouTubeUserComments(userID, handle, channelID string, offset in
 
 	return c.Do(Request{
 		Method: "POST",
-		Path:   "/youtube/user_all_comments",
+		Path:   PathYouTubeUserComments,
 		Body:   body,
 	})
 }
@@ -145,7 +313,7 @@ func (c *Client) YouTubeUserComments(userID, handle, channelID string, offset in
 func (c *Client) YouTubeCommentReplies(commentID string) (*Response, error) {
 	return c.Do(Request{
 		Method: "POST",
-		Path:   "/youtube/comment_replies",
+		Path:   PathYouTubeCommentReplies,
 		Body: map[string]string{
 			"comment_id": commentID,
 		},
@@ -154,6 +322,11 @@ func (c *Client) YouTubeCommentReplies(commentID string) (*Response, error) {
 
 // ReversePhoneLookup performs a reverse phone lookup
 func (c *Client) ReversePhoneLookup(phone string, insecureMode bool) (*Response, error) {
+	phone, err := NormalizePhone(phone, c.region)
+	if err != nil {
+		return nil, err
+	}
+
 	headers := map[string]string{
 		"phone": phone,
 	}
@@ -163,13 +336,18 @@ func (c *Client) ReversePhoneLookup(phone string, insecureMode bool) (*Response,
 
 	return c.Do(Request{
 		Method:  "POST",
-		Path:    "/reverse_phone_lookup",
+		Path:    PathReversePhoneLookup,
 		Headers: headers,
 	})
 }
 
 // ReverseEmailLookup performs a reverse email lookup
 func (c *Client) ReverseEmailLookup(email string, insecureMode bool) (*Response, error) {
+	email, err := NormalizeEmail(email)
+	if err != nil {
+		return nil, err
+	}
+
 	headers := map[string]string{
 		"email": email,
 	}
@@ -179,7 +357,7 @@ func (c *Client) ReverseEmailLookup(email string, insecureMode bool) (*Response,
 
 	return c.Do(Request{
 		Method:  "POST",
-		Path:    "/reverse_email_lookup",
+		Path:    PathReverseEmailLookup,
 		Headers: headers,
 	})
 }
@@ -188,6 +366,16 @@ func (c *Client) ReverseEmailLookup(email string, insecureMode bool) (*Response,
 func (c *Client) TwitterHistoryLookup(handle string, id int64, byOld bool) (*Response, error) {
 	headers := map[string]string{}
 	if handle != "" {
+		if byOld {
+			// Legacy handles predate the current length limit
+			handle = strings.TrimPrefix(strings.TrimSpace(handle), "@")
+		} else {
+			var err error
+			handle, err = NormalizeUsername(PlatformTwitter, handle)
+			if err != nil {
+				return nil, err
+			}
+		}
 		headers["handle"] = handle
 	}
 	if id != 0 {
@@ -199,7 +387,7 @@ func (c *Client) TwitterHistoryLookup(handle string, id int64, byOld bool) (*Res
 
 	return c.Do(Request{
 		Method:  "POST",
-		Path:    "/twitter_history_lookup",
+		Path:    PathTwitterHistoryLookup,
 		Headers: headers,
 	})
 }
@@ -215,28 +403,43 @@ func (c *Client) DatabaseLookup(query string, exact bool) (*Response, error) {
 
 	return c.Do(Request{
 		Method:  "POST",
-		Path:    "/database_lookup",
+		Path:    PathDatabaseLookup,
 		Headers: headers,
 	})
 }
 
-// TwitchUserMessages retrieves all messages from a Twitch user
-func (c *Client) TwitchUserMessages(username, server string, offset int) (*Response, error) {
+// TwitchUserMessages retrieves all messages from a Twitch user. With
+// TwitchServerAuto every server is queried and the results are merged.
+func (c *Client) TwitchUserMessages(username string, server TwitchServer, offset int) (*Response, error) {
+	username, err := NormalizeUsername(PlatformTwitch, username)
+	if err != nil {
+		return nil, err
+	}
+
+	if server == TwitchServerAuto {
+		return c.twitchMessagesAuto(username, offset)
+	}
+
 	headers := map[string]string{
 		"username": username,
-		"server":   server,
+		"server":   string(server),
 		"offset":   fmt.Sprintf("%d", offset),
 	}
 
 	return c.Do(Request{
 		Method:  "POST",
-		Path:    "/twitch/user_all_messages",
+		Path:    PathTwitchUserMessages,
 		Headers: headers,
 	})
 }
 
 // TwitchUserTimeouts retrieves chat bans/timeouts for a Twitch user
 func (c *Client) TwitchUserTimeouts(username string, offset int) (*Response, error) {
+	username, err := NormalizeUsername(PlatformTwitch, username)
+	if err != nil {
+		return nil, err
+	}
+
 	headers := map[string]string{
 		"username": username,
 		"offset":   fmt.Sprintf("%d", offset),
@@ -244,53 +447,80 @@ func (c *Client) TwitchUserTimeouts(username string, offset int) (*Response, err
 
 	return c.Do(Request{
 		Method:  "POST",
-		Path:    "/twitch/user_all_timeouts",
+		Path:    PathTwitchUserTimeouts,
 		Headers: headers,
 	})
 }
 
-// TwitchUserHistory retrieves Twitch user history
-func (c *Client) TwitchUserHistory(username, mode string) (*Response, error) {
+// TwitchUserHistory retrieves Twitch user history for a single mode; use
+// TwitchUserHistoryAll for HistoryModeAll
+func (c *Client) TwitchUserHistory(username string, mode HistoryMode) (*Response, error) {
+	username, err := NormalizeUsername(PlatformTwitch, username)
+	if err != nil {
+		return nil, err
+	}
+
+	switch mode {
+	case HistoryModeUsername, HistoryModeUserType, HistoryModeBroadcasterType:
+	default:
+		return nil, fmt.Errorf("unsupported history mode %q", mode)
+	}
+
 	headers := map[string]string{
 		"username": username,
-		"mode":     mode,
+		"mode":     string(mode),
 	}
 
 	return c.Do(Request{
 		Method:  "POST",
-		Path:    "/twitch/user_history",
+		Path:    PathTwitchUserHistory,
 		Headers: headers,
 	})
 }
 
 // TwitchFollowage retrieves following list of a Twitch user
 func (c *Client) TwitchFollowage(username string) (*Response, error) {
+	username, err := NormalizeUsername(PlatformTwitch, username)
+	if err != nil {
+		return nil, err
+	}
+
 	headers := map[string]string{
 		"username": username,
 	}
 
 	return c.Do(Request{
 		Method:  "POST",
-		Path:    "/twitch/followage",
+		Path:    PathTwitchFollowage,
 		Headers: headers,
 	})
 }
 
 // TwitchFollowers retrieves followers list of a Twitch user
 func (c *Client) TwitchFollowers(username string) (*Response, error) {
+	username, err := NormalizeUsername(PlatformTwitch, username)
+	if err != nil {
+		return nil, err
+	}
+
 	headers := map[string]string{
 		"username": username,
 	}
 
 	return c.Do(Request{
 		Method:  "POST",
-		Path:    "/twitch/followers",
+		Path:    PathTwitchFollowers,
 		Headers: headers,
 	})
 }
 
 // KickUserMessages retrieves all messages from a Kick user
 func (c *Client) KickUserMessages(username string, offset int) (*Response, error) {
+	username, err := NormalizeUsername(PlatformKick, username)
+	if err != nil {
+		return nil, err
+	}
+
 	headers := map[string]string{
 		"username": username,
 		"offset":   fmt.Sprintf("%d", offset),
@@ -298,46 +528,61 @@ func (c *Client) KickUserMessages(username string, offset int) (*Response, error
 
 	return c.Do(Request{
 		Method:  "POST",
-		Path:    "/kick/user_all_messages",
+		Path:    PathKickUserMessages,
 		Headers: headers,
 	})
 }
 
 // KickUserTimeouts retrieves chat bans/timeouts for a Kick user
 func (c *Client) KickUserTimeouts(username string) (*Response, error) {
+	username, err := NormalizeUsername(PlatformKick, username)
+	if err != nil {
+		return nil, err
+	}
+
 	headers := map[string]string{
 		"username": username,
 	}
 
 	return c.Do(Request{
 		Method:  "POST",
-		Path:    "/kick/user_all_timeouts",
+		Path:    PathKickUserTimeouts,
 		Headers: headers,
 	})
 }
 
 // KickUserModChannels retrieves channels where a Kick user is a moderator
 func (c *Client) KickUserModChannels(username string) (*Response, error) {
+	username, err := NormalizeUsername(PlatformKick, username)
+	if err != nil {
+		return nil, err
+	}
+
 	headers := map[string]string{
 		"username": username,
 	}
 
 	return c.Do(Request{
 		Method:  "POST",
-		Path:    "/kick/user_channel_mods_in",
+		Path:    PathKickUserModChannels,
 		Headers: headers,
 	})
 }
 
-// KickUserSubscribers retrieves subscribers list of a Kick user
+// KickUserSubscribers retrieves the channels a Kick user is subscribed to
 func (c *Client) KickUserSubscribers(username string) (*Response, error) {
+	username, err := NormalizeUsername(PlatformKick, username)
+	if err != nil {
+		return nil, err
+	}
+
 	headers := map[string]string{
 		"username": username,
 	}
 
 	return c.Do(Request{
 		Method:  "POST",
-		Path:    "/kick/user_subscribers_list",
+		Path:    PathKickUserSubscribers,
 		Headers: headers,
 	})
-} 
\ No newline at end of file
+}
@@ -0,0 +1,19 @@
+package api
+
+// DefaultCreditCost is the number of credits a request is assumed to cost
+// when no override is configured. The API does not report the cost of
+// individual requests, so all figures are estimates.
+const DefaultCreditCost = 1
+
+// SetCreditCosts overrides the estimated credit cost per endpoint path
+func (c *Client) SetCreditCosts(costs map[string]int) {
+	c.costs = costs
+}
+
+// EstimatedCost returns the estimated credit cost of a request to path
+func (c *Client) EstimatedCost(path string) int {
+	if cost, ok := c.costs[path]; ok {
+		return cost
+	}
+	return DefaultCreditCost
+}
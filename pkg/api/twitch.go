@@ -0,0 +1,203 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/records"
+)
+
+// TwitchServer selects the archive server queried for Twitch messages
+type TwitchServer string
+
+// Twitch message servers
+const (
+	TwitchServerSuperserver2 TwitchServer = "superserver2"
+	TwitchServerMain         TwitchServer = "main"
+	// TwitchServerAuto queries every server and merges the results
+	TwitchServerAuto TwitchServer = "auto"
+)
+
+// TwitchServers lists the concrete servers queried by TwitchServerAuto, in
+// order of preference
+var TwitchServers = []TwitchServer{TwitchServerSuperserver2, TwitchServerMain}
+
+// ServerField is added to every record merged by TwitchServerAuto and names
+// the server(s) the record was found on
+const ServerField = "_server"
+
+// ParseTwitchServer validates a server name
+func ParseTwitchServer(s string) (TwitchServer, error) {
+	switch server := TwitchServer(s); server {
+	case TwitchServerSuperserver2, TwitchServerMain, TwitchServerAuto:
+		return server, nil
+	}
+	return "", fmt.Errorf("unknown Twitch server %q (expected superserver2, main, or auto)", s)
+}
+
+// Requests returns the number of API requests one query to the server makes
+func (s TwitchServer) Requests() int {
+	if s == TwitchServerAuto {
+		return len(TwitchServers)
+	}
+	return 1
+}
+
+// twitchMessagesAuto queries every server and merges the messages into a
+// single JSON array. Servers that fail are skipped with a warning; if all
+// fail, the first response or error is returned.
+func (c *Client) twitchMessagesAuto(username string, offset int) (*Response, error) {
+	var pages [][]records.Record
+	var servers []TwitchServer
+	var firstResp *Response
+	var firstErr error
+
+	for _, server := range TwitchServers {
+		recs, resp, err := c.twitchServerPage(username, server, offset)
+		if err != nil || resp.StatusCode != 200 {
+			if err == nil {
+				err = fmt.Errorf("unexpected response (Status %d)", resp.StatusCode)
+			}
+			fmt.Fprintf(c.warnings, "Warning: Twitch server %s failed: %v\n", server, err)
+			if firstErr == nil && firstResp == nil {
+				if resp != nil && resp.StatusCode != 200 {
+					firstResp = resp
+				} else {
+					firstErr = err
+				}
+			}
+			continue
+		}
+		pages = append(pages, recs)
+		servers = append(servers, server)
+	}
+
+	if len(pages) == 0 {
+		if firstResp != nil {
+			return firstResp, nil
+		}
+		return nil, firstErr
+	}
+
+	body, err := json.Marshal(mergeMessages(servers, pages))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal merged messages: %w", err)
+	}
+	return &Response{StatusCode: 200, Body: body, Advance: pageAdvance(pages)}, nil
+}
+
+// twitchServerPage fetches and extracts one page from a single server
+func (c *Client) twitchServerPage(username string, server TwitchServer, offset int) ([]records.Record, *Response, error) {
+	resp, err := c.TwitchUserMessages(username, server, offset)
+	if err != nil || resp.StatusCode != 200 {
+		return nil, resp, err
+	}
+	recs, err := records.Extract(resp.Body)
+	return recs, resp, err
+}
+
+// mergeMessages joins the pages returned by servers, de-duplicated by
+// messageKey. Every record is tagged with the servers it was found on.
+func mergeMessages(servers []TwitchServer, pages [][]records.Record) []records.Record {
+	merged := []records.Record{}
+	index := map[string]records.Record{}
+	for i, recs := range pages {
+		for _, rec := range recs {
+			key := messageKey(rec)
+			if seen, ok := index[key]; ok {
+				seen[ServerField] = seen.String(ServerField) + "," + string(servers[i])
+				continue
+			}
+			rec[ServerField] = string(servers[i])
+			index[key] = rec
+			merged = append(merged, rec)
+		}
+	}
+	return merged
+}
+
+// pageAdvance returns how far the shared offset may move without skipping
+// records on any server: the length of the shortest non-empty page. Servers
+// with longer pages return some records again on the next page, and those
+// are dropped as duplicates by the caller.
+func pageAdvance(pages [][]records.Record) int {
+	advance := 0
+	for _, recs := range pages {
+		if n := len(recs); n > 0 && (advance == 0 || n < advance) {
+			advance = n
+		}
+	}
+	return advance
+}
+
+// messageKey identifies a message across servers
+func messageKey(rec records.Record) string {
+	if id := rec.String(records.IDKeys...); id != "" {
+		return "id:" + id
+	}
+	parts := []string{
+		rec.String(records.TimeKeys...),
+		rec.String("channel", "channel_name", "room"),
+		rec.String("message", "text", "content", "msg"),
+	}
+	if parts[0] == "" && parts[2] == "" {
+		return "hash:" + rec.Hash()
+	}
+	return "msg:" + strings.Join(parts, "\x00")
+}
+
+// HistoryMode selects which part of a Twitch account's history is returned
+type HistoryMode string
+
+// Twitch history modes
+const (
+	HistoryModeUsername        HistoryMode = "username"
+	HistoryModeUserType        HistoryMode = "utype"
+	HistoryModeBroadcasterType HistoryMode = "btype"
+	// HistoryModeAll fetches every mode
+	HistoryModeAll HistoryMode = "all"
+)
+
+// HistoryModes lists the modes fetched by HistoryModeAll
+var HistoryModes = []HistoryMode{HistoryModeUsername, HistoryModeUserType, HistoryModeBroadcasterType}
+
+// ParseHistoryMode validates a history mode name, ignoring case
+func ParseHistoryMode(s string) (HistoryMode, error) {
+	switch mode := HistoryMode(strings.ToLower(strings.TrimSpace(s))); mode {
+	case HistoryModeUsername, HistoryModeUserType, HistoryModeBroadcasterType, HistoryModeAll:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown history mode %q (expected username, utype, btype, or all)", s)
+}
+
+// Description returns a human readable name for the mode
+func (m HistoryMode) Description() string {
+	switch m {
+	case HistoryModeUsername:
+		return "Username history"
+	case HistoryModeUserType:
+		return "User type history"
+	case HistoryModeBroadcasterType:
+		return "Broadcaster type history"
+	}
+	return string(m)
+}
+
+// TwitchUserHistoryAll retrieves every history mode for a Twitch user. A
+// mode whose request fails is left out of the results and the remaining
+// modes are still fetched; the errors are returned joined.
+func (c *Client) TwitchUserHistoryAll(username string) (map[HistoryMode]*Response, error) {
+	results := make(map[HistoryMode]*Response, len(HistoryModes))
+	var errs []error
+	for _, mode := range HistoryModes {
+		resp, err := c.TwitchUserHistory(username, mode)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", mode, err))
+			continue
+		}
+		results[mode] = resp
+	}
+	return results, errors.Join(errs...)
+}
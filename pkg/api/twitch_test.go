@@ -0,0 +1,157 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/records"
+)
+
+func TestMessageKey(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b records.Record
+		same bool
+	}{
+		{"same id", records.Record{"id": "1", "message": "hi"}, records.Record{"id": "1", "message": "edited"}, true},
+		{"different id", records.Record{"id": "1"}, records.Record{"id": "2"}, false},
+		{"id field names", records.Record{"id": "1"}, records.Record{"message_id": "1"}, true},
+		{
+			"time, channel and text",
+			records.Record{"timestamp": "2024-01-01T00:00:00Z", "channel": "foo", "message": "hi", "color": "red"},
+			records.Record{"timestamp": "2024-01-01T00:00:00Z", "channel": "foo", "message": "hi"},
+			true,
+		},
+		{
+			"different channel",
+			records.Record{"timestamp": "2024-01-01T00:00:00Z", "channel": "foo", "message": "hi"},
+			records.Record{"timestamp": "2024-01-01T00:00:00Z", "channel": "bar", "message": "hi"},
+			false,
+		},
+		{"no time or text", records.Record{"channel": "foo"}, records.Record{"channel": "foo", "x": 1}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := messageKey(tt.a) == messageKey(tt.b); got != tt.same {
+				t.Errorf("messageKey(%v) == messageKey(%v) is %v, want %v", tt.a, tt.b, got, tt.same)
+			}
+		})
+	}
+}
+
+func TestMergeMessages(t *testing.T) {
+	servers := []TwitchServer{TwitchServerSuperserver2, TwitchServerMain}
+	pages := [][]records.Record{
+		{{"id": "1"}, {"id": "2"}},
+		{{"id": "2"}, {"id": "3"}},
+	}
+
+	merged := mergeMessages(servers, pages)
+	want := map[string]string{"1": "superserver2", "2": "superserver2,main", "3": "main"}
+	if len(merged) != len(want) {
+		t.Fatalf("merged %d records, want %d: %v", len(merged), len(want), merged)
+	}
+	for i, id := range []string{"1", "2", "3"} {
+		if got := merged[i].String("id"); got != id {
+			t.Errorf("merged[%d] = %s, want %s", i, got, id)
+		}
+		if got := merged[i].String(ServerField); got != want[id] {
+			t.Errorf("%s found on %q, want %q", id, got, want[id])
+		}
+	}
+}
+
+func TestPageAdvance(t *testing.T) {
+	page := func(n int) []records.Record {
+		return make([]records.Record, n)
+	}
+
+	tests := []struct {
+		name  string
+		pages [][]records.Record
+		want  int
+	}{
+		{"equal pages", [][]records.Record{page(100), page(100)}, 100},
+		{"shorter page", [][]records.Record{page(100), page(40)}, 40},
+		{"exhausted server", [][]records.Record{page(100), page(0)}, 100},
+		{"all empty", [][]records.Record{page(0), page(0)}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pageAdvance(tt.pages); got != tt.want {
+				t.Errorf("pageAdvance = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResponseNextOffset(t *testing.T) {
+	if got := (&Response{}).NextOffset(100, 50); got != 150 {
+		t.Errorf("NextOffset without Advance = %d, want 150", got)
+	}
+	if got := (&Response{Advance: 100}).NextOffset(100, 200); got != 200 {
+		t.Errorf("NextOffset with Advance = %d, want 200", got)
+	}
+}
+
+func TestParseHistoryMode(t *testing.T) {
+	tests := []struct {
+		s    string
+		want HistoryMode
+		ok   bool
+	}{
+		{"username", HistoryModeUsername, true},
+		{"utype", HistoryModeUserType, true},
+		{"btype", HistoryModeBroadcasterType, true},
+		{"all", HistoryModeAll, true},
+		{"BType", HistoryModeBroadcasterType, true},
+		{" All ", HistoryModeAll, true},
+		{"", "", false},
+		{"usernames", "", false},
+		{"type", "", false},
+	}
+	for _, tt := range tests {
+		got, err := ParseHistoryMode(tt.s)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ParseHistoryMode(%q) = %q, %v; want %q, ok %v", tt.s, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+// roundTripFunc serves requests without a network
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestTwitchUserHistoryAll(t *testing.T) {
+	c := NewClient("key")
+	c.SetProgress(nil)
+	c.client = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		switch req.Header.Get("mode") {
+		case string(HistoryModeUserType):
+			return nil, errors.New("connection reset")
+		case string(HistoryModeBroadcasterType):
+			return &http.Response{StatusCode: 500, Body: io.NopCloser(strings.NewReader(`{}`))}, nil
+		}
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(`[{"username": "old"}]`))}, nil
+	})}
+
+	results, err := c.TwitchUserHistoryAll("foo")
+	if err == nil || !strings.Contains(err.Error(), "utype") {
+		t.Errorf("TwitchUserHistoryAll error = %v, want the utype failure", err)
+	}
+	// The failed mode does not stop the others
+	if len(results) != 2 || results[HistoryModeUsername].StatusCode != 200 || results[HistoryModeBroadcasterType].StatusCode != 500 {
+		t.Errorf("results = %v, want username and btype", results)
+	}
+	if _, ok := results[HistoryModeUserType]; ok {
+		t.Errorf("failed utype request is in the results")
+	}
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+)
+
+// Platforms accepted by NormalizeUsername
+const (
+	PlatformTwitter = "twitter"
+	PlatformTwitch  = "twitch"
+	PlatformKick    = "kick"
+)
+
+// ValidationError reports an input rejected before it was sent to the API
+type ValidationError struct {
+	Field  string
+	Value  string
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid %s %q: %s", e.Field, e.Value, e.Reason)
+}
+
+// callingCodes maps ISO 3166 region codes to their country calling codes
+var callingCodes = map[string]string{
+	"US": "1", "CA": "1", "GB": "44", "IE": "353", "FR": "33", "DE": "49",
+	"NL": "31", "BE": "32", "LU": "352", "CH": "41", "AT": "43", "IT": "39",
+	"ES": "34", "PT": "351", "DK": "45", "SE": "46", "NO": "47", "FI": "358",
+	"IS": "354", "PL": "48", "CZ": "420", "SK": "421", "HU": "36", "RO": "40",
+	"BG": "359", "GR": "30", "TR": "90", "UA": "380", "RU": "7", "KZ": "7",
+	"IL": "972", "AE": "971", "SA": "966", "IN": "91", "PK": "92", "CN": "86",
+	"HK": "852", "TW": "886", "JP": "81", "KR": "82", "SG": "65", "MY": "60",
+	"TH": "66", "VN": "84", "PH": "63", "ID": "62", "AU": "61", "NZ": "64",
+	"ZA": "27", "NG": "234", "EG": "20", "MA": "212", "KE": "254", "BR": "55",
+	"AR": "54", "CL": "56", "CO": "57", "PE": "51", "MX": "52",
+}
+
+var (
+	phoneSeparators = strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "", "/", "")
+	digitsOnly      = regexp.MustCompile(`^\d+$`)
+
+	usernamePatterns = map[string]*regexp.Regexp{
+		PlatformTwitter: regexp.MustCompile(`^[A-Za-z0-9_]{1,15}$`),
+		PlatformTwitch:  regexp.MustCompile(`^[a-z0-9_]{3,25}$`),
+		PlatformKick:    regexp.MustCompile(`^[A-Za-z0-9_-]{2,25}$`),
+	}
+	usernameRules = map[string]string{
+		PlatformTwitter: "expected 1-15 letters, digits or underscores",
+		PlatformTwitch:  "expected 3-25 letters, digits or underscores",
+		PlatformKick:    "expected 2-25 letters, digits, underscores or hyphens",
+	}
+)
+
+// NormalizePhone converts a phone number to E.164 (+<country><number>).
+// Numbers without a leading + or 00 are interpreted in defaultRegion, an
+// ISO 3166 code such as "US". Without a default region such numbers are
+// only stripped of separators and passed on as they are.
+func NormalizePhone(phone, defaultRegion string) (string, error) {
+	s := phoneSeparators.Replace(strings.TrimSpace(phone))
+	invalid := func(reason string) (string, error) {
+		return "", &ValidationError{Field: "phone", Value: phone, Reason: reason}
+	}
+
+	var digits string
+	switch {
+	case strings.HasPrefix(s, "+"):
+		digits = s[1:]
+	case strings.HasPrefix(s, "00"):
+		digits = s[2:]
+	default:
+		if defaultRegion == "" {
+			if s == "" || !digitsOnly.MatchString(s) {
+				return invalid("only digits and separators are allowed")
+			}
+			return s, nil
+		}
+		code, ok := callingCodes[strings.ToUpper(defaultRegion)]
+		if !ok {
+			return invalid(fmt.Sprintf("unknown default region %q", defaultRegion))
+		}
+		if !digitsOnly.MatchString(s) {
+			return invalid("only digits and separators are allowed")
+		}
+		switch {
+		case code == "1" && len(s) == 11 && s[0] == '1':
+			// North American number already carrying the country code
+			digits = s
+		case code != "1" && strings.HasPrefix(s, "0"):
+			// Drop the national trunk prefix
+			digits = code + s[1:]
+		default:
+			digits = code + s
+		}
+	}
+
+	if !digitsOnly.MatchString(digits) {
+		return invalid("only digits and separators are allowed")
+	}
+	if digits[0] == '0' {
+		return invalid("country code cannot start with 0")
+	}
+	if len(digits) > 15 {
+		return invalid("too long for E.164 (max 15 digits)")
+	}
+	if len(digits) < 8 {
+		return invalid("too short")
+	}
+	return "+" + digits, nil
+}
+
+// NormalizeEmail validates an email address and lowercases it
+func NormalizeEmail(email string) (string, error) {
+	s := strings.TrimSpace(email)
+	invalid := func(reason string) (string, error) {
+		return "", &ValidationError{Field: "email", Value: email, Reason: reason}
+	}
+
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Address != s || addr.Name != "" {
+		return invalid("not a plain address of the form name@domain")
+	}
+	local, domain, _ := strings.Cut(s, "@")
+	if len(local) > 64 || len(s) > 254 {
+		return invalid("too long")
+	}
+	if !strings.Contains(domain, ".") || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") || strings.Contains(domain, "..") {
+		return invalid("domain must be a dotted host name")
+	}
+	return strings.ToLower(s), nil
+}
+
+// UsernamePattern returns the rule usernames on platform must match, or nil
+// for an unknown platform. The pattern applies after NormalizeUsername has
+// stripped the @ and lowercased Twitch logins.
+func UsernamePattern(platform string) *regexp.Regexp {
+	return usernamePatterns[platform]
+}
+
+// NormalizeUsername validates a username against the platform's rules,
+// stripping a leading @. Twitch logins are lowercased.
+func NormalizeUsername(platform, username string) (string, error) {
+	s := strings.TrimPrefix(strings.TrimSpace(username), "@")
+	if platform == PlatformTwitch {
+		s = strings.ToLower(s)
+	}
+
+	pattern, ok := usernamePatterns[platform]
+	if !ok {
+		return "", fmt.Errorf("unknown platform %q", platform)
+	}
+	if !pattern.MatchString(s) {
+		return "", &ValidationError{Field: platform + " username", Value: username, Reason: usernameRules[platform]}
+	}
+	return s, nil
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		phone, region, want string
+		ok                  bool
+	}{
+		{"+1 (415) 555-0100", "", "+14155550100", true},
+		{"0044 20 7946 0958", "", "+442079460958", true},
+		{"415-555-0100", "US", "+14155550100", true},
+		{"1 415 555 0100", "us", "+14155550100", true},
+		{"020 7946 0958", "GB", "+442079460958", true},
+		{"555-123-4567", "", "5551234567", true},
+		{"5551234567", "", "5551234567", true},
+		{"555-CALL-NOW", "", "", false},
+		{"", "", "", false},
+		{"020 7946 0958", "XX", "", false},
+		{"+0 123 456 789", "", "", false},
+		{"+1234567890123456", "", "", false},
+		{"+1 234", "", "", false},
+	}
+	for _, tt := range tests {
+		got, err := NormalizePhone(tt.phone, tt.region)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("NormalizePhone(%q, %q) = %q, %v; want %q, ok %v", tt.phone, tt.region, got, err, tt.want, tt.ok)
+		}
+		var verr *ValidationError
+		if err != nil && !errors.As(err, &verr) {
+			t.Errorf("NormalizePhone(%q) error %T, want *ValidationError", tt.phone, err)
+		}
+	}
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := []struct {
+		email, want string
+		ok          bool
+	}{
+		{"John.Doe@Example.COM", "john.doe@example.com", true},
+		{"  a+tag@mail.example.org ", "a+tag@mail.example.org", true},
+		{"John <john@example.com>", "", false},
+		{"john@localhost", "", false},
+		{"john@example..com", "", false},
+		{"john@.example.com", "", false},
+		{"no-at-sign.example.com", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeEmail(tt.email)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("NormalizeEmail(%q) = %q, %v; want %q, ok %v", tt.email, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestNormalizeUsername(t *testing.T) {
+	tests := []struct {
+		platform, username, want string
+		ok                       bool
+	}{
+		{PlatformTwitter, "@jack", "jack", true},
+		{PlatformTwitter, "abcdefghijklmno", "abcdefghijklmno", true},
+		{PlatformTwitter, "abcdefghijklmnop", "", false},
+		{PlatformTwitter, "jack-dorsey", "", false},
+		{PlatformTwitch, "XQC", "xqc", true},
+		{PlatformTwitch, "ab", "", false},
+		{PlatformTwitch, "has-hyphen", "", false},
+		{PlatformKick, "Train-Wreck_tv", "Train-Wreck_tv", true},
+		{PlatformKick, "a", "", false},
+		{PlatformKick, "has space", "", false},
+		{"myspace", "tom", "", false},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeUsername(tt.platform, tt.username)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("NormalizeUsername(%s, %q) = %q, %v; want %q, ok %v", tt.platform, tt.username, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestTwitterHistoryLookupByOld(t *testing.T) {
+	// The guard sees the request before it is sent, so no server is needed
+	stop := errors.New("stop")
+	var sent Request
+	c := NewClient("key")
+	c.SetGuard(func(req Request) error {
+		sent = req
+		return stop
+	})
+
+	// Legacy handles may be longer than today's 15 character limit
+	if _, err := c.TwitterHistoryLookup("@averyveryverylonghandle", 0, true); !errors.Is(err, stop) {
+		t.Fatalf("by-old lookup = %v, want it sent", err)
+	}
+	if sent.Headers["handle"] != "averyveryverylonghandle" || sent.Headers["byold"] != "true" {
+		t.Errorf("headers = %v, want the handle without @ and byold", sent.Headers)
+	}
+
+	var verr *ValidationError
+	if _, err := c.TwitterHistoryLookup("averyveryverylonghandle", 0, false); !errors.As(err, &verr) {
+		t.Errorf("current lookup = %v, want a validation error", err)
+	}
+}
@@ -0,0 +1,118 @@
+// Package archive stores timestamped snapshots of API responses on disk so
+// that later runs can compare against or reuse them.
+package archive
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/config"
+)
+
+const (
+	archiveDir = "archive"
+	timeFormat = "20060102T150405.000000000Z"
+)
+
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Snapshot represents a single archived API response
+type Snapshot struct {
+	Kind string          `json:"kind"`
+	Key  string          `json:"key"`
+	Time time.Time       `json:"time"`
+	Body json.RawMessage `json:"body"`
+}
+
+func snapshotDir(kind, key string) (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	key = unsafeChars.ReplaceAllString(strings.ToLower(key), "_")
+	return filepath.Join(dir, archiveDir, unsafeChars.ReplaceAllString(kind, "_"), key), nil
+}
+
+// Save archives body as the newest snapshot for kind and key
+func Save(kind, key string, body []byte) (*Snapshot, error) {
+	dir, err := snapshotDir(kind, key)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	snap := &Snapshot{Kind: kind, Key: key, Time: time.Now().UTC()}
+	if json.Valid(body) {
+		snap.Body = json.RawMessage(body)
+	} else {
+		// Keep non-JSON responses as a JSON string
+		quoted, _ := json.Marshal(string(body))
+		snap.Body = quoted
+	}
+
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+
+	path := filepath.Join(dir, snap.Time.Format(timeFormat)+".json")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return snap, nil
+}
+
+// List returns the snapshots for kind and key, oldest first
+func List(kind, key string) ([]*Snapshot, error) {
+	dir, err := snapshotDir(kind, key)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read archive directory: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	snaps := make([]*Snapshot, 0, len(names))
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read snapshot: %w", err)
+		}
+		var snap Snapshot
+		if err := json.Unmarshal(data, &snap); err != nil {
+			return nil, fmt.Errorf("failed to parse snapshot %s: %w", name, err)
+		}
+		snaps = append(snaps, &snap)
+	}
+	return snaps, nil
+}
+
+// Latest returns the newest snapshot for kind and key, or nil if none exist
+func Latest(kind, key string) (*Snapshot, error) {
+	snaps, err := List(kind, key)
+	if err != nil || len(snaps) == 0 {
+		return nil, err
+	}
+	return snaps[len(snaps)-1], nil
+}
@@ -0,0 +1,248 @@
+// Package audit maintains an append-only, hash-chained log of every lookup
+// performed. Each entry carries the hash of its predecessor, so editing,
+// removing or reordering entries breaks the chain and is detected by Verify.
+package audit
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ivan9253/lolarchiver-cli/internal/jsonlog"
+	"github.com/ivan9253/lolarchiver-cli/pkg/config"
+)
+
+const (
+	logFile = "audit.log"
+	// keyFile holds the key of the query hashes, next to the log
+	keyFile = "audit.key"
+)
+
+// Entry represents a single audited request
+type Entry struct {
+	Seq        int       `json:"seq"`
+	Time       time.Time `json:"time"`
+	Operator   string    `json:"operator"`
+	Profile    string    `json:"profile"`
+	Case       string    `json:"case,omitempty"`
+	Endpoint   string    `json:"endpoint"`
+	QueryHash  string    `json:"query_hash"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Credits    int       `json:"credits"`
+	Reason     string    `json:"reason,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	PrevHash   string    `json:"prev_hash"`
+	Hash       string    `json:"hash"`
+}
+
+// Log represents an audit log file
+type Log struct {
+	path string
+	key  []byte
+	mu   sync.Mutex
+}
+
+// DefaultPath returns the location of the audit log
+func DefaultPath() (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, logFile), nil
+}
+
+// LoadKey returns the key of the query hashes stored in dir, creating a
+// random one on first use
+func LoadKey(dir string) ([]byte, error) {
+	key, err := config.LoadKey(filepath.Join(dir, keyFile))
+	if err != nil {
+		return nil, fmt.Errorf("audit key: %w", err)
+	}
+	return key, nil
+}
+
+// Open returns the audit log stored at path, hashing queries with key. The
+// file is created on the first append.
+func Open(path string, key []byte) *Log {
+	return &Log{path: path, key: key}
+}
+
+// Path returns the location of the log file
+func (l *Log) Path() string {
+	return l.path
+}
+
+// HashQuery returns the hex HMAC-SHA-256 of the canonical JSON encoding of
+// the query parameters, keyed per install, so the log proves what was asked
+// without storing it and without the query being recoverable by hashing
+// guessed phone numbers or emails
+func (l *Log) HashQuery(params map[string]interface{}) string {
+	data, _ := json.Marshal(params)
+	mac := hmac.New(sha256.New, l.key)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// computeHash returns the hash of an entry, which covers every field except
+// the hash itself
+func computeHash(e Entry) string {
+	e.Hash = ""
+	data, _ := json.Marshal(e)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Append chains the entry onto the log, filling in its sequence number and
+// hashes. The file is locked while the last entry is read and the new one
+// written, so processes sharing the log keep a single chain.
+func (l *Log) Append(e Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0700); err != nil {
+		return fmt.Errorf("failed to create audit directory: %w", err)
+	}
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	if err := jsonlog.Lock(f); err != nil {
+		return fmt.Errorf("failed to lock audit log: %w", err)
+	}
+	defer jsonlog.Unlock(f)
+
+	var last Entry
+	found, err := jsonlog.Last(f, &last)
+	if err != nil {
+		return fmt.Errorf("failed to read last audit entry: %w", err)
+	}
+	e.Seq = 1
+	e.PrevHash = ""
+	if found {
+		e.Seq = last.Seq + 1
+		e.PrevHash = last.Hash
+	}
+	e.Hash = computeHash(e)
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// Read returns every entry in the log at path without verifying it
+func Read(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("audit log line %d: %w", line, err)
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// VerifyError describes the first break in the hash chain
+type VerifyError struct {
+	Seq    int
+	Reason string
+}
+
+func (e *VerifyError) Error() string {
+	return fmt.Sprintf("audit log tampered at entry %d: %s", e.Seq, e.Reason)
+}
+
+// Verify checks the hash chain of the log at path and returns the number of
+// entries and the hash of the last one. Truncation of trailing entries can
+// only be detected by comparing the returned head hash with a previously
+// recorded value.
+func Verify(path string) (int, string, error) {
+	entries, err := Read(path)
+	if err != nil {
+		return 0, "", err
+	}
+
+	prev := ""
+	for i, e := range entries {
+		if e.Seq != i+1 {
+			return i, prev, &VerifyError{Seq: e.Seq, Reason: "expected sequence " + strconv.Itoa(i+1)}
+		}
+		if e.PrevHash != prev {
+			return i, prev, &VerifyError{Seq: e.Seq, Reason: "previous hash does not match"}
+		}
+		if computeHash(e) != e.Hash {
+			return i, prev, &VerifyError{Seq: e.Seq, Reason: "entry hash does not match its contents"}
+		}
+		prev = e.Hash
+	}
+	return len(entries), prev, nil
+}
+
+// Entries is a list of audit entries
+type Entries []Entry
+
+// Columns implements output.Tabular
+func (es Entries) Columns() []string {
+	return []string{"SEQ", "TIME", "OPERATOR", "PROFILE", "CASE", "ENDPOINT", "QUERY_HASH", "STATUS", "CREDITS", "REASON", "ERROR", "HASH"}
+}
+
+// Rows implements output.Tabular
+func (es Entries) Rows() [][]string {
+	rows := make([][]string, 0, len(es))
+	for _, e := range es {
+		status := ""
+		if e.StatusCode != 0 {
+			status = strconv.Itoa(e.StatusCode)
+		}
+		rows = append(rows, []string{
+			strconv.Itoa(e.Seq),
+			e.Time.Format(time.RFC3339),
+			e.Operator,
+			e.Profile,
+			e.Case,
+			e.Endpoint,
+			e.QueryHash,
+			status,
+			strconv.Itoa(e.Credits),
+			e.Reason,
+			e.Error,
+			e.Hash,
+		})
+	}
+	return rows
+}
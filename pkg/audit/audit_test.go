@@ -0,0 +1,145 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAppendChainsEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l := Open(path, nil)
+	for i := 0; i < 3; i++ {
+		if err := l.Append(Entry{Time: time.Now().UTC(), Endpoint: "/credits_left"}); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+
+	n, head, err := Verify(path)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if n != 3 || head == "" {
+		t.Errorf("Verify = %d entries, head %q; want 3 and a hash", n, head)
+	}
+}
+
+func TestAppendFromSeveralLogs(t *testing.T) {
+	// Two Log values stand in for two processes appending to the same file,
+	// e.g. a running watch and a one-off lookup
+	path := filepath.Join(t.TempDir(), "audit.log")
+	a, b := Open(path, nil), Open(path, nil)
+
+	if err := a.Append(Entry{Endpoint: "/a"}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if err := b.Append(Entry{Endpoint: "/b"}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if err := a.Append(Entry{Endpoint: "/a"}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for _, l := range []*Log{a, b} {
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(l *Log) {
+				defer wg.Done()
+				if err := l.Append(Entry{Endpoint: "/concurrent"}); err != nil {
+					t.Errorf("Append: %v", err)
+				}
+			}(l)
+		}
+	}
+	wg.Wait()
+
+	n, _, err := Verify(path)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if n != 43 {
+		t.Errorf("Verify = %d entries, want 43", n)
+	}
+}
+
+func TestAppendAfterLongEntry(t *testing.T) {
+	// The last entry is found by reading backwards in chunks, so an entry
+	// longer than one chunk must still be read whole
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l := Open(path, nil)
+	if err := l.Append(Entry{Endpoint: "/a", Error: strings.Repeat("x", 10000)}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if err := Open(path, nil).Append(Entry{Endpoint: "/b"}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	if _, _, err := Verify(path); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l := Open(path, nil)
+	for _, endpoint := range []string{"/a", "/b"} {
+		if err := l.Append(Entry{Endpoint: endpoint}); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	edited := strings.Replace(string(data), `"/a"`, `"/x"`, 1)
+	if err := os.WriteFile(path, []byte(edited), 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	_, _, err = Verify(path)
+	var verr *VerifyError
+	if !errors.As(err, &verr) || verr.Seq != 1 {
+		t.Errorf("Verify = %v, want tampering at entry 1", err)
+	}
+}
+
+func TestHashQueryIsKeyed(t *testing.T) {
+	params := map[string]interface{}{"phone": "+14155550100"}
+	a := Open("", []byte("key-a")).HashQuery(params)
+	b := Open("", []byte("key-b")).HashQuery(params)
+
+	if a == b {
+		t.Errorf("HashQuery is the same under different keys")
+	}
+	if again := Open("", []byte("key-a")).HashQuery(params); again != a {
+		t.Errorf("HashQuery = %s, then %s under the same key", a, again)
+	}
+
+	// An unkeyed hash of the parameters must not reveal the query
+	data, _ := json.Marshal(params)
+	sum := sha256.Sum256(data)
+	if a == hex.EncodeToString(sum[:]) {
+		t.Errorf("HashQuery is the plain SHA-256 of the parameters")
+	}
+}
+
+func TestLoadKey(t *testing.T) {
+	dir := t.TempDir()
+	key, err := LoadKey(dir)
+	if err != nil || len(key) != 32 {
+		t.Fatalf("LoadKey = %d bytes, %v; want 32", len(key), err)
+	}
+	again, err := LoadKey(dir)
+	if err != nil || string(again) != string(key) {
+		t.Errorf("LoadKey returned a different key on the second call")
+	}
+}
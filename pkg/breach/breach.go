@@ -0,0 +1,356 @@
+// Package breach parses database lookup results into typed hits, groups
+// them by source and counts the kinds of data each source exposes.
+package breach
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/fields"
+	"github.com/ivan9253/lolarchiver-cli/pkg/records"
+)
+
+// Field categories
+const (
+	Email    = fields.Email
+	Username = fields.Username
+	Password = fields.Password
+	Hash     = fields.Hash
+	IP       = fields.IP
+	Phone    = fields.Phone
+	Name     = fields.Name
+	Other    = "other"
+)
+
+// Categories lists every field category in display order
+var Categories = []string{Email, Username, Password, Hash, IP, Phone, Name, Other}
+
+// SourceKeys are the fields checked, in order, for the breach a hit came from
+var SourceKeys = []string{"source", "database", "db", "breach", "leak", "origin", "site", "dataset"}
+
+// UnknownSource names hits that do not carry a source
+const UnknownSource = "(unknown)"
+
+// Category returns the category of a field name
+func Category(field string) string {
+	if c := fields.KindOf(field); c != "" {
+		return c
+	}
+	return Other
+}
+
+// Hit is one record returned by a database lookup. After Dedupe, Sources
+// lists every source that returned an identical record.
+type Hit struct {
+	Sources []string          `json:"sources"`
+	Fields  map[string]string `json:"fields"`
+}
+
+// Has reports whether the hit holds a non-empty field of category
+func (h Hit) Has(category string) bool {
+	for field := range h.Fields {
+		if Category(field) == category {
+			return true
+		}
+	}
+	return false
+}
+
+// Values returns the values of the hit's fields in category, sorted by
+// field name
+func (h Hit) Values(category string) []string {
+	var fields []string
+	for field := range h.Fields {
+		if Category(field) == category {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	values := make([]string, 0, len(fields))
+	for _, field := range fields {
+		values = append(values, h.Fields[field])
+	}
+	return values
+}
+
+// key identifies the content of a hit independently of its source
+func (h Hit) key() string {
+	fields := make([]string, 0, len(h.Fields))
+	for field, value := range h.Fields {
+		fields = append(fields, strings.ToLower(field)+"="+value)
+	}
+	sort.Strings(fields)
+	return strings.Join(fields, "\x00")
+}
+
+// Parse decodes a database lookup response into hits. Empty fields are
+// dropped; nested values are kept as compact JSON.
+func Parse(body []byte) ([]Hit, error) {
+	recs, err := records.Extract(body)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]Hit, 0, len(recs))
+	for _, rec := range recs {
+		source := rec.String(SourceKeys...)
+		if source == "" {
+			source = UnknownSource
+		}
+		hit := Hit{Sources: []string{source}, Fields: make(map[string]string)}
+		for field := range rec {
+			if isSourceKey(field) {
+				continue
+			}
+			if value := strings.TrimSpace(rec.String(field)); value != "" {
+				hit.Fields[field] = value
+			}
+		}
+		hits = append(hits, hit)
+	}
+	return hits, nil
+}
+
+func isSourceKey(field string) bool {
+	for _, key := range SourceKeys {
+		if field == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Dedupe merges hits with identical fields, keeping the first occurrence
+// and collecting the sources of the others. It returns the merged hits and
+// the number of duplicates removed.
+func Dedupe(hits []Hit) ([]Hit, int) {
+	index := make(map[string]int)
+	var out []Hit
+	removed := 0
+	for _, hit := range hits {
+		k := hit.key()
+		i, ok := index[k]
+		if !ok {
+			index[k] = len(out)
+			out = append(out, Hit{Sources: append([]string{}, hit.Sources...), Fields: hit.Fields})
+			continue
+		}
+		removed++
+		for _, s := range hit.Sources {
+			if !contains(out[i].Sources, s) {
+				out[i].Sources = append(out[i].Sources, s)
+			}
+		}
+	}
+	return out, removed
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// SourceStats counts the hits of one source and how many of them hold each
+// category of field
+type SourceStats struct {
+	Source string         `json:"source"`
+	Hits   int            `json:"hits"`
+	Fields map[string]int `json:"fields"`
+}
+
+// Summary lists per-source statistics, sorted by number of hits, followed by
+// a total row
+type Summary []SourceStats
+
+// TotalSource names the total row of a summary
+const TotalSource = "(total)"
+
+// Summarise computes per-source statistics. A hit shared by several sources
+// counts towards each of them, and once towards the total.
+func Summarise(hits []Hit) Summary {
+	bySource := make(map[string]*SourceStats)
+	total := SourceStats{Source: TotalSource, Fields: make(map[string]int)}
+	for _, hit := range hits {
+		total.Hits++
+		for _, c := range Categories {
+			if hit.Has(c) {
+				total.Fields[c]++
+			}
+		}
+		for _, source := range hit.Sources {
+			s, ok := bySource[source]
+			if !ok {
+				s = &SourceStats{Source: source, Fields: make(map[string]int)}
+				bySource[source] = s
+			}
+			s.Hits++
+			for _, c := range Categories {
+				if hit.Has(c) {
+					s.Fields[c]++
+				}
+			}
+		}
+	}
+
+	summary := make(Summary, 0, len(bySource)+1)
+	for _, s := range bySource {
+		summary = append(summary, *s)
+	}
+	sort.Slice(summary, func(i, j int) bool {
+		if summary[i].Hits != summary[j].Hits {
+			return summary[i].Hits > summary[j].Hits
+		}
+		return summary[i].Source < summary[j].Source
+	})
+	return append(summary, total)
+}
+
+// Columns implements output.Tabular
+func (s Summary) Columns() []string {
+	return append([]string{"source", "hits"}, Categories...)
+}
+
+// Rows implements output.Tabular
+func (s Summary) Rows() [][]string {
+	rows := make([][]string, 0, len(s))
+	for _, st := range s {
+		row := []string{st.Source, strconv.Itoa(st.Hits)}
+		for _, c := range Categories {
+			row = append(row, strconv.Itoa(st.Fields[c]))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// SourceRow is one hit listed under one of its sources
+type SourceRow struct {
+	Source string `json:"source"`
+	Hit    Hit    `json:"hit"`
+}
+
+// BySource lists every hit under each of its sources, sorted by source
+type BySource []SourceRow
+
+// GroupBySource lists hits by source
+func GroupBySource(hits []Hit) BySource {
+	var rows BySource
+	for _, hit := range hits {
+		for _, source := range hit.Sources {
+			rows = append(rows, SourceRow{Source: source, Hit: hit})
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].Source < rows[j].Source
+	})
+	return rows
+}
+
+// Columns implements output.Tabular
+func (b BySource) Columns() []string {
+	return append([]string{"source"}, Categories...)
+}
+
+// Rows implements output.Tabular. Fields in the other category are shown
+// as name=value pairs.
+func (b BySource) Rows() [][]string {
+	rows := make([][]string, 0, len(b))
+	for _, r := range b {
+		row := []string{r.Source}
+		for _, c := range Categories {
+			if c != Other {
+				row = append(row, strings.Join(r.Hit.Values(c), " "))
+				continue
+			}
+			var pairs []string
+			for field, value := range r.Hit.Fields {
+				if Category(field) == Other {
+					pairs = append(pairs, field+"="+value)
+				}
+			}
+			sort.Strings(pairs)
+			row = append(row, strings.Join(pairs, " "))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// FieldValue is a distinct value of one category with the hits and sources
+// it appears in
+type FieldValue struct {
+	Category string   `json:"category"`
+	Value    string   `json:"value"`
+	Hits     int      `json:"hits"`
+	Sources  []string `json:"sources"`
+}
+
+// ByField lists distinct values per category
+type ByField []FieldValue
+
+// GroupByField collects the distinct values of every category except
+// other. Values compare case insensitively for emails and usernames.
+func GroupByField(hits []Hit) ByField {
+	index := make(map[string]int)
+	var out ByField
+	for _, hit := range hits {
+		for _, c := range Categories {
+			if c == Other {
+				continue
+			}
+			for _, value := range hit.Values(c) {
+				k := c + "\x00" + value
+				if c == Email || c == Username {
+					k = c + "\x00" + strings.ToLower(value)
+				}
+				i, ok := index[k]
+				if !ok {
+					i = len(out)
+					index[k] = i
+					out = append(out, FieldValue{Category: c, Value: value})
+				}
+				out[i].Hits++
+				for _, s := range hit.Sources {
+					if !contains(out[i].Sources, s) {
+						out[i].Sources = append(out[i].Sources, s)
+					}
+				}
+			}
+		}
+	}
+
+	order := make(map[string]int, len(Categories))
+	for i, c := range Categories {
+		order[c] = i
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Category != b.Category {
+			return order[a.Category] < order[b.Category]
+		}
+		if a.Hits != b.Hits {
+			return a.Hits > b.Hits
+		}
+		return a.Value < b.Value
+	})
+	return out
+}
+
+// Columns implements output.Tabular
+func (f ByField) Columns() []string {
+	return []string{"category", "value", "hits", "sources"}
+}
+
+// Rows implements output.Tabular
+func (f ByField) Rows() [][]string {
+	rows := make([][]string, 0, len(f))
+	for _, v := range f {
+		rows = append(rows, []string{v.Category, v.Value, strconv.Itoa(v.Hits), strings.Join(v.Sources, " ")})
+	}
+	return rows
+}
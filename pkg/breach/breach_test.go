@@ -0,0 +1,131 @@
+package breach
+
+import (
+	"reflect"
+	"testing"
+)
+
+const body = `{"results": [
+	{"source": "siteA", "email": "Bob@Example.com", "password": "hunter2", "ip": "1.2.3.4"},
+	{"database": "siteB", "email": "Bob@Example.com", "password": "hunter2", "ip": "1.2.3.4"},
+	{"source": "siteB", "Email_Address": "bob@example.com", "user": "bob", "signup": "2019"},
+	{"email": "alice@example.com", "phone": "", "name": "Alice"}
+]}`
+
+func parse(t *testing.T) []Hit {
+	t.Helper()
+	hits, err := Parse([]byte(body))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return hits
+}
+
+func TestParse(t *testing.T) {
+	hits := parse(t)
+	if len(hits) != 4 {
+		t.Fatalf("Parse returned %d hits, want 4", len(hits))
+	}
+	if !reflect.DeepEqual(hits[1].Sources, []string{"siteB"}) {
+		t.Errorf("hit 1 sources = %v, want siteB from the database field", hits[1].Sources)
+	}
+	if _, ok := hits[1].Fields["database"]; ok {
+		t.Errorf("source field kept among the fields: %v", hits[1].Fields)
+	}
+	if !reflect.DeepEqual(hits[3].Sources, []string{UnknownSource}) {
+		t.Errorf("hit 3 sources = %v, want %s", hits[3].Sources, UnknownSource)
+	}
+	if _, ok := hits[3].Fields["phone"]; ok {
+		t.Errorf("empty field kept: %v", hits[3].Fields)
+	}
+}
+
+func TestCategory(t *testing.T) {
+	tests := map[string]string{
+		"Email_Address": Email,
+		"user":          Username,
+		"pwd":           Password,
+		"salt":          Hash,
+		"last_ip":       IP,
+		"mobile":        Phone,
+		"first_name":    Name,
+		"signup":        Other,
+	}
+	for field, want := range tests {
+		if got := Category(field); got != want {
+			t.Errorf("Category(%q) = %q, want %q", field, got, want)
+		}
+	}
+}
+
+func TestDedupe(t *testing.T) {
+	hits, removed := Dedupe(parse(t))
+	if removed != 1 || len(hits) != 3 {
+		t.Fatalf("Dedupe = %d hits, %d removed; want 3 and 1", len(hits), removed)
+	}
+	if !reflect.DeepEqual(hits[0].Sources, []string{"siteA", "siteB"}) {
+		t.Errorf("merged sources = %v, want siteA and siteB", hits[0].Sources)
+	}
+
+	// A duplicate from a source already listed is not added twice
+	again, removed := Dedupe(append(hits, Hit{Sources: []string{"siteA"}, Fields: hits[0].Fields}))
+	if removed != 1 || !reflect.DeepEqual(again[0].Sources, []string{"siteA", "siteB"}) {
+		t.Errorf("Dedupe = %v, %d removed; want sources unchanged", again[0].Sources, removed)
+	}
+}
+
+func TestSummarise(t *testing.T) {
+	hits, _ := Dedupe(parse(t))
+	summary := Summarise(hits)
+
+	want := Summary{
+		{Source: "siteB", Hits: 2, Fields: map[string]int{Email: 2, Password: 1, IP: 1, Username: 1, Other: 1}},
+		{Source: "(unknown)", Hits: 1, Fields: map[string]int{Email: 1, Name: 1}},
+		{Source: "siteA", Hits: 1, Fields: map[string]int{Email: 1, Password: 1, IP: 1}},
+		{Source: TotalSource, Hits: 3, Fields: map[string]int{Email: 3, Password: 1, IP: 1, Username: 1, Other: 1, Name: 1}},
+	}
+	if !reflect.DeepEqual(summary, want) {
+		t.Errorf("Summarise =\n%+v\nwant\n%+v", summary, want)
+	}
+
+	row := summary.Rows()[3]
+	if want := []string{TotalSource, "3", "3", "1", "1", "0", "1", "0", "1", "1"}; !reflect.DeepEqual(row, want) {
+		t.Errorf("total row = %v, want %v", row, want)
+	}
+}
+
+func TestGroupBySource(t *testing.T) {
+	hits, _ := Dedupe(parse(t))
+	rows := GroupBySource(hits)
+
+	var sources []string
+	for _, r := range rows {
+		sources = append(sources, r.Source)
+	}
+	if want := []string{"(unknown)", "siteA", "siteB", "siteB"}; !reflect.DeepEqual(sources, want) {
+		t.Errorf("GroupBySource sources = %v, want %v", sources, want)
+	}
+
+	// Unknown fields are listed as pairs in the last column
+	last := rows.Rows()[3]
+	if got := last[len(last)-1]; got != "signup=2019" {
+		t.Errorf("other column = %q, want signup=2019", got)
+	}
+}
+
+func TestGroupByField(t *testing.T) {
+	hits, _ := Dedupe(parse(t))
+	got := GroupByField(hits)
+
+	want := ByField{
+		{Category: Email, Value: "Bob@Example.com", Hits: 2, Sources: []string{"siteA", "siteB"}},
+		{Category: Email, Value: "alice@example.com", Hits: 1, Sources: []string{UnknownSource}},
+		{Category: Username, Value: "bob", Hits: 1, Sources: []string{"siteB"}},
+		{Category: Password, Value: "hunter2", Hits: 1, Sources: []string{"siteA", "siteB"}},
+		{Category: IP, Value: "1.2.3.4", Hits: 1, Sources: []string{"siteA", "siteB"}},
+		{Category: Name, Value: "Alice", Hits: 1, Sources: []string{UnknownSource}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GroupByField =\n%+v\nwant\n%+v", got, want)
+	}
+}
@@ -0,0 +1,65 @@
+// Package cache stores API responses on disk for reuse across runs within a
+// configurable maximum age.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/config"
+)
+
+const cacheDir = "cache"
+
+// Cache represents a namespace of cached entries
+type Cache struct {
+	dir    string
+	maxAge time.Duration
+}
+
+// Open returns the cache for namespace. Entries older than maxAge are
+// treated as missing; a zero maxAge disables lookups entirely.
+func Open(namespace string, maxAge time.Duration) (*Cache, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return nil, err
+	}
+	return &Cache{dir: filepath.Join(dir, cacheDir, namespace), maxAge: maxAge}, nil
+}
+
+func (c *Cache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Get returns the cached data for key if it exists and is fresh enough
+func (c *Cache) Get(key string) ([]byte, bool) {
+	if c.maxAge <= 0 {
+		return nil, false
+	}
+	path := c.path(key)
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) > c.maxAge {
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// Put stores data for key
+func (c *Cache) Put(key string, data []byte) error {
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	if err := os.WriteFile(c.path(key), data, 0600); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return nil
+}
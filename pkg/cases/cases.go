@@ -0,0 +1,331 @@
+// Package cases manages investigation workspaces. A case records every API
+// request made while it is active, together with the raw response, so that
+// it can later be shown exactly what was queried and when.
+package cases
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ivan9253/lolarchiver-cli/internal/jsonlog"
+	"github.com/ivan9253/lolarchiver-cli/pkg/config"
+)
+
+const (
+	casesDir    = "cases"
+	currentFile = "current_case"
+	metaFile    = "case.json"
+	logFile     = "requests.jsonl"
+	resultsDir  = "results"
+
+	StatusOpen   = "open"
+	StatusClosed = "closed"
+)
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// Case represents an investigation workspace
+type Case struct {
+	Name    string     `json:"name"`
+	Status  string     `json:"status"`
+	Created time.Time  `json:"created"`
+	Closed  *time.Time `json:"closed,omitempty"`
+
+	dir string
+}
+
+// Entry represents a single request recorded in a case
+type Entry struct {
+	Seq          int                    `json:"seq"`
+	Time         time.Time              `json:"time"`
+	Profile      string                 `json:"profile"`
+	Method       string                 `json:"method"`
+	Endpoint     string                 `json:"endpoint"`
+	Params       map[string]interface{} `json:"params,omitempty"`
+	StatusCode   int                    `json:"status_code,omitempty"`
+	DurationMS   int64                  `json:"duration_ms"`
+	Credits      int                    `json:"credits"`
+	ResponseHash string                 `json:"response_hash,omitempty"`
+	ResultFile   string                 `json:"result_file,omitempty"`
+	Error        string                 `json:"error,omitempty"`
+}
+
+func rootDir() (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, casesDir), nil
+}
+
+func caseDir(name string) (string, error) {
+	if !validName.MatchString(name) {
+		return "", fmt.Errorf("invalid case name %q: use letters, digits, '.', '_' or '-'", name)
+	}
+	root, err := rootDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, name), nil
+}
+
+// Create creates a new open case
+func Create(name string) (*Case, error) {
+	dir, err := caseDir(name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(dir); err == nil {
+		return nil, fmt.Errorf("case %q already exists", name)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, resultsDir), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create case directory: %w", err)
+	}
+
+	c := &Case{Name: name, Status: StatusOpen, Created: time.Now().UTC(), dir: dir}
+	return c, c.save()
+}
+
+// Load loads an existing case
+func Load(name string) (*Case, error) {
+	dir, err := caseDir(name)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, metaFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("case %q does not exist", name)
+		}
+		return nil, fmt.Errorf("failed to read case: %w", err)
+	}
+
+	var c Case
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse case: %w", err)
+	}
+	c.dir = dir
+	return &c, nil
+}
+
+// List returns all cases sorted by name
+func List() ([]*Case, error) {
+	root, err := rootDir()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read cases directory: %w", err)
+	}
+
+	var list []*Case
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		c, err := Load(entry.Name())
+		if err != nil {
+			continue
+		}
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, nil
+}
+
+// Dir returns the directory holding the case
+func (c *Case) Dir() string {
+	return c.dir
+}
+
+// Reopen marks a closed case as open again
+func (c *Case) Reopen() error {
+	c.Status = StatusOpen
+	c.Closed = nil
+	return c.save()
+}
+
+// Close marks the case as closed. Closed cases reject new entries.
+func (c *Case) Close() error {
+	now := time.Now().UTC()
+	c.Status = StatusClosed
+	c.Closed = &now
+	return c.save()
+}
+
+func (c *Case) save() error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal case: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(c.dir, metaFile), data, 0600); err != nil {
+		return fmt.Errorf("failed to write case: %w", err)
+	}
+	return nil
+}
+
+// Record appends an entry to the case log and stores body as its result.
+// The sequence number, response hash and result file are filled in. The log
+// is locked while the sequence number is taken and the entry written, so
+// processes recording into the same case never share a number.
+func (c *Case) Record(entry Entry, body []byte) error {
+	if c.Status != StatusOpen {
+		return fmt.Errorf("case %q is closed", c.Name)
+	}
+
+	f, err := os.OpenFile(filepath.Join(c.dir, logFile), os.O_APPEND|os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open case log: %w", err)
+	}
+	defer f.Close()
+
+	if err := jsonlog.Lock(f); err != nil {
+		return fmt.Errorf("failed to lock case log: %w", err)
+	}
+	defer jsonlog.Unlock(f)
+
+	var last Entry
+	found, err := jsonlog.Last(f, &last)
+	if err != nil {
+		return fmt.Errorf("failed to read last case entry: %w", err)
+	}
+	entry.Seq = 1
+	if found {
+		entry.Seq = last.Seq + 1
+	}
+
+	if body != nil {
+		sum := sha256.Sum256(body)
+		entry.ResponseHash = hex.EncodeToString(sum[:])
+		entry.ResultFile = filepath.Join(resultsDir, fmt.Sprintf("%06d.json", entry.Seq))
+		if err := writeResult(filepath.Join(c.dir, entry.ResultFile), body); err != nil {
+			return err
+		}
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal entry: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write case log: %w", err)
+	}
+	return nil
+}
+
+// writeResult stores a response body, refusing to replace an existing
+// result so that a stored body always matches the hash logged with it
+func writeResult(path string, body []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create results directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create result: %w", err)
+	}
+	if _, err := f.Write(body); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write result: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write result: %w", err)
+	}
+	return nil
+}
+
+// Entries returns all recorded entries in order
+func (c *Case) Entries() ([]Entry, error) {
+	f, err := os.Open(filepath.Join(c.dir, logFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open case log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse case log: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read case log: %w", err)
+	}
+	return entries, nil
+}
+
+// Result returns the stored response body of an entry
+func (c *Case) Result(entry Entry) ([]byte, error) {
+	if entry.ResultFile == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(filepath.Join(c.dir, entry.ResultFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read result: %w", err)
+	}
+	return data, nil
+}
+
+// Current returns the name of the case selected with SetCurrent, or "" if
+// none is selected
+func Current() (string, error) {
+	root, err := rootDir()
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(filepath.Join(root, currentFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read current case: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// SetCurrent selects the case that commands record into by default. An
+// empty name clears the selection.
+func SetCurrent(name string) error {
+	root, err := rootDir()
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(root, currentFile)
+	if name == "" {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to clear current case: %w", err)
+		}
+		return nil
+	}
+	if err := os.MkdirAll(root, 0700); err != nil {
+		return fmt.Errorf("failed to create cases directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(name+"\n"), 0600); err != nil {
+		return fmt.Errorf("failed to write current case: %w", err)
+	}
+	return nil
+}
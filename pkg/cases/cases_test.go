@@ -0,0 +1,146 @@
+package cases
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestCreateAndLoad(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if _, err := Create("../escape"); err == nil {
+		t.Errorf("Create accepted an invalid name")
+	}
+	if _, err := Create("alpha"); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := Create("alpha"); err == nil {
+		t.Errorf("Create accepted an existing case")
+	}
+
+	c, err := Load("alpha")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if c.Name != "alpha" || c.Status != StatusOpen {
+		t.Errorf("Load = %+v, want open case alpha", c)
+	}
+	if _, err := Load("missing"); err == nil {
+		t.Errorf("Load of a missing case succeeded")
+	}
+}
+
+func TestCurrent(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if name, err := Current(); err != nil || name != "" {
+		t.Errorf("Current = %q, %v; want none", name, err)
+	}
+	if err := SetCurrent("alpha"); err != nil {
+		t.Fatalf("SetCurrent: %v", err)
+	}
+	if name, _ := Current(); name != "alpha" {
+		t.Errorf("Current = %q, want alpha", name)
+	}
+	if err := SetCurrent(""); err != nil {
+		t.Fatalf("SetCurrent(\"\"): %v", err)
+	}
+	if name, _ := Current(); name != "" {
+		t.Errorf("Current = %q after clearing, want none", name)
+	}
+}
+
+func TestRecord(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	c, err := Create("alpha")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	body := []byte(`{"ok":true}`)
+	if err := c.Record(Entry{Endpoint: "/a", StatusCode: 200}, body); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if err := c.Record(Entry{Endpoint: "/b", Error: "timeout"}, nil); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+
+	entries, err := c.Entries()
+	if err != nil {
+		t.Fatalf("Entries: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Seq != 1 || entries[1].Seq != 2 {
+		t.Fatalf("Entries = %+v, want sequences 1 and 2", entries)
+	}
+
+	sum := sha256.Sum256(body)
+	if entries[0].ResponseHash != hex.EncodeToString(sum[:]) {
+		t.Errorf("response hash %s does not match the body", entries[0].ResponseHash)
+	}
+	stored, err := c.Result(entries[0])
+	if err != nil || string(stored) != string(body) {
+		t.Errorf("Result = %q, %v; want %q", stored, err, body)
+	}
+	if entries[1].ResultFile != "" {
+		t.Errorf("failed request has result file %q", entries[1].ResultFile)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := c.Record(Entry{Endpoint: "/c"}, nil); err == nil {
+		t.Errorf("Record into a closed case succeeded")
+	}
+}
+
+func TestRecordConcurrent(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if _, err := Create("alpha"); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// Separate handles stand in for separate processes
+	const n = 20
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c, err := Load("alpha")
+			if err != nil {
+				t.Errorf("Load: %v", err)
+				return
+			}
+			if err := c.Record(Entry{Endpoint: "/a"}, []byte(fmt.Sprintf(`{"n":%d}`, i))); err != nil {
+				t.Errorf("Record: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	c, _ := Load("alpha")
+	entries, err := c.Entries()
+	if err != nil {
+		t.Fatalf("Entries: %v", err)
+	}
+	if len(entries) != n {
+		t.Fatalf("recorded %d entries, want %d", len(entries), n)
+	}
+	for i, e := range entries {
+		if e.Seq != i+1 {
+			t.Errorf("entry %d has sequence %d", i, e.Seq)
+		}
+		body, err := c.Result(e)
+		if err != nil {
+			t.Fatalf("Result: %v", err)
+		}
+		sum := sha256.Sum256(body)
+		if hex.EncodeToString(sum[:]) != e.ResponseHash {
+			t.Errorf("entry %d result does not match its hash", e.Seq)
+		}
+	}
+}
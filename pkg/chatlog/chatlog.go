@@ -0,0 +1,188 @@
+// Package chatlog writes chat messages as plain-text IRC-style logs, CSV or
+// HTML transcripts grouped by channel and day.
+package chatlog
+
+import (
+	"encoding/csv"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/timeline"
+)
+
+// Supported formats
+const (
+	IRC  = "irc"
+	CSV  = "csv"
+	HTML = "html"
+)
+
+// Formats lists every supported format
+var Formats = []string{IRC, CSV, HTML}
+
+// Day holds the messages of one channel on one calendar day
+type Day struct {
+	Date     string
+	Messages timeline.Timeline
+}
+
+// Channel holds the messages of one channel, split by day
+type Channel struct {
+	Name string
+	Days []Day
+}
+
+// Group sorts messages by channel and time and splits them by UTC day.
+// Messages without a timestamp are grouped under an empty date at the end
+// of their channel.
+func Group(events timeline.Timeline) []Channel {
+	sorted := append(timeline.Timeline{}, events...)
+	sorted.Sort()
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return channelName(sorted[i]) < channelName(sorted[j])
+	})
+
+	var channels []Channel
+	for _, ev := range sorted {
+		name := channelName(ev)
+		if len(channels) == 0 || channels[len(channels)-1].Name != name {
+			channels = append(channels, Channel{Name: name})
+		}
+		ch := &channels[len(channels)-1]
+		date := ""
+		if !ev.Time.IsZero() {
+			date = ev.Time.UTC().Format(time.DateOnly)
+		}
+		if len(ch.Days) == 0 || ch.Days[len(ch.Days)-1].Date != date {
+			ch.Days = append(ch.Days, Day{Date: date})
+		}
+		day := &ch.Days[len(ch.Days)-1]
+		day.Messages = append(day.Messages, ev)
+	}
+	return channels
+}
+
+func channelName(ev timeline.Event) string {
+	name := strings.TrimPrefix(ev.Channel, "#")
+	if name == "" {
+		return "unknown"
+	}
+	return name
+}
+
+// Write renders messages in format
+func Write(w io.Writer, format string, events timeline.Timeline) error {
+	switch format {
+	case IRC:
+		return WriteIRC(w, events)
+	case CSV:
+		return WriteCSV(w, events)
+	case HTML:
+		return WriteHTML(w, events)
+	default:
+		return fmt.Errorf("unknown log format %q (expected %s)", format, strings.Join(Formats, ", "))
+	}
+}
+
+// WriteIRC writes one "[time] #channel <user> message" line per message,
+// channel by channel, with a marker whenever the day changes
+func WriteIRC(w io.Writer, events timeline.Timeline) error {
+	for _, ch := range Group(events) {
+		for _, day := range ch.Days {
+			date := day.Date
+			if date == "" {
+				date = "unknown date"
+			}
+			if _, err := fmt.Fprintf(w, "--- #%s %s\n", oneLine(ch.Name), date); err != nil {
+				return err
+			}
+			for _, ev := range day.Messages {
+				if _, err := fmt.Fprintf(w, "[%s] #%s <%s> %s\n", clock(ev.Time), oneLine(ch.Name), oneLine(ev.Actor), oneLine(ev.Text)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// WriteCSV writes one row per message, sorted by channel and time
+func WriteCSV(w io.Writer, events timeline.Timeline) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"time", "platform", "channel", "user", "message"}); err != nil {
+		return err
+	}
+	for _, ch := range Group(events) {
+		for _, day := range ch.Days {
+			for _, ev := range day.Messages {
+				t := ""
+				if !ev.Time.IsZero() {
+					t = ev.Time.UTC().Format(time.RFC3339)
+				}
+				if err := cw.Write([]string{t, ev.Platform, ch.Name, ev.Actor, ev.Text}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+var transcript = htmltemplate.Must(htmltemplate.New("transcript").Funcs(map[string]interface{}{
+	"clock": clock,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Chat transcript</title>
+<style>
+body { font-family: monospace; margin: 2em; color: #222; }
+h2 { font-family: sans-serif; border-bottom: 1px solid #ccc; }
+h3 { font-family: sans-serif; color: #666; font-size: 1em; }
+.line { white-space: pre-wrap; }
+.time { color: #888; }
+.nick { color: #05a; font-weight: bold; }
+nav a { margin-right: 1em; }
+</style>
+</head>
+<body>
+<h1>Chat transcript</h1>
+<nav>{{range .}}<a href="#channel-{{.Name}}">#{{.Name}}</a>{{end}}</nav>
+{{range .}}
+<h2 id="channel-{{.Name}}">#{{.Name}}</h2>
+{{range .Days}}
+<h3>{{if .Date}}{{.Date}}{{else}}Unknown date{{end}}</h3>
+{{range .Messages}}<div class="line"><span class="time">[{{clock .Time}}]</span> <span class="nick">&lt;{{.Actor}}&gt;</span> {{.Text}}</div>
+{{end}}{{end}}{{end}}
+</body>
+</html>
+`))
+
+// WriteHTML writes a transcript with a heading per channel and per day
+func WriteHTML(w io.Writer, events timeline.Timeline) error {
+	return transcript.Execute(w, Group(events))
+}
+
+func clock(t time.Time) string {
+	if t.IsZero() {
+		return "--:--:--"
+	}
+	return t.UTC().Format(time.TimeOnly)
+}
+
+// oneLine joins s onto a single line and drops control characters, so that
+// a log written to a terminal cannot carry escape sequences
+func oneLine(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, strings.Join(strings.Fields(s), " "))
+}
@@ -0,0 +1,129 @@
+package chatlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/timeline"
+)
+
+func at(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+var events = timeline.Timeline{
+	{Time: at("2024-03-02T00:30:00+02:00"), Platform: "twitch", Actor: "alice", Channel: "#foo", Text: "late"},
+	{Time: at("2024-03-01T10:00:00Z"), Platform: "twitch", Actor: "alice", Channel: "foo", Text: "first"},
+	{Time: at("2024-03-01T09:00:00Z"), Platform: "twitch", Actor: "bob", Channel: "bar", Text: "hello\nthere"},
+	{Platform: "twitch", Actor: "bob", Channel: "foo", Text: "undated"},
+	{Time: at("2024-03-03T00:00:00Z"), Platform: "twitch", Actor: "carol", Text: "nowhere"},
+}
+
+func TestGroup(t *testing.T) {
+	channels := Group(events)
+
+	type day struct {
+		channel, date string
+		texts         []string
+	}
+	var got []day
+	for _, ch := range channels {
+		for _, d := range ch.Days {
+			var texts []string
+			for _, ev := range d.Messages {
+				texts = append(texts, ev.Text)
+			}
+			got = append(got, day{ch.Name, d.Date, texts})
+		}
+	}
+
+	// "#foo" and "foo" are one channel, and the message sent after
+	// midnight local time still falls on the first UTC day
+	want := []day{
+		{"bar", "2024-03-01", []string{"hello\nthere"}},
+		{"foo", "2024-03-01", []string{"first", "late"}},
+		{"foo", "", []string{"undated"}},
+		{"unknown", "2024-03-03", []string{"nowhere"}},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Group = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i].channel != want[i].channel || got[i].date != want[i].date || strings.Join(got[i].texts, "|") != strings.Join(want[i].texts, "|") {
+			t.Errorf("day %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestWriteIRC(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteIRC(&buf, events); err != nil {
+		t.Fatalf("WriteIRC: %v", err)
+	}
+	want := `--- #bar 2024-03-01
+[09:00:00] #bar <bob> hello there
+--- #foo 2024-03-01
+[10:00:00] #foo <alice> first
+[22:30:00] #foo <alice> late
+--- #foo unknown date
+[--:--:--] #foo <bob> undated
+--- #unknown 2024-03-03
+[00:00:00] #unknown <carol> nowhere
+`
+	if got := buf.String(); got != want {
+		t.Errorf("WriteIRC =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteIRCStripsControls(t *testing.T) {
+	var buf bytes.Buffer
+	evil := timeline.Timeline{{Actor: "eve\x1b[2J", Channel: "c\x07", Text: "hi\x1b]52;c;ZXZpbA==\x07\u009b31m"}}
+	if err := WriteIRC(&buf, evil); err != nil {
+		t.Fatalf("WriteIRC: %v", err)
+	}
+	if strings.ContainsAny(buf.String(), "\x1b\x07\u009b") {
+		t.Errorf("WriteIRC kept control characters: %q", buf.String())
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, events[1:3]); err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+	want := "time,platform,channel,user,message\n" +
+		"2024-03-01T09:00:00Z,twitch,bar,bob,\"hello\nthere\"\n" +
+		"2024-03-01T10:00:00Z,twitch,foo,alice,first\n"
+	if got := buf.String(); got != want {
+		t.Errorf("WriteCSV =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteHTMLEscapes(t *testing.T) {
+	var buf bytes.Buffer
+	evil := timeline.Timeline{{Time: at("2024-03-01T10:00:00Z"), Actor: "<b>eve</b>", Channel: "foo", Text: "<script>alert(1)</script>"}}
+	if err := WriteHTML(&buf, evil); err != nil {
+		t.Fatalf("WriteHTML: %v", err)
+	}
+	out := buf.String()
+	if strings.Contains(out, "<script>") || strings.Contains(out, "<b>eve") {
+		t.Errorf("WriteHTML did not escape message text:\n%s", out)
+	}
+	for _, want := range []string{`id="channel-foo"`, "<h3>2024-03-01</h3>", "[10:00:00]", "&lt;script&gt;"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("WriteHTML output lacks %q", want)
+		}
+	}
+}
+
+func TestWriteUnknownFormat(t *testing.T) {
+	if err := Write(&bytes.Buffer{}, "xml", events); err == nil {
+		t.Errorf("Write accepted an unknown format")
+	}
+}
@@ -4,26 +4,137 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"os/user"
 	"path/filepath"
 )
 
 const (
 	configDir  = ".lolarchiver"
 	configFile = "config.json"
+
+	// ProfileEnv names the environment variable selecting the active profile
+	ProfileEnv = "LOLARCHIVER_PROFILE"
+	// DefaultProfile is the profile used when none is selected
+	DefaultProfile = "default"
 )
 
 // Config represents the application configuration
 type Config struct {
-	APIKey string `json:"api_key"`
+	APIKey      string             `json:"api_key"`
+	Operator    string             `json:"operator,omitempty"`
+	Region      string             `json:"default_region,omitempty"`
+	CreditCosts map[string]int     `json:"credit_costs,omitempty"`
+	Profiles    map[string]Profile `json:"profiles,omitempty"`
+	Policy      *Policy            `json:"policy,omitempty"`
 }
 
-func Load() (*Config, error) {
+// Policy controls the safeguards applied to lookups of personal data
+type Policy struct {
+	// Endpoints lists the API paths the policy applies to; empty selects
+	// the reverse phone, reverse email and database lookups
+	Endpoints       []string `json:"endpoints,omitempty"`
+	RequireReason   bool     `json:"require_reason"`
+	MinReasonLength int      `json:"min_reason_length,omitempty"`
+	RequireCase     bool     `json:"require_case"`
+	AllowInsecure   bool     `json:"allow_insecure"`
+}
+
+// DefaultPolicy is applied when the config file defines no policy. It
+// refuses --insecure but does not require a justification, so that
+// existing scripts keep working until a policy is configured.
+var DefaultPolicy = Policy{}
+
+// LookupPolicy returns the configured policy or DefaultPolicy
+func (c *Config) LookupPolicy() Policy {
+	if c.Policy == nil {
+		return DefaultPolicy
+	}
+	return *c.Policy
+}
+
+// Covers reports whether the policy applies to path, using defaults when
+// no endpoints are configured
+func (p Policy) Covers(path string, defaults []string) bool {
+	endpoints := p.Endpoints
+	if len(endpoints) == 0 {
+		endpoints = defaults
+	}
+	for _, e := range endpoints {
+		if e == path {
+			return true
+		}
+	}
+	return false
+}
+
+// Profile represents per-profile settings layered over the global config
+type Profile struct {
+	APIKey    string           `json:"api_key,omitempty"`
+	Notifiers []NotifierConfig `json:"notifiers,omitempty"`
+}
+
+// NotifierConfig describes a single notification sink
+type NotifierConfig struct {
+	// Type is one of "webhook", "command" or "file"
+	Type string `json:"type"`
+
+	// Webhook settings
+	URL     string            `json:"url,omitempty"`
+	Secret  string            `json:"secret,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// Retries defaults to 3 when unset; 0 disables retrying
+	Retries *int `json:"retries,omitempty"`
+
+	// Command settings
+	Command []string `json:"command,omitempty"`
+
+	// File drop settings
+	Dir string `json:"dir,omitempty"`
+}
+
+// ProfileName returns the name of the active profile
+func ProfileName() string {
+	if name := os.Getenv(ProfileEnv); name != "" {
+		return name
+	}
+	return DefaultProfile
+}
+
+// OperatorName returns the operator recorded in the audit log: the
+// configured operator, or the current system user
+func (c *Config) OperatorName() string {
+	if c.Operator != "" {
+		return c.Operator
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// Profile returns the active profile, or an empty profile if it is not
+// defined
+func (c *Config) Profile() Profile {
+	return c.Profiles[ProfileName()]
+}
+
+// Dir returns the directory holding the configuration and local state
+func Dir() (string, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user home directory: %w", err)
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, configDir), nil
+}
+
+// Load loads the configuration from the config file
+func Load() (*Config, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
 	}
 
-	configPath := filepath.Join(homeDir, configDir, configFile)
+	configPath := filepath.Join(dir, configFile)
 	data, err := os.ReadFile(configPath)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -42,12 +153,10 @@ func Load() (*Config, error) {
 
 // Save saves the configuration to the config file
 func Save(config *Config) error {
-	homeDir, err := os.UserHomeDir()
+	configDirPath, err := Dir()
 	if err != nil {
-		return fmt.Errorf("failed to get user home directory: %w", err)
+		return err
 	}
-
-	configDirPath := filepath.Join(homeDir, configDir)
 	if err := os.MkdirAll(configDirPath, 0700); err != nil {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
@@ -83,9 +192,13 @@ func GetAPIKey() (string, error) {
 		return "", err
 	}
 
+	if key := config.Profile().APIKey; key != "" {
+		return key, nil
+	}
+
 	if config.APIKey == "" {
 		return "", fmt.Errorf("API key not set. Use 'lolarchiver-cli config set-api-key' to set it")
 	}
 
 	return config.APIKey, nil
-} 
\ No newline at end of file
+}
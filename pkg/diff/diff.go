@@ -0,0 +1,204 @@
+// Package diff compares two decoded JSON documents and reports the entries
+// that were added, removed or changed between them.
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/records"
+)
+
+// Operations reported in a Change
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// Change represents a single difference between two documents
+type Change struct {
+	Op   string      `json:"op"`
+	Path string      `json:"path"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// KeyFields are the candidate fields used to match up array elements between
+// the two documents, in order of preference
+var KeyFields = []string{"id", "username", "screen_name", "handle", "name", "utype", "btype", "type"}
+
+// Bodies decodes two JSON bodies and compares them
+func Bodies(old, new []byte) ([]Change, error) {
+	var o, n interface{}
+	if len(old) > 0 {
+		if err := records.Decode(old, &o); err != nil {
+			return nil, fmt.Errorf("failed to decode previous document: %w", err)
+		}
+	}
+	if len(new) > 0 {
+		if err := records.Decode(new, &n); err != nil {
+			return nil, fmt.Errorf("failed to decode current document: %w", err)
+		}
+	}
+	return Values(o, n), nil
+}
+
+// Values compares two decoded JSON values
+func Values(old, new interface{}) []Change {
+	var changes []Change
+	compare("", old, new, &changes)
+	return changes
+}
+
+func compare(path string, old, new interface{}, changes *[]Change) {
+	switch o := old.(type) {
+	case map[string]interface{}:
+		if n, ok := new.(map[string]interface{}); ok {
+			compareObjects(path, o, n, changes)
+			return
+		}
+	case []interface{}:
+		if n, ok := new.([]interface{}); ok {
+			compareArrays(path, o, n, changes)
+			return
+		}
+	}
+
+	if !equal(old, new) {
+		*changes = append(*changes, Change{Op: Changed, Path: displayPath(path), Old: old, New: new})
+	}
+}
+
+func compareObjects(path string, old, new map[string]interface{}, changes *[]Change) {
+	keys := make([]string, 0, len(old)+len(new))
+	for key := range old {
+		keys = append(keys, key)
+	}
+	for key := range new {
+		if _, ok := old[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		child := key
+		if path != "" {
+			child = path + "." + key
+		}
+		o, inOld := old[key]
+		n, inNew := new[key]
+		switch {
+		case !inNew:
+			*changes = append(*changes, Change{Op: Removed, Path: child, Old: o})
+		case !inOld:
+			*changes = append(*changes, Change{Op: Added, Path: child, New: n})
+		default:
+			compare(child, o, n, changes)
+		}
+	}
+}
+
+func compareArrays(path string, old, new []interface{}, changes *[]Change) {
+	field := keyField(old, new)
+	oldKeys := elementKeys(old, field)
+	newKeys := elementKeys(new, field)
+
+	newIndex := make(map[string]int, len(new))
+	for i, key := range newKeys {
+		newIndex[key] = i
+	}
+	oldIndex := make(map[string]int, len(old))
+	for i, key := range oldKeys {
+		oldIndex[key] = i
+	}
+
+	for i, key := range oldKeys {
+		child := path + "[" + key + "]"
+		if j, ok := newIndex[key]; ok {
+			compare(child, old[i], new[j], changes)
+		} else {
+			*changes = append(*changes, Change{Op: Removed, Path: child, Old: old[i]})
+		}
+	}
+	for j, key := range newKeys {
+		if _, ok := oldIndex[key]; !ok {
+			*changes = append(*changes, Change{Op: Added, Path: path + "[" + key + "]", New: new[j]})
+		}
+	}
+}
+
+// keyField returns the first candidate field present on every element of
+// both arrays with unique values in each, or "" if there is none
+func keyField(arrays ...[]interface{}) string {
+	for _, field := range KeyFields {
+		usable := true
+		for _, arr := range arrays {
+			seen := map[string]bool{}
+			for _, elem := range arr {
+				obj, ok := elem.(map[string]interface{})
+				if !ok {
+					usable = false
+					break
+				}
+				value := records.Record(obj).String(field)
+				if value == "" || seen[value] {
+					usable = false
+					break
+				}
+				seen[value] = true
+			}
+			if !usable {
+				break
+			}
+		}
+		if usable {
+			return field
+		}
+	}
+	return ""
+}
+
+// elementKeys identifies array elements by key field, by scalar value, or
+// by content hash, disambiguating duplicates with an occurrence counter
+func elementKeys(arr []interface{}, field string) []string {
+	keys := make([]string, len(arr))
+	counts := map[string]int{}
+	for i, elem := range arr {
+		var key string
+		switch t := elem.(type) {
+		case map[string]interface{}:
+			if field != "" {
+				key = field + "=" + records.Record(t).String(field)
+			} else {
+				key = "#" + records.Record(t).Hash()[:12]
+			}
+		case []interface{}:
+			data, _ := json.Marshal(t)
+			key = string(data)
+		default:
+			key = fmt.Sprint(t)
+		}
+		counts[key]++
+		if counts[key] > 1 {
+			key = fmt.Sprintf("%s~%d", key, counts[key])
+		}
+		keys[i] = key
+	}
+	return keys
+}
+
+func equal(a, b interface{}) bool {
+	x, _ := json.Marshal(a)
+	y, _ := json.Marshal(b)
+	return string(x) == string(y)
+}
+
+func displayPath(path string) string {
+	if path == "" {
+		return "."
+	}
+	return path
+}
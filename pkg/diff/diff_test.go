@@ -0,0 +1,98 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+)
+
+// summary reduces changes to "op path" strings for comparison
+func summary(changes []Change) []string {
+	var out []string
+	for _, c := range changes {
+		out = append(out, c.Op+" "+c.Path)
+	}
+	return out
+}
+
+func TestBodies(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     []string
+	}{
+		{"identical", `{"a":1}`, `{"a":1}`, nil},
+		{"object fields", `{"a":1,"b":2}`, `{"b":3,"c":4}`, []string{"removed a", "changed b", "added c"}},
+		{"nested path", `{"user":{"name":"x"}}`, `{"user":{"name":"y"}}`, []string{"changed user.name"}},
+		{"root type change", `[1]`, `{"a":1}`, []string{"changed ."}},
+		{"first snapshot", ``, `[{"username":"a"}]`, []string{"changed ."}},
+		{
+			"handle added",
+			`[{"username":"old","date":"2020"}]`,
+			`[{"username":"new","date":"2023"},{"username":"old","date":"2020"}]`,
+			[]string{"added [username=new]"},
+		},
+		{
+			"handle removed",
+			`[{"username":"a"},{"username":"b"}]`,
+			`[{"username":"b"}]`,
+			[]string{"removed [username=a]"},
+		},
+		{
+			"field of a matched element changed",
+			`[{"username":"a","btype":""},{"username":"b","btype":""}]`,
+			`[{"username":"b","btype":"affiliate"},{"username":"a","btype":""}]`,
+			[]string{"changed [username=b].btype"},
+		},
+		{
+			"reordered array",
+			`[{"id":1,"n":"x"},{"id":2,"n":"y"},{"id":3,"n":"z"}]`,
+			`[{"id":3,"n":"z"},{"id":1,"n":"x"},{"id":2,"n":"y"}]`,
+			nil,
+		},
+		{
+			"id preferred over username",
+			`[{"id":1,"username":"a"}]`,
+			`[{"id":1,"username":"b"}]`,
+			[]string{"changed [id=1].username"},
+		},
+		{
+			"duplicate keys fall back to hashes",
+			`[{"username":"a","n":1},{"username":"a","n":2}]`,
+			`[{"username":"a","n":2},{"username":"a","n":1}]`,
+			nil,
+		},
+		{"scalar arrays", `["a","b","b"]`, `["b","c","b"]`, []string{"removed [a]", "added [c]"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := Bodies([]byte(tt.old), []byte(tt.new))
+			if err != nil {
+				t.Fatalf("Bodies: %v", err)
+			}
+			if got := summary(changes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Bodies = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBodiesValues(t *testing.T) {
+	changes, err := Bodies([]byte(`[{"username":"a","type":"user"}]`), []byte(`[{"username":"a","type":"staff"}]`))
+	if err != nil {
+		t.Fatalf("Bodies: %v", err)
+	}
+	want := []Change{{Op: Changed, Path: "[username=a].type", Old: "user", New: "staff"}}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("Bodies = %+v, want %+v", changes, want)
+	}
+}
+
+func TestBodiesInvalid(t *testing.T) {
+	if _, err := Bodies([]byte(`{`), []byte(`{}`)); err == nil {
+		t.Errorf("Bodies accepted an invalid previous document")
+	}
+	if _, err := Bodies([]byte(`{}`), []byte(`nope`)); err == nil {
+		t.Errorf("Bodies accepted an invalid current document")
+	}
+}
@@ -0,0 +1,69 @@
+// Package fields classifies the field names found in API records by the
+// kind of personal data they hold. It is shared by redaction and by the
+// database result summaries so that both agree on what a field contains.
+package fields
+
+import "strings"
+
+// Kinds of data a field can hold
+const (
+	Email    = "email"
+	Username = "username"
+	Password = "password"
+	Hash     = "hash"
+	IP       = "ip"
+	Phone    = "phone"
+	Name     = "name"
+)
+
+// kinds maps normalised field names to the kind of data they hold
+var kinds = map[string]string{
+	"email":          Email,
+	"mail":           Email,
+	"emails":         Email,
+	"emailaddress":   Email,
+	"username":       Username,
+	"user":           Username,
+	"login":          Username,
+	"nickname":       Username,
+	"nick":           Username,
+	"handle":         Username,
+	"screenname":     Username,
+	"password":       Password,
+	"pass":           Password,
+	"passwd":         Password,
+	"pwd":            Password,
+	"plaintext":      Password,
+	"hash":           Hash,
+	"passwordhash":   Hash,
+	"hashedpassword": Hash,
+	"passhash":       Hash,
+	"salt":           Hash,
+	"ip":             IP,
+	"ips":            IP,
+	"ipaddress":      IP,
+	"lastip":         IP,
+	"regip":          IP,
+	"registrationip": IP,
+	"phone":          Phone,
+	"phones":         Phone,
+	"phonenumber":    Phone,
+	"mobile":         Phone,
+	"tel":            Phone,
+	"telephone":      Phone,
+	"name":           Name,
+	"fullname":       Name,
+	"firstname":      Name,
+	"lastname":       Name,
+}
+
+// Normalise lowercases a field name and drops separators, so that
+// "Email_Address" and "emailaddress" compare equal
+func Normalise(field string) string {
+	return strings.NewReplacer("_", "", "-", "", " ", "").Replace(strings.ToLower(field))
+}
+
+// KindOf returns the kind of data a field name denotes, or "" when unknown
+func KindOf(field string) string {
+	return kinds[Normalise(field)]
+}
@@ -0,0 +1,233 @@
+package follows
+
+import (
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Sets that an account can belong to in a comparison
+const (
+	// Common holds accounts present in every compared list
+	Common = "common"
+	// Partial holds accounts present in more than one list but not in all
+	// of them, which only happens with three or more lists
+	Partial = "partial"
+	// OnlyPrefix prefixes the set of accounts present in one list only
+	OnlyPrefix = "only:"
+)
+
+// Member is an account found in one or more compared lists, with its
+// follow date in each list that contains it
+type Member struct {
+	Set   string                `json:"set"`
+	User  string                `json:"user"`
+	Dates map[string]*time.Time `json:"dates"`
+}
+
+// Similarity compares two lists. OnlyA and OnlyB count the accounts in
+// one list but not the other.
+type Similarity struct {
+	A       string  `json:"a"`
+	B       string  `json:"b"`
+	Common  int     `json:"common"`
+	OnlyA   int     `json:"only_a"`
+	OnlyB   int     `json:"only_b"`
+	Union   int     `json:"union"`
+	Jaccard float64 `json:"jaccard"`
+}
+
+// Comparison is the result of comparing the lists of several accounts
+type Comparison struct {
+	Users        []string         `json:"users"`
+	Sizes        map[string]int   `json:"sizes"`
+	Members      []Member         `json:"members"`
+	Similarities SimilarityMatrix `json:"similarity"`
+}
+
+// Compare places every account in exactly one set: common to all lists,
+// partial (in several but not all lists) or only in a single list. It also
+// computes the pairwise differences and Jaccard similarity. users gives the
+// order of the lists.
+func Compare(users []string, lists map[string][]Follow) *Comparison {
+	c := &Comparison{Users: users, Sizes: make(map[string]int)}
+
+	sets := make(map[string]map[string]*time.Time, len(users))
+	seen := make(map[string]int)
+	for _, u := range users {
+		set := make(map[string]*time.Time)
+		for _, f := range lists[u] {
+			if _, dup := set[f.User]; dup {
+				continue
+			}
+			set[f.User] = f.Date
+			seen[f.User]++
+		}
+		sets[u] = set
+		c.Sizes[u] = len(set)
+	}
+
+	dates := func(account string) map[string]*time.Time {
+		out := make(map[string]*time.Time)
+		for _, u := range users {
+			if d, ok := sets[u][account]; ok {
+				out[u] = d
+			}
+		}
+		return out
+	}
+
+	for account, n := range seen {
+		switch {
+		case n == len(users):
+			c.Members = append(c.Members, Member{Set: Common, User: account, Dates: dates(account)})
+		case n > 1:
+			c.Members = append(c.Members, Member{Set: Partial, User: account, Dates: dates(account)})
+		default:
+			for _, u := range users {
+				if _, ok := sets[u][account]; ok {
+					c.Members = append(c.Members, Member{Set: OnlyPrefix + u, User: account, Dates: dates(account)})
+				}
+			}
+		}
+	}
+
+	order := make(map[string]int, len(users)+2)
+	order[Common] = 0
+	order[Partial] = 1
+	for i, u := range users {
+		order[OnlyPrefix+u] = i + 2
+	}
+	sort.Slice(c.Members, func(i, j int) bool {
+		a, b := c.Members[i], c.Members[j]
+		if a.Set != b.Set {
+			return order[a.Set] < order[b.Set]
+		}
+		return a.User < b.User
+	})
+
+	for i := 0; i < len(users); i++ {
+		for j := i + 1; j < len(users); j++ {
+			a, b := sets[users[i]], sets[users[j]]
+			common := 0
+			for account := range a {
+				if _, ok := b[account]; ok {
+					common++
+				}
+			}
+			s := Similarity{
+				A:      users[i],
+				B:      users[j],
+				Common: common,
+				OnlyA:  len(a) - common,
+				OnlyB:  len(b) - common,
+				Union:  len(a) + len(b) - common,
+			}
+			if s.Union > 0 {
+				s.Jaccard = float64(common) / float64(s.Union)
+			}
+			c.Similarities = append(c.Similarities, s)
+		}
+	}
+	return c
+}
+
+// Filter returns the members belonging to set, or all members when set is
+// empty
+func (c *Comparison) Filter(set string) []Member {
+	if set == "" {
+		return c.Members
+	}
+	var out []Member
+	for _, m := range c.Members {
+		if m.Set == set {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// Select returns the members found in every list of in and in none of the
+// lists of notIn, e.g. Select([]string{"a"}, []string{"b"}) is the
+// difference of a and b
+func (c *Comparison) Select(in, notIn []string) []Member {
+	var out []Member
+	for _, m := range c.Members {
+		keep := true
+		for _, u := range in {
+			if _, ok := m.Dates[u]; !ok {
+				keep = false
+			}
+		}
+		for _, u := range notIn {
+			if _, ok := m.Dates[u]; ok {
+				keep = false
+			}
+		}
+		if keep {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// MemberTable renders members with one date column per compared account
+type MemberTable struct {
+	Users   []string
+	Members []Member
+}
+
+// Columns implements output.Tabular
+func (t MemberTable) Columns() []string {
+	cols := []string{"SET", "USER"}
+	for _, u := range t.Users {
+		cols = append(cols, u)
+	}
+	return cols
+}
+
+// Rows implements output.Tabular
+func (t MemberTable) Rows() [][]string {
+	rows := make([][]string, 0, len(t.Members))
+	for _, m := range t.Members {
+		row := []string{m.Set, m.User}
+		for _, u := range t.Users {
+			d, ok := m.Dates[u]
+			switch {
+			case !ok:
+				row = append(row, "")
+			case d == nil:
+				row = append(row, "yes")
+			default:
+				row = append(row, d.UTC().Format(time.RFC3339))
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// SimilarityMatrix lists pairwise similarities
+type SimilarityMatrix []Similarity
+
+// Columns implements output.Tabular
+func (m SimilarityMatrix) Columns() []string {
+	return []string{"A", "B", "COMMON", "ONLY_A", "ONLY_B", "UNION", "JACCARD"}
+}
+
+// Rows implements output.Tabular
+func (m SimilarityMatrix) Rows() [][]string {
+	rows := make([][]string, 0, len(m))
+	for _, s := range m {
+		rows = append(rows, []string{
+			s.A,
+			s.B,
+			strconv.Itoa(s.Common),
+			strconv.Itoa(s.OnlyA),
+			strconv.Itoa(s.OnlyB),
+			strconv.Itoa(s.Union),
+			strconv.FormatFloat(s.Jaccard, 'f', 4, 64),
+		})
+	}
+	return rows
+}
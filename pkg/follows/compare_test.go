@@ -0,0 +1,109 @@
+package follows
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func followList(users ...string) []Follow {
+	list := make([]Follow, len(users))
+	for i, u := range users {
+		list[i] = Follow{User: u}
+	}
+	return list
+}
+
+func memberSets(members []Member) map[string]string {
+	out := make(map[string]string, len(members))
+	for _, m := range members {
+		out[m.User] = m.Set
+	}
+	return out
+}
+
+func memberUsers(members []Member) []string {
+	var out []string
+	for _, m := range members {
+		out = append(out, m.User)
+	}
+	return out
+}
+
+func TestCompare(t *testing.T) {
+	date := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	lists := map[string][]Follow{
+		// x is listed twice and counted once
+		"a": append(followList("x", "y", "p", "x"), Follow{User: "z", Date: &date}),
+		"b": followList("x", "y", "q"),
+		"c": followList("x", "z", "r"),
+	}
+	c := Compare([]string{"a", "b", "c"}, lists)
+
+	wantSets := map[string]string{
+		"x": Common,
+		"y": Partial,
+		"z": Partial,
+		"p": OnlyPrefix + "a",
+		"q": OnlyPrefix + "b",
+		"r": OnlyPrefix + "c",
+	}
+	if got := memberSets(c.Members); !reflect.DeepEqual(got, wantSets) {
+		t.Errorf("sets = %v, want %v", got, wantSets)
+	}
+	// Members are ordered by set, then by user
+	if got, want := memberUsers(c.Members), []string{"x", "y", "z", "p", "q", "r"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("members = %v, want %v", got, want)
+	}
+	if want := map[string]int{"a": 4, "b": 3, "c": 3}; !reflect.DeepEqual(c.Sizes, want) {
+		t.Errorf("sizes = %v, want %v", c.Sizes, want)
+	}
+	if d := c.Members[2].Dates; len(d) != 2 || d["a"] == nil || !d["a"].Equal(date) || d["c"] != nil {
+		t.Errorf("dates of z = %v, want a's date and no date in c", d)
+	}
+
+	want := SimilarityMatrix{
+		{A: "a", B: "b", Common: 2, OnlyA: 2, OnlyB: 1, Union: 5, Jaccard: 0.4},
+		{A: "a", B: "c", Common: 2, OnlyA: 2, OnlyB: 1, Union: 5, Jaccard: 0.4},
+		{A: "b", B: "c", Common: 1, OnlyA: 2, OnlyB: 2, Union: 5, Jaccard: 0.2},
+	}
+	if !reflect.DeepEqual(c.Similarities, want) {
+		t.Errorf("similarities = %+v, want %+v", c.Similarities, want)
+	}
+}
+
+func TestCompareEmpty(t *testing.T) {
+	c := Compare([]string{"a", "b"}, map[string][]Follow{})
+	if len(c.Members) != 0 || len(c.Similarities) != 1 || c.Similarities[0].Jaccard != 0 {
+		t.Errorf("Compare of empty lists = %+v", c)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	lists := map[string][]Follow{
+		"a": followList("x", "y", "p"),
+		"b": followList("x", "y", "q"),
+		"c": followList("x", "r"),
+	}
+	c := Compare([]string{"a", "b", "c"}, lists)
+
+	tests := []struct {
+		in, notIn []string
+		want      []string
+	}{
+		{[]string{"a"}, []string{"b"}, []string{"p"}},
+		{[]string{"a", "b"}, nil, []string{"x", "y"}},
+		{[]string{"a", "b"}, []string{"c"}, []string{"y"}},
+		{nil, []string{"a", "b"}, []string{"r"}},
+		{[]string{"c"}, []string{"c"}, nil},
+	}
+	for _, tt := range tests {
+		if got := memberUsers(c.Select(tt.in, tt.notIn)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Select(%v, %v) = %v, want %v", tt.in, tt.notIn, got, tt.want)
+		}
+	}
+
+	if got := memberUsers(c.Filter(Partial)); !reflect.DeepEqual(got, []string{"y"}) {
+		t.Errorf("Filter(partial) = %v, want [y]", got)
+	}
+}
@@ -0,0 +1,44 @@
+// Package follows parses Twitch follower and followage lists.
+package follows
+
+import (
+	"strings"
+	"time"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/records"
+)
+
+// UserKeys are the fields checked, in order, for the other account's name
+var UserKeys = []string{"username", "login", "user_login", "user_name", "user", "name", "display_name", "channel", "value"}
+
+// DateKeys are the fields checked, in order, for the follow date
+var DateKeys = append([]string{"followed_at", "followedAt", "follow_date", "since"}, records.TimeKeys...)
+
+// Follow represents one entry of a follower or followage list
+type Follow struct {
+	User string     `json:"user"`
+	Date *time.Time `json:"date,omitempty"`
+}
+
+// Parse decodes a follower or followage response. Usernames are lowercased
+// so that lists from different calls can be compared.
+func Parse(body []byte) ([]Follow, error) {
+	recs, err := records.Extract(body)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]Follow, 0, len(recs))
+	for _, rec := range recs {
+		user := strings.ToLower(strings.TrimSpace(rec.String(UserKeys...)))
+		if user == "" {
+			continue
+		}
+		f := Follow{User: user}
+		if t, ok := rec.Time(DateKeys...); ok {
+			f.Date = &t
+		}
+		list = append(list, f)
+	}
+	return list, nil
+}
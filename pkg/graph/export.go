@@ -0,0 +1,237 @@
+package graph
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Export formats
+const (
+	GraphML = "graphml"
+	GEXF    = "gexf"
+	DOT     = "dot"
+	CSV     = "csv"
+)
+
+// Formats lists every export format
+var Formats = []string{GraphML, GEXF, DOT, CSV}
+
+// ParseFormat checks that format is one of Formats
+func ParseFormat(format string) error {
+	for _, f := range Formats {
+		if format == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown graph format %q (expected %s)", format, strings.Join(Formats, ", "))
+}
+
+// Write exports the graph in the given format
+func (g *Graph) Write(w io.Writer, format string) error {
+	switch format {
+	case GraphML:
+		return g.WriteGraphML(w)
+	case GEXF:
+		return g.WriteGEXF(w)
+	case DOT:
+		return g.WriteDOT(w)
+	case CSV:
+		return g.WriteCSV(w)
+	}
+	return ParseFormat(format)
+}
+
+func formatDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+type graphmlKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphmlData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type graphmlNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphmlData `xml:"data"`
+}
+
+type graphmlEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphmlData `xml:"data,omitempty"`
+}
+
+type graphmlDoc struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphmlKey `xml:"key"`
+	Graph   struct {
+		ID          string        `xml:"id,attr"`
+		EdgeDefault string        `xml:"edgedefault,attr"`
+		Nodes       []graphmlNode `xml:"node"`
+		Edges       []graphmlEdge `xml:"edge"`
+	} `xml:"graph"`
+}
+
+// WriteGraphML exports the graph as GraphML
+func (g *Graph) WriteGraphML(w io.Writer) error {
+	doc := graphmlDoc{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphmlKey{
+			{ID: "depth", For: "node", Name: "depth", Type: "int"},
+			{ID: "expanded", For: "node", Name: "expanded", Type: "boolean"},
+			{ID: "followed_at", For: "edge", Name: "followed_at", Type: "string"},
+		},
+	}
+	doc.Graph.ID = g.Seed
+	doc.Graph.EdgeDefault = "directed"
+	for _, n := range g.Nodes() {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphmlNode{
+			ID: n.ID,
+			Data: []graphmlData{
+				{Key: "depth", Value: strconv.Itoa(n.Depth)},
+				{Key: "expanded", Value: strconv.FormatBool(n.Expanded)},
+			},
+		})
+	}
+	for _, e := range g.Edges() {
+		edge := graphmlEdge{Source: e.Source, Target: e.Target}
+		if e.Date != nil {
+			edge.Data = []graphmlData{{Key: "followed_at", Value: formatDate(e.Date)}}
+		}
+		doc.Graph.Edges = append(doc.Graph.Edges, edge)
+	}
+	return writeXML(w, doc)
+}
+
+type gexfAttribute struct {
+	ID    string `xml:"id,attr"`
+	Title string `xml:"title,attr"`
+	Type  string `xml:"type,attr"`
+}
+
+type gexfAttributes struct {
+	Class      string          `xml:"class,attr"`
+	Attributes []gexfAttribute `xml:"attribute"`
+}
+
+type gexfValue struct {
+	For   string `xml:"for,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type gexfNode struct {
+	ID     string      `xml:"id,attr"`
+	Label  string      `xml:"label,attr"`
+	Values []gexfValue `xml:"attvalues>attvalue"`
+}
+
+type gexfEdge struct {
+	ID     string      `xml:"id,attr"`
+	Source string      `xml:"source,attr"`
+	Target string      `xml:"target,attr"`
+	Values []gexfValue `xml:"attvalues>attvalue,omitempty"`
+}
+
+type gexfDoc struct {
+	XMLName xml.Name `xml:"gexf"`
+	XMLNS   string   `xml:"xmlns,attr"`
+	Version string   `xml:"version,attr"`
+	Graph   struct {
+		DefaultEdgeType string           `xml:"defaultedgetype,attr"`
+		Attributes      []gexfAttributes `xml:"attributes"`
+		Nodes           []gexfNode       `xml:"nodes>node"`
+		Edges           []gexfEdge       `xml:"edges>edge"`
+	} `xml:"graph"`
+}
+
+// WriteGEXF exports the graph as GEXF 1.3
+func (g *Graph) WriteGEXF(w io.Writer) error {
+	doc := gexfDoc{XMLNS: "http://gexf.net/1.3", Version: "1.3"}
+	doc.Graph.DefaultEdgeType = "directed"
+	doc.Graph.Attributes = []gexfAttributes{
+		{Class: "node", Attributes: []gexfAttribute{
+			{ID: "depth", Title: "depth", Type: "integer"},
+			{ID: "expanded", Title: "expanded", Type: "boolean"},
+		}},
+		{Class: "edge", Attributes: []gexfAttribute{
+			{ID: "followed_at", Title: "followed_at", Type: "string"},
+		}},
+	}
+	for _, n := range g.Nodes() {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, gexfNode{
+			ID:    n.ID,
+			Label: n.ID,
+			Values: []gexfValue{
+				{For: "depth", Value: strconv.Itoa(n.Depth)},
+				{For: "expanded", Value: strconv.FormatBool(n.Expanded)},
+			},
+		})
+	}
+	for i, e := range g.Edges() {
+		edge := gexfEdge{ID: strconv.Itoa(i), Source: e.Source, Target: e.Target}
+		if e.Date != nil {
+			edge.Values = []gexfValue{{For: "followed_at", Value: formatDate(e.Date)}}
+		}
+		doc.Graph.Edges = append(doc.Graph.Edges, edge)
+	}
+	return writeXML(w, doc)
+}
+
+func writeXML(w io.Writer, doc interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode graph: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// WriteDOT exports the graph in Graphviz DOT format
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", strconv.Quote(g.Seed))
+	for _, n := range g.Nodes() {
+		fmt.Fprintf(&b, "  %s [depth=%d];\n", strconv.Quote(n.ID), n.Depth)
+	}
+	for _, e := range g.Edges() {
+		if e.Date != nil {
+			fmt.Fprintf(&b, "  %s -> %s [followed_at=%s];\n", strconv.Quote(e.Source), strconv.Quote(e.Target), strconv.Quote(formatDate(e.Date)))
+		} else {
+			fmt.Fprintf(&b, "  %s -> %s;\n", strconv.Quote(e.Source), strconv.Quote(e.Target))
+		}
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteCSV exports the edge list as CSV
+func (g *Graph) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"source", "target", "followed_at"})
+	for _, e := range g.Edges() {
+		cw.Write([]string{e.Source, e.Target, formatDate(e.Date)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
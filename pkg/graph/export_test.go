@@ -0,0 +1,107 @@
+package graph
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// escapingGraph has IDs that need quoting in every format
+func escapingGraph() *Graph {
+	g := New(`seed"x`)
+	g.Node(`seed"x`).Expanded = true
+	g.AddNode("a&b<c>", 1)
+	date := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	g.AddEdge("a&b<c>", `seed"x`, &date)
+	g.AddEdge(`seed"x`, "a&b<c>", nil)
+	return g
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{GraphML, `<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+  <key id="depth" for="node" attr.name="depth" attr.type="int"></key>
+  <key id="expanded" for="node" attr.name="expanded" attr.type="boolean"></key>
+  <key id="followed_at" for="edge" attr.name="followed_at" attr.type="string"></key>
+  <graph id="seed&#34;x" edgedefault="directed">
+    <node id="seed&#34;x">
+      <data key="depth">0</data>
+      <data key="expanded">true</data>
+    </node>
+    <node id="a&amp;b&lt;c&gt;">
+      <data key="depth">1</data>
+      <data key="expanded">false</data>
+    </node>
+    <edge source="a&amp;b&lt;c&gt;" target="seed&#34;x">
+      <data key="followed_at">2024-01-02T03:04:05Z</data>
+    </edge>
+    <edge source="seed&#34;x" target="a&amp;b&lt;c&gt;"></edge>
+  </graph>
+</graphml>
+`},
+		{GEXF, `<?xml version="1.0" encoding="UTF-8"?>
+<gexf xmlns="http://gexf.net/1.3" version="1.3">
+  <graph defaultedgetype="directed">
+    <attributes class="node">
+      <attribute id="depth" title="depth" type="integer"></attribute>
+      <attribute id="expanded" title="expanded" type="boolean"></attribute>
+    </attributes>
+    <attributes class="edge">
+      <attribute id="followed_at" title="followed_at" type="string"></attribute>
+    </attributes>
+    <nodes>
+      <node id="seed&#34;x" label="seed&#34;x">
+        <attvalues>
+          <attvalue for="depth" value="0"></attvalue>
+          <attvalue for="expanded" value="true"></attvalue>
+        </attvalues>
+      </node>
+      <node id="a&amp;b&lt;c&gt;" label="a&amp;b&lt;c&gt;">
+        <attvalues>
+          <attvalue for="depth" value="1"></attvalue>
+          <attvalue for="expanded" value="false"></attvalue>
+        </attvalues>
+      </node>
+    </nodes>
+    <edges>
+      <edge id="0" source="a&amp;b&lt;c&gt;" target="seed&#34;x">
+        <attvalues>
+          <attvalue for="followed_at" value="2024-01-02T03:04:05Z"></attvalue>
+        </attvalues>
+      </edge>
+      <edge id="1" source="seed&#34;x" target="a&amp;b&lt;c&gt;">
+        <attvalues></attvalues>
+      </edge>
+    </edges>
+  </graph>
+</gexf>
+`},
+		{DOT, `digraph "seed\"x" {
+  "seed\"x" [depth=0];
+  "a&b<c>" [depth=1];
+  "a&b<c>" -> "seed\"x" [followed_at="2024-01-02T03:04:05Z"];
+  "seed\"x" -> "a&b<c>";
+}
+`},
+		{CSV, `source,target,followed_at
+a&b<c>,"seed""x",2024-01-02T03:04:05Z
+"seed""x",a&b<c>,
+`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := escapingGraph().Write(&buf, tt.format); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("Write(%s) =\n%s\nwant\n%s", tt.format, buf.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+// Package graph builds Twitch follow graphs by breadth-first exploration and
+// exports them for external graph tools.
+package graph
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/follows"
+)
+
+// Directions that can be explored from a node
+const (
+	// Followers adds an edge from every follower to the node
+	Followers = "followers"
+	// Followage adds an edge from the node to every account it follows
+	Followage = "followage"
+)
+
+// ErrBudget is returned by a Fetcher when no more requests may be made
+var ErrBudget = errors.New("credit budget exhausted")
+
+// Node represents an account in the graph. Expanded is set once every
+// list of the node has been fetched.
+type Node struct {
+	ID       string `json:"id"`
+	Depth    int    `json:"depth"`
+	Expanded bool   `json:"expanded"`
+}
+
+// Edge represents "Source follows Target"
+type Edge struct {
+	Source string     `json:"source"`
+	Target string     `json:"target"`
+	Date   *time.Time `json:"date,omitempty"`
+}
+
+// Graph represents a directed follow graph
+type Graph struct {
+	Seed      string
+	Truncated bool
+
+	nodes map[string]*Node
+	order []string
+	edges []Edge
+	seen  map[[2]string]bool
+}
+
+// New creates a graph containing only the seed node
+func New(seed string) *Graph {
+	g := &Graph{Seed: seed, nodes: map[string]*Node{}, seen: map[[2]string]bool{}}
+	g.AddNode(seed, 0)
+	return g
+}
+
+// AddNode adds a node unless it already exists and reports whether it was
+// added
+func (g *Graph) AddNode(id string, depth int) (*Node, bool) {
+	if n, ok := g.nodes[id]; ok {
+		return n, false
+	}
+	n := &Node{ID: id, Depth: depth}
+	g.nodes[id] = n
+	g.order = append(g.order, id)
+	return n, true
+}
+
+// AddEdge adds a directed edge unless it already exists
+func (g *Graph) AddEdge(source, target string, date *time.Time) {
+	key := [2]string{source, target}
+	if g.seen[key] {
+		return
+	}
+	g.seen[key] = true
+	g.edges = append(g.edges, Edge{Source: source, Target: target, Date: date})
+}
+
+// Node returns the node with the given ID, or nil
+func (g *Graph) Node(id string) *Node {
+	return g.nodes[id]
+}
+
+// Nodes returns the nodes in insertion order
+func (g *Graph) Nodes() []*Node {
+	nodes := make([]*Node, 0, len(g.order))
+	for _, id := range g.order {
+		nodes = append(nodes, g.nodes[id])
+	}
+	return nodes
+}
+
+// Edges returns the edges in insertion order
+func (g *Graph) Edges() []Edge {
+	return g.edges
+}
+
+// Fetcher returns the follower or followage list of a user
+type Fetcher func(user, direction string) ([]follows.Follow, error)
+
+// CrawlOptions bounds a crawl
+type CrawlOptions struct {
+	// Depth is the number of hops to expand from the seed
+	Depth int
+	// MaxNodes caps the size of the graph; zero means no limit
+	MaxNodes int
+	// Directions lists the lists fetched for every expanded node
+	Directions []string
+	// OnError is called for fetch errors other than ErrBudget, which are
+	// otherwise skipped
+	OnError func(user, direction string, err error)
+}
+
+// Crawl explores the follow graph breadth-first from seed. When the fetcher
+// returns ErrBudget the partial graph is returned together with the error.
+func Crawl(seed string, opts CrawlOptions, fetch Fetcher) (*Graph, error) {
+	g := New(seed)
+	queue := []string{seed}
+
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		node := g.nodes[id]
+		if node.Depth >= opts.Depth {
+			continue
+		}
+
+		expanded := true
+		for _, dir := range opts.Directions {
+			list, err := fetch(id, dir)
+			if errors.Is(err, ErrBudget) {
+				return g, err
+			}
+			if err != nil {
+				if opts.OnError != nil {
+					opts.OnError(id, dir, err)
+				}
+				expanded = false
+				continue
+			}
+
+			for _, f := range list {
+				if f.User == id {
+					continue
+				}
+				if g.nodes[f.User] == nil && opts.MaxNodes > 0 && len(g.nodes) >= opts.MaxNodes {
+					g.Truncated = true
+					continue
+				}
+				if _, added := g.AddNode(f.User, node.Depth+1); added {
+					queue = append(queue, f.User)
+				}
+				if dir == Followers {
+					g.AddEdge(f.User, id, f.Date)
+				} else {
+					g.AddEdge(id, f.User, f.Date)
+				}
+			}
+		}
+		node.Expanded = expanded
+	}
+	return g, nil
+}
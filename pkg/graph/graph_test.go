@@ -0,0 +1,130 @@
+package graph
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/follows"
+)
+
+// fakeFetcher serves follower lists from a map and records every call
+type fakeFetcher struct {
+	followers map[string][]string
+	fail      map[string]error
+	calls     []string
+}
+
+func (f *fakeFetcher) fetch(user, direction string) ([]follows.Follow, error) {
+	f.calls = append(f.calls, user)
+	if err := f.fail[user]; err != nil {
+		return nil, err
+	}
+	var list []follows.Follow
+	for _, u := range f.followers[user] {
+		list = append(list, follows.Follow{User: u})
+	}
+	return list, nil
+}
+
+func nodeIDs(g *Graph) []string {
+	var ids []string
+	for _, n := range g.Nodes() {
+		ids = append(ids, n.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestCrawlDepth(t *testing.T) {
+	f := &fakeFetcher{followers: map[string][]string{
+		"seed": {"a", "b"},
+		"a":    {"c", "seed"},
+		"b":    {"a"},
+		"c":    {"d"},
+	}}
+
+	g, err := Crawl("seed", CrawlOptions{Depth: 2, Directions: []string{Followers}}, f.fetch)
+	if err != nil {
+		t.Fatalf("Crawl: %v", err)
+	}
+
+	// c is at depth 2 and is not expanded, so d is never reached
+	if want := []string{"a", "b", "c", "seed"}; !reflect.DeepEqual(nodeIDs(g), want) {
+		t.Errorf("nodes = %v, want %v", nodeIDs(g), want)
+	}
+	if want := []string{"seed", "a", "b"}; !reflect.DeepEqual(f.calls, want) {
+		t.Errorf("fetched %v, want %v", f.calls, want)
+	}
+	if g.Node("c").Depth != 2 || g.Node("c").Expanded {
+		t.Errorf("c = %+v, want depth 2 and not expanded", *g.Node("c"))
+	}
+	if len(g.Edges()) != 5 || g.Truncated {
+		t.Errorf("got %d edges, truncated %v; want 5 and false", len(g.Edges()), g.Truncated)
+	}
+}
+
+func TestCrawlMaxNodes(t *testing.T) {
+	f := &fakeFetcher{followers: map[string][]string{
+		"seed": {"a", "b", "c"},
+		"a":    {"b", "d"},
+	}}
+
+	g, err := Crawl("seed", CrawlOptions{Depth: 2, MaxNodes: 3, Directions: []string{Followers}}, f.fetch)
+	if err != nil {
+		t.Fatalf("Crawl: %v", err)
+	}
+	if want := []string{"a", "b", "seed"}; !reflect.DeepEqual(nodeIDs(g), want) {
+		t.Errorf("nodes = %v, want %v", nodeIDs(g), want)
+	}
+	if !g.Truncated {
+		t.Errorf("Truncated = false, want true")
+	}
+	// Edges between nodes already in the graph are still added
+	if g.Node("a").Expanded != true || len(g.Edges()) != 3 {
+		t.Errorf("a expanded %v, %d edges; want true and 3", g.Node("a").Expanded, len(g.Edges()))
+	}
+}
+
+func TestCrawlBudget(t *testing.T) {
+	f := &fakeFetcher{
+		followers: map[string][]string{"seed": {"a", "b"}},
+		fail:      map[string]error{"b": ErrBudget},
+	}
+
+	g, err := Crawl("seed", CrawlOptions{Depth: 3, Directions: []string{Followers}}, f.fetch)
+	if !errors.Is(err, ErrBudget) {
+		t.Fatalf("Crawl error = %v, want ErrBudget", err)
+	}
+	if g == nil || !reflect.DeepEqual(nodeIDs(g), []string{"a", "b", "seed"}) {
+		t.Fatalf("Crawl did not return the partial graph")
+	}
+	if !g.Node("a").Expanded || g.Node("b").Expanded {
+		t.Errorf("a expanded %v, b expanded %v; want true and false", g.Node("a").Expanded, g.Node("b").Expanded)
+	}
+}
+
+func TestCrawlFetchError(t *testing.T) {
+	f := &fakeFetcher{
+		followers: map[string][]string{"seed": {"a"}},
+		fail:      map[string]error{"a": errors.New("not found")},
+	}
+
+	var failed []string
+	opts := CrawlOptions{
+		Depth:      2,
+		Directions: []string{Followers},
+		OnError:    func(user, direction string, err error) { failed = append(failed, user) },
+	}
+	g, err := Crawl("seed", opts, f.fetch)
+	if err != nil {
+		t.Fatalf("Crawl: %v", err)
+	}
+	if !reflect.DeepEqual(failed, []string{"a"}) {
+		t.Errorf("OnError called for %v, want [a]", failed)
+	}
+	if g.Node("a").Expanded {
+		t.Errorf("a is marked expanded although its list was not fetched")
+	}
+}
@@ -0,0 +1,188 @@
+// Package kickreport combines the per-channel lookups of a Kick account
+// (moderated channels, subscriptions, timeouts and messages) into one row
+// per channel.
+package kickreport
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/moderation"
+	"github.com/ivan9253/lolarchiver-cli/pkg/records"
+	"github.com/ivan9253/lolarchiver-cli/pkg/timeline"
+)
+
+// ChannelKeys are the fields naming the channel of a Kick record. Mod
+// channel lists may be plain strings, which records.Extract stores as value.
+var ChannelKeys = append(append([]string{}, timeline.ChannelKeys...), "slug", "value")
+
+// unknownChannel groups records that do not name a channel
+const unknownChannel = "(unknown)"
+
+// Report combines every lookup for a Kick account
+type Report struct {
+	Username string   `json:"username"`
+	Sources  []Source `json:"sources"`
+	Channels Channels `json:"channels"`
+
+	byName map[string]*Channel
+}
+
+// Source records the outcome of one lookup
+type Source struct {
+	Name    string `json:"name"`
+	Records int    `json:"records"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Channel summarises the account's relationship with one channel
+type Channel struct {
+	Channel        string     `json:"channel"`
+	Moderator      bool       `json:"moderator"`
+	Subscribed     bool       `json:"subscribed"`
+	Timeouts       int        `json:"timeouts"`
+	Bans           int        `json:"bans"`
+	TimeoutSeconds int64      `json:"timeout_seconds"`
+	LastTimeout    *time.Time `json:"last_timeout,omitempty"`
+	Messages       int        `json:"messages"`
+	FirstMessage   *time.Time `json:"first_message,omitempty"`
+	LastMessage    *time.Time `json:"last_message,omitempty"`
+}
+
+// New creates an empty report for username
+func New(username string) *Report {
+	return &Report{
+		Username: username,
+		Channels: Channels{},
+		byName:   make(map[string]*Channel),
+	}
+}
+
+func (r *Report) channel(rec records.Record) *Channel {
+	name := strings.ToLower(strings.TrimPrefix(rec.String(ChannelKeys...), "#"))
+	if name == "" {
+		name = unknownChannel
+	}
+	ch, ok := r.byName[name]
+	if !ok {
+		ch = &Channel{Channel: name}
+		r.byName[name] = ch
+		r.Channels = append(r.Channels, ch)
+	}
+	return ch
+}
+
+// AddSource records the outcome of a lookup
+func (r *Report) AddSource(name string, n int, err error) {
+	src := Source{Name: name, Records: n}
+	if err != nil {
+		src.Error = err.Error()
+	}
+	r.Sources = append(r.Sources, src)
+}
+
+// AddMods marks the channels the account moderates
+func (r *Report) AddMods(recs []records.Record) {
+	for _, rec := range recs {
+		r.channel(rec).Moderator = true
+	}
+}
+
+// AddTimeouts counts the timeouts and bans the account received per
+// channel. Unban records are skipped.
+func (r *Report) AddTimeouts(recs []records.Record) {
+	for _, rec := range recs {
+		if moderation.IsUnban(rec) {
+			continue
+		}
+		ch := r.channel(rec)
+		seconds, ban := moderation.Length(rec)
+		if ban {
+			ch.Bans++
+		} else {
+			ch.Timeouts++
+			ch.TimeoutSeconds += seconds
+		}
+		if t, ok := rec.Time(records.TimeKeys...); ok && (ch.LastTimeout == nil || t.After(*ch.LastTimeout)) {
+			ch.LastTimeout = &t
+		}
+	}
+}
+
+// AddMessages counts the messages the account sent per channel
+func (r *Report) AddMessages(recs []records.Record) {
+	for _, rec := range recs {
+		ch := r.channel(rec)
+		ch.Messages++
+		if t, ok := rec.Time(records.TimeKeys...); ok {
+			if ch.FirstMessage == nil || t.Before(*ch.FirstMessage) {
+				ch.FirstMessage = &t
+			}
+			if ch.LastMessage == nil || t.After(*ch.LastMessage) {
+				ch.LastMessage = &t
+			}
+		}
+	}
+}
+
+// AddSubscriptions marks the channels the account is subscribed to
+func (r *Report) AddSubscriptions(recs []records.Record) {
+	for _, rec := range recs {
+		r.channel(rec).Subscribed = true
+	}
+}
+
+// Sort orders channels by message count, then by name
+func (r *Report) Sort() {
+	sort.Slice(r.Channels, func(i, j int) bool {
+		a, b := r.Channels[i], r.Channels[j]
+		if a.Messages != b.Messages {
+			return a.Messages > b.Messages
+		}
+		return a.Channel < b.Channel
+	})
+}
+
+// Channels is the tabular form of the per-channel rows
+type Channels []*Channel
+
+// Columns implements output.Tabular
+func (c Channels) Columns() []string {
+	return []string{"CHANNEL", "MODERATOR", "SUBSCRIBED", "TIMEOUTS", "BANS", "TIMEOUT_SECONDS", "LAST_TIMEOUT", "MESSAGES", "FIRST_MESSAGE", "LAST_MESSAGE"}
+}
+
+// Rows implements output.Tabular
+func (c Channels) Rows() [][]string {
+	rows := make([][]string, 0, len(c))
+	for _, ch := range c {
+		rows = append(rows, []string{
+			ch.Channel,
+			yesNo(ch.Moderator),
+			yesNo(ch.Subscribed),
+			strconv.Itoa(ch.Timeouts),
+			strconv.Itoa(ch.Bans),
+			strconv.FormatInt(ch.TimeoutSeconds, 10),
+			formatTime(ch.LastTimeout),
+			strconv.Itoa(ch.Messages),
+			formatTime(ch.FirstMessage),
+			formatTime(ch.LastMessage),
+		})
+	}
+	return rows
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
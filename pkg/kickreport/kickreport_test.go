@@ -0,0 +1,113 @@
+package kickreport
+
+import (
+	"testing"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/records"
+)
+
+func TestReport(t *testing.T) {
+	r := New("bob")
+	r.AddMods([]records.Record{{"value": "Adin"}})
+	r.AddSubscriptions([]records.Record{{"channel": "#adin"}, {"slug": "xqc"}})
+	r.AddTimeouts([]records.Record{
+		{"channel": "trainwreck", "duration": 60.0},
+		{"channel": "trainwreck", "type": "ban"},
+		{"channel": "trainwreck", "type": "unban"},
+	})
+	r.AddMessages([]records.Record{
+		{"channel": "trainwreck", "created_at": "2025-02-01 10:00:00"},
+		{"channel": "trainwreck", "created_at": "2025-02-03 10:00:00"},
+		{"message": "no channel"},
+	})
+	r.Sort()
+
+	byName := make(map[string]*Channel)
+	for _, ch := range r.Channels {
+		byName[ch.Channel] = ch
+	}
+	if len(r.Channels) != 4 {
+		t.Fatalf("channels = %d, want adin, xqc, trainwreck and (unknown)", len(r.Channels))
+	}
+	if r.Channels[0].Channel != "trainwreck" {
+		t.Errorf("first channel = %s, want the one with most messages", r.Channels[0].Channel)
+	}
+
+	adin := byName["adin"]
+	if adin == nil || !adin.Moderator || !adin.Subscribed {
+		t.Errorf("adin = %+v, want moderator and subscribed", adin)
+	}
+	if xqc := byName["xqc"]; xqc == nil || xqc.Moderator || !xqc.Subscribed {
+		t.Errorf("xqc = %+v, want subscribed only", xqc)
+	}
+
+	tw := byName["trainwreck"]
+	if tw.Timeouts != 1 || tw.Bans != 1 || tw.TimeoutSeconds != 60 || tw.Messages != 2 {
+		t.Errorf("trainwreck = %+v, want 1 timeout of 60s, 1 ban and 2 messages", tw)
+	}
+	if tw.FirstMessage == nil || tw.LastMessage == nil || !tw.FirstMessage.Before(*tw.LastMessage) {
+		t.Errorf("trainwreck messages span %v to %v", tw.FirstMessage, tw.LastMessage)
+	}
+	if byName[unknownChannel] == nil {
+		t.Errorf("message without a channel not grouped under %s", unknownChannel)
+	}
+}
+
+func TestReportSingleSource(t *testing.T) {
+	// Each channel appears in one lookup only and must get a row of its own
+	tests := []struct {
+		name string
+		add  func(r *Report)
+		want Channel
+	}{
+		{
+			"subscription only",
+			func(r *Report) { r.AddSubscriptions([]records.Record{{"slug": "XQC"}}) },
+			Channel{Channel: "xqc", Subscribed: true},
+		},
+		{
+			"mod channel only",
+			func(r *Report) { r.AddMods([]records.Record{{"value": "#Adin"}}) },
+			Channel{Channel: "adin", Moderator: true},
+		},
+		{
+			"timeout only",
+			func(r *Report) {
+				r.AddTimeouts([]records.Record{{"channel": "trainwreck", "duration": 600.0}})
+			},
+			Channel{Channel: "trainwreck", Timeouts: 1, TimeoutSeconds: 600},
+		},
+		{
+			"ban only",
+			func(r *Report) { r.AddTimeouts([]records.Record{{"channel": "trainwreck", "type": "ban"}}) },
+			Channel{Channel: "trainwreck", Bans: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New("bob")
+			tt.add(r)
+			if len(r.Channels) != 1 || *r.Channels[0] != tt.want {
+				t.Errorf("channels = %v, want %+v", r.Channels.Rows(), tt.want)
+			}
+		})
+	}
+}
+
+func TestReportSkipsUnbans(t *testing.T) {
+	r := New("bob")
+	r.AddTimeouts([]records.Record{
+		{"channel": "trainwreck", "type": "unban"},
+		{"channel": "trainwreck", "action": "Untimeout"},
+		{"channel": "adin", "type": "timeout", "duration": 30.0},
+	})
+
+	// An unban alone does not create a channel row
+	if len(r.Channels) != 1 || r.Channels[0].Channel != "adin" {
+		t.Fatalf("channels = %v, want adin only", r.Channels.Rows())
+	}
+	if ch := r.Channels[0]; ch.Timeouts != 1 || ch.Bans != 0 || ch.TimeoutSeconds != 30 {
+		t.Errorf("adin = %+v, want one 30s timeout", ch)
+	}
+}
@@ -0,0 +1,413 @@
+// Package moderation aggregates the chat timeouts and bans of a user across
+// platforms: where they happened, how long they lasted and whether they
+// grew more severe over time.
+package moderation
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/records"
+	"github.com/ivan9253/lolarchiver-cli/pkg/timeline"
+)
+
+// DurationKeys are the fields checked, in order, for a timeout length in
+// seconds
+var DurationKeys = []string{"duration", "length", "seconds", "timeout_duration"}
+
+// ChannelKeys are the fields naming the channel an action was taken in
+var ChannelKeys = append(append([]string{}, timeline.ChannelKeys...), "slug")
+
+// ReasonKeys are the fields checked, in order, for the moderator's reason
+var ReasonKeys = []string{"reason", "message", "note"}
+
+// KindKeys are the fields checked, in order, for the type of action
+var KindKeys = []string{"type", "action", "kind"}
+
+// Action is a single timeout or ban
+type Action struct {
+	Time     time.Time        `json:"time"`
+	Platform string           `json:"platform"`
+	Channel  string           `json:"channel"`
+	Ban      bool             `json:"ban"`
+	Seconds  int64            `json:"seconds,omitempty"`
+	Reason   string           `json:"reason,omitempty"`
+	Context  []timeline.Event `json:"context,omitempty"`
+}
+
+// Length returns the length of a timeout in seconds, and whether the record
+// is a ban: typed as one, or with a duration that is explicitly 0 or null.
+// A timeout whose duration is missing or unreadable has an unknown length
+// and is returned as 0 seconds, as is an unban, which callers should skip.
+func Length(rec records.Record) (int64, bool) {
+	if IsUnban(rec) {
+		return 0, false
+	}
+	if strings.Contains(strings.ToLower(rec.String(KindKeys...)), "ban") {
+		return 0, true
+	}
+	for _, key := range DurationKeys {
+		v, ok := rec[key]
+		if !ok {
+			continue
+		}
+		if v == nil {
+			return 0, true
+		}
+		seconds, err := strconv.ParseFloat(rec.String(key), 64)
+		if err != nil {
+			return 0, false
+		}
+		if seconds == 0 {
+			return 0, true
+		}
+		return max(int64(seconds), 0), false
+	}
+	return 0, false
+}
+
+// IsUnban reports whether a record lifts a ban or timeout rather than
+// imposing one
+func IsUnban(rec records.Record) bool {
+	kind := strings.ToLower(rec.String(KindKeys...))
+	return strings.Contains(kind, "unban") || strings.Contains(kind, "untimeout")
+}
+
+// FromRecords normalises the timeout records of one platform. Unban records
+// are skipped, since they are not actions against the user.
+func FromRecords(platform string, recs []records.Record) []Action {
+	actions := make([]Action, 0, len(recs))
+	for _, rec := range recs {
+		if IsUnban(rec) {
+			continue
+		}
+		t, _ := rec.Time(records.TimeKeys...)
+		seconds, ban := Length(rec)
+		channel := channelKey(rec.String(ChannelKeys...))
+		if channel == "" {
+			channel = "(unknown)"
+		}
+		actions = append(actions, Action{
+			Time:     t,
+			Platform: platform,
+			Channel:  channel,
+			Ban:      ban,
+			Seconds:  seconds,
+			Reason:   rec.String(ReasonKeys...),
+		})
+	}
+	return actions
+}
+
+// channelKey normalises a channel name so that actions and messages from
+// the same channel compare equal
+func channelKey(name string) string {
+	return strings.ToLower(strings.TrimPrefix(name, "#"))
+}
+
+// Correlate attaches to each action up to n of the user's messages in the
+// same channel sent at or before the action, oldest first. Actions without
+// a timestamp are left alone.
+func Correlate(actions []Action, messages timeline.Timeline, n int) {
+	byChannel := make(map[string]timeline.Timeline)
+	for _, ev := range messages {
+		if ev.Time.IsZero() {
+			continue
+		}
+		key := ev.Platform + "\x00" + channelKey(ev.Channel)
+		byChannel[key] = append(byChannel[key], ev)
+	}
+	for _, tl := range byChannel {
+		tl.Sort()
+	}
+
+	for i := range actions {
+		a := &actions[i]
+		if a.Time.IsZero() || n <= 0 {
+			continue
+		}
+		tl := byChannel[a.Platform+"\x00"+channelKey(a.Channel)]
+		end := sort.Search(len(tl), func(j int) bool { return tl[j].Time.After(a.Time) })
+		start := max(end-n, 0)
+		if start < end {
+			a.Context = append([]timeline.Event{}, tl[start:end]...)
+		}
+	}
+}
+
+// severity orders actions so that any ban outranks any timeout
+func (a Action) severity() int64 {
+	if a.Ban {
+		return 1<<62 - 1
+	}
+	return a.Seconds
+}
+
+// Channel summarises the actions taken against the user in one channel
+type Channel struct {
+	Platform       string     `json:"platform"`
+	Channel        string     `json:"channel"`
+	Timeouts       int        `json:"timeouts"`
+	Bans           int        `json:"bans"`
+	TimeoutSeconds int64      `json:"timeout_seconds"`
+	LongestSeconds int64      `json:"longest_seconds"`
+	First          *time.Time `json:"first,omitempty"`
+	Last           *time.Time `json:"last,omitempty"`
+	Escalating     bool       `json:"escalating"`
+}
+
+// Period summarises the actions taken in one calendar month
+type Period struct {
+	Period         string `json:"period"`
+	Timeouts       int    `json:"timeouts"`
+	Bans           int    `json:"bans"`
+	TimeoutSeconds int64  `json:"timeout_seconds"`
+	AvgSeconds     int64  `json:"avg_seconds"`
+
+	// timed counts the timeouts of known length, which the average is over
+	timed int
+}
+
+// Totals summarises every action
+type Totals struct {
+	Actions        int   `json:"actions"`
+	Timeouts       int   `json:"timeouts"`
+	Bans           int   `json:"bans"`
+	TimeoutSeconds int64 `json:"timeout_seconds"`
+	Channels       int   `json:"channels"`
+	Escalating     int   `json:"escalating_channels"`
+}
+
+// History is the combined moderation history of a user
+type History struct {
+	Totals   Totals   `json:"totals"`
+	Channels Channels `json:"channels"`
+	Periods  Periods  `json:"periods"`
+	Actions  Actions  `json:"actions"`
+}
+
+// Summarise sorts actions chronologically and aggregates them by channel
+// and by month. A channel is escalating when it has at least two actions,
+// none less severe than the one before, and the last more severe than the
+// first. Monthly averages only count timeouts of known length.
+func Summarise(actions []Action) *History {
+	sort.SliceStable(actions, func(i, j int) bool { return actions[i].Time.Before(actions[j].Time) })
+
+	h := &History{Channels: Channels{}, Periods: Periods{}, Actions: Actions(actions)}
+	channels := make(map[string]*Channel)
+	order := make(map[string][]Action)
+	periods := make(map[string]*Period)
+
+	for _, a := range actions {
+		key := a.Platform + "\x00" + a.Channel
+		ch, ok := channels[key]
+		if !ok {
+			ch = &Channel{Platform: a.Platform, Channel: a.Channel}
+			channels[key] = ch
+			h.Channels = append(h.Channels, ch)
+		}
+		order[key] = append(order[key], a)
+
+		period := "(unknown)"
+		if !a.Time.IsZero() {
+			t := a.Time
+			if ch.First == nil {
+				ch.First = &t
+			}
+			ch.Last = &t
+			period = t.UTC().Format("2006-01")
+		}
+		p, ok := periods[period]
+		if !ok {
+			p = &Period{Period: period}
+			periods[period] = p
+			h.Periods = append(h.Periods, p)
+		}
+
+		h.Totals.Actions++
+		if a.Ban {
+			ch.Bans++
+			p.Bans++
+			h.Totals.Bans++
+			continue
+		}
+		ch.Timeouts++
+		ch.TimeoutSeconds += a.Seconds
+		ch.LongestSeconds = max(ch.LongestSeconds, a.Seconds)
+		p.Timeouts++
+		p.TimeoutSeconds += a.Seconds
+		if a.Seconds > 0 {
+			p.timed++
+		}
+		h.Totals.Timeouts++
+		h.Totals.TimeoutSeconds += a.Seconds
+	}
+
+	for key, ch := range channels {
+		ch.Escalating = escalating(order[key])
+		if ch.Escalating {
+			h.Totals.Escalating++
+		}
+	}
+	h.Totals.Channels = len(channels)
+
+	for _, p := range h.Periods {
+		if p.timed > 0 {
+			p.AvgSeconds = p.TimeoutSeconds / int64(p.timed)
+		}
+	}
+	sort.SliceStable(h.Periods, func(i, j int) bool { return h.Periods[i].Period < h.Periods[j].Period })
+	sort.SliceStable(h.Channels, func(i, j int) bool {
+		a, b := h.Channels[i], h.Channels[j]
+		if a.Timeouts+a.Bans != b.Timeouts+b.Bans {
+			return a.Timeouts+a.Bans > b.Timeouts+b.Bans
+		}
+		if a.Platform != b.Platform {
+			return a.Platform < b.Platform
+		}
+		return a.Channel < b.Channel
+	})
+	return h
+}
+
+// escalating reports whether actions grew more severe. Timeouts of unknown
+// length are left out, since they cannot be ranked.
+func escalating(all []Action) bool {
+	var actions []Action
+	for _, a := range all {
+		if a.Ban || a.Seconds > 0 {
+			actions = append(actions, a)
+		}
+	}
+	if len(actions) < 2 {
+		return false
+	}
+	for i := 1; i < len(actions); i++ {
+		if actions[i].severity() < actions[i-1].severity() {
+			return false
+		}
+	}
+	return actions[len(actions)-1].severity() > actions[0].severity()
+}
+
+// FormatSeconds renders a duration in seconds compactly, e.g. 1d2h or 10m
+func FormatSeconds(seconds int64) string {
+	if seconds <= 0 {
+		return "0s"
+	}
+	units := []struct {
+		suffix string
+		size   int64
+	}{{"d", 86400}, {"h", 3600}, {"m", 60}, {"s", 1}}
+
+	var b strings.Builder
+	parts := 0
+	for _, u := range units {
+		if n := seconds / u.size; n > 0 && parts < 2 {
+			fmt.Fprintf(&b, "%d%s", n, u.suffix)
+			seconds -= n * u.size
+			parts++
+		} else if parts > 0 {
+			// Only show adjacent units so that 1d5s reads as 1d
+			break
+		}
+	}
+	return b.String()
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// Channels is the tabular form of the per-channel summary
+type Channels []*Channel
+
+// Columns implements output.Tabular
+func (c Channels) Columns() []string {
+	return []string{"PLATFORM", "CHANNEL", "TIMEOUTS", "BANS", "TIMEOUT_TOTAL", "LONGEST", "FIRST", "LAST", "ESCALATING"}
+}
+
+// Rows implements output.Tabular
+func (c Channels) Rows() [][]string {
+	rows := make([][]string, 0, len(c))
+	for _, ch := range c {
+		escalating := "no"
+		if ch.Escalating {
+			escalating = "yes"
+		}
+		rows = append(rows, []string{
+			ch.Platform,
+			ch.Channel,
+			strconv.Itoa(ch.Timeouts),
+			strconv.Itoa(ch.Bans),
+			FormatSeconds(ch.TimeoutSeconds),
+			FormatSeconds(ch.LongestSeconds),
+			formatTime(ch.First),
+			formatTime(ch.Last),
+			escalating,
+		})
+	}
+	return rows
+}
+
+// Periods is the tabular form of the monthly summary
+type Periods []*Period
+
+// Columns implements output.Tabular
+func (p Periods) Columns() []string {
+	return []string{"PERIOD", "TIMEOUTS", "BANS", "TIMEOUT_TOTAL", "AVERAGE"}
+}
+
+// Rows implements output.Tabular
+func (p Periods) Rows() [][]string {
+	rows := make([][]string, 0, len(p))
+	for _, period := range p {
+		rows = append(rows, []string{
+			period.Period,
+			strconv.Itoa(period.Timeouts),
+			strconv.Itoa(period.Bans),
+			FormatSeconds(period.TimeoutSeconds),
+			FormatSeconds(period.AvgSeconds),
+		})
+	}
+	return rows
+}
+
+// Actions is the tabular form of individual actions. Correlated messages
+// are joined into a single column.
+type Actions []Action
+
+// Columns implements output.Tabular
+func (a Actions) Columns() []string {
+	return []string{"TIME", "PLATFORM", "CHANNEL", "ACTION", "DURATION", "REASON", "LAST_MESSAGES"}
+}
+
+// Rows implements output.Tabular
+func (a Actions) Rows() [][]string {
+	rows := make([][]string, 0, len(a))
+	for _, action := range a {
+		t := ""
+		if !action.Time.IsZero() {
+			t = action.Time.UTC().Format(time.RFC3339)
+		}
+		kind, duration := "timeout", FormatSeconds(action.Seconds)
+		switch {
+		case action.Ban:
+			kind, duration = "ban", ""
+		case action.Seconds == 0:
+			duration = "unknown"
+		}
+		var context []string
+		for _, ev := range action.Context {
+			context = append(context, ev.Text)
+		}
+		rows = append(rows, []string{t, action.Platform, action.Channel, kind, duration, action.Reason, strings.Join(context, " | ")})
+	}
+	return rows
+}
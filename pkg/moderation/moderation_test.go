@@ -0,0 +1,134 @@
+package moderation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/records"
+)
+
+func TestLength(t *testing.T) {
+	tests := []struct {
+		name    string
+		rec     records.Record
+		seconds int64
+		ban     bool
+	}{
+		{"timeout", records.Record{"duration": 600}, 600, false},
+		{"timeout as string", records.Record{"length": "30"}, 30, false},
+		{"fractional seconds", records.Record{"seconds": 1.5}, 1, false},
+		{"zero duration is a ban", records.Record{"duration": 0}, 0, true},
+		{"null duration is a ban", records.Record{"duration": nil}, 0, true},
+		{"typed ban", records.Record{"type": "Ban", "duration": 600}, 0, true},
+		{"permanent ban action", records.Record{"action": "permanent_ban"}, 0, true},
+		{"missing duration", records.Record{"type": "timeout"}, 0, false},
+		{"unreadable duration", records.Record{"duration": "forever"}, 0, false},
+		{"negative duration", records.Record{"duration": -5}, 0, false},
+		{"unban", records.Record{"type": "unban", "duration": 0}, 0, false},
+	}
+	for _, tt := range tests {
+		seconds, ban := Length(tt.rec)
+		if seconds != tt.seconds || ban != tt.ban {
+			t.Errorf("%s: Length = (%d, %v), want (%d, %v)", tt.name, seconds, ban, tt.seconds, tt.ban)
+		}
+	}
+}
+
+func TestFromRecordsSkipsUnbans(t *testing.T) {
+	recs := []records.Record{
+		{"channel": "#Forsen", "duration": 600, "reason": "spam"},
+		{"channel": "forsen", "type": "unban"},
+		{"channel": "forsen", "action": "untimeout"},
+		{"duration": 60},
+	}
+	actions := FromRecords("twitch", recs)
+	if len(actions) != 2 {
+		t.Fatalf("FromRecords returned %d actions, want 2: %+v", len(actions), actions)
+	}
+	if actions[0].Channel != "forsen" || actions[0].Seconds != 600 || actions[0].Reason != "spam" {
+		t.Errorf("first action = %+v", actions[0])
+	}
+	if actions[1].Channel != "(unknown)" {
+		t.Errorf("action without channel = %q, want (unknown)", actions[1].Channel)
+	}
+}
+
+func TestEscalating(t *testing.T) {
+	timeout := func(seconds int64) Action { return Action{Seconds: seconds} }
+	ban := Action{Ban: true}
+	tests := []struct {
+		name    string
+		actions []Action
+		want    bool
+	}{
+		{"single action", []Action{timeout(60)}, false},
+		{"growing timeouts", []Action{timeout(60), timeout(600)}, true},
+		{"timeouts then ban", []Action{timeout(60), timeout(60), ban}, true},
+		{"equal timeouts", []Action{timeout(60), timeout(60)}, false},
+		{"shorter timeout", []Action{timeout(600), timeout(60), timeout(3600)}, false},
+		{"ban then timeout", []Action{ban, timeout(60)}, false},
+		{"repeated bans", []Action{ban, ban}, false},
+		{"unknown lengths are ignored", []Action{timeout(60), timeout(0), timeout(600)}, true},
+		{"only one known length", []Action{timeout(0), timeout(600)}, false},
+	}
+	for _, tt := range tests {
+		if got := escalating(tt.actions); got != tt.want {
+			t.Errorf("%s: escalating = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSummarise(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 12, 0, 0, 0, time.UTC) }
+	actions := []Action{
+		{Time: day(20), Platform: "twitch", Channel: "forsen", Ban: true},
+		{Time: day(1), Platform: "twitch", Channel: "forsen", Seconds: 60},
+		{Time: day(10), Platform: "twitch", Channel: "forsen", Seconds: 600},
+		{Time: day(5), Platform: "kick", Channel: "xqc"},
+		{Time: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), Platform: "kick", Channel: "xqc", Seconds: 300},
+		{Platform: "kick", Channel: "xqc", Seconds: 3000},
+	}
+	h := Summarise(actions)
+
+	want := Totals{Actions: 6, Timeouts: 5, Bans: 1, TimeoutSeconds: 3960, Channels: 2, Escalating: 1}
+	if h.Totals != want {
+		t.Errorf("Totals = %+v, want %+v", h.Totals, want)
+	}
+	if !h.Actions[0].Time.IsZero() || !h.Actions[1].Time.Equal(day(1)) {
+		t.Errorf("actions not sorted chronologically: %v", h.Actions.Rows())
+	}
+
+	if len(h.Channels) != 2 {
+		t.Fatalf("got %d channels, want 2", len(h.Channels))
+	}
+	for _, ch := range h.Channels {
+		switch ch.Channel {
+		case "forsen":
+			if ch.Timeouts != 2 || ch.Bans != 1 || ch.LongestSeconds != 600 || !ch.Escalating {
+				t.Errorf("forsen = %+v", *ch)
+			}
+			if !ch.First.Equal(day(1)) || !ch.Last.Equal(day(20)) {
+				t.Errorf("forsen first/last = %v/%v", ch.First, ch.Last)
+			}
+		case "xqc":
+			if ch.Timeouts != 3 || ch.TimeoutSeconds != 3300 || ch.Escalating {
+				t.Errorf("xqc = %+v", *ch)
+			}
+		}
+	}
+
+	// Averages only count timeouts of known length
+	periods := map[string]Period{}
+	for _, p := range h.Periods {
+		periods[p.Period] = *p
+	}
+	if p := periods["2024-03"]; p.Timeouts != 3 || p.Bans != 1 || p.TimeoutSeconds != 660 || p.AvgSeconds != 330 {
+		t.Errorf("2024-03 = %+v", p)
+	}
+	if p := periods["(unknown)"]; p.Timeouts != 1 || p.AvgSeconds != 3000 {
+		t.Errorf("(unknown) = %+v", p)
+	}
+	if h.Periods[0].Period != "(unknown)" || h.Periods[len(h.Periods)-1].Period != "2024-04" {
+		t.Errorf("periods not sorted: %v", h.Periods.Rows())
+	}
+}
@@ -0,0 +1,95 @@
+// Package notify delivers newly found records to external tooling through
+// webhooks, local commands or dropped files.
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/config"
+)
+
+// Event represents a single record pushed to a notifier. Delivery is at
+// least once: an event may be sent again after a crash or a failure of
+// another sink, so receivers should drop repeated IDs.
+type Event struct {
+	ID     string      `json:"id,omitempty"`
+	Source string      `json:"source"`
+	Time   time.Time   `json:"time"`
+	Record interface{} `json:"record"`
+}
+
+// Notifier is implemented by every notification sink
+type Notifier interface {
+	Notify(event Event) error
+	// String names the sink, so that deliveries can be tracked per sink
+	String() string
+}
+
+// New creates a notifier from its configuration
+func New(cfg config.NotifierConfig) (Notifier, error) {
+	switch cfg.Type {
+	case "webhook":
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("webhook notifier requires a url")
+		}
+		retries := -1
+		if cfg.Retries != nil {
+			retries = *cfg.Retries
+		}
+		return NewWebhook(cfg.URL, cfg.Secret, cfg.Headers, retries), nil
+	case "command":
+		if len(cfg.Command) == 0 {
+			return nil, fmt.Errorf("command notifier requires a command")
+		}
+		return NewCommand(cfg.Command), nil
+	case "file":
+		if cfg.Dir == "" {
+			return nil, fmt.Errorf("file notifier requires a dir")
+		}
+		return NewFileDrop(cfg.Dir), nil
+	default:
+		return nil, fmt.Errorf("unknown notifier type: %q", cfg.Type)
+	}
+}
+
+// Multi fans an event out to several notifiers
+type Multi []Notifier
+
+// FromProfile creates the notifiers configured for a profile
+func FromProfile(profile config.Profile) (Multi, error) {
+	var multi Multi
+	for i, cfg := range profile.Notifiers {
+		n, err := New(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("notifier %d: %w", i, err)
+		}
+		multi = append(multi, n)
+	}
+	return multi, nil
+}
+
+// Notify sends the event to every notifier, returning the combined errors
+func (m Multi) Notify(event Event) error {
+	return m.Deliver(event, map[string]bool{})
+}
+
+// Deliver sends the event to every notifier not named in delivered and adds
+// the ones that succeed, so that a retry after a partial failure skips the
+// sinks that already have the event. It returns the combined errors.
+func (m Multi) Deliver(event Event, delivered map[string]bool) error {
+	var errs []error
+	for _, n := range m {
+		name := n.String()
+		if delivered[name] {
+			continue
+		}
+		if err := n.Notify(event); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		delivered[name] = true
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,91 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Command runs a local command for every event with the event JSON on stdin
+type Command struct {
+	args []string
+}
+
+// NewCommand creates a command notifier from an argument vector
+func NewCommand(args []string) *Command {
+	return &Command{args: args}
+}
+
+// String implements Notifier
+func (c *Command) String() string {
+	return "command " + strings.Join(c.args, " ")
+}
+
+// Notify runs the command and waits for it to exit
+func (c *Command) Notify(event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	cmd := exec.Command(c.args[0], c.args[1:]...)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(), "LOLARCHIVER_SOURCE="+event.Source, "LOLARCHIVER_EVENT_ID="+event.ID)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg != "" {
+			return fmt.Errorf("command %s: %w: %s", c.args[0], err, msg)
+		}
+		return fmt.Errorf("command %s: %w", c.args[0], err)
+	}
+	return nil
+}
+
+// FileDrop writes every event as a separate JSON file into a directory
+type FileDrop struct {
+	dir string
+}
+
+// NewFileDrop creates a file drop notifier writing into dir
+func NewFileDrop(dir string) *FileDrop {
+	return &FileDrop{dir: dir}
+}
+
+// String implements Notifier
+func (f *FileDrop) String() string {
+	return "file " + f.dir
+}
+
+// Notify writes the event atomically so that consumers never see a partial
+// file
+func (f *FileDrop) Notify(event Event) error {
+	payload, err := json.MarshalIndent(event, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	if err := os.MkdirAll(f.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create drop directory: %w", err)
+	}
+
+	sum := sha256.Sum256(payload)
+	name := fmt.Sprintf("%s-%s.json", event.Time.UTC().Format("20060102T150405.000000000Z"), hex.EncodeToString(sum[:4]))
+	tmp := filepath.Join(f.dir, "."+name+".tmp")
+	if err := os.WriteFile(tmp, payload, 0600); err != nil {
+		return fmt.Errorf("failed to write event file: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(f.dir, name)); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to move event file: %w", err)
+	}
+	return nil
+}
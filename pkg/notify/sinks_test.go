@@ -0,0 +1,122 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommand(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	out := filepath.Join(t.TempDir(), "event.json")
+	c := NewCommand([]string{"sh", "-c", `cat > "$0"; echo >> "$0"; echo "$LOLARCHIVER_SOURCE $LOLARCHIVER_EVENT_ID" >> "$0"`, out})
+
+	event := Event{ID: "watch:m1", Source: "watch", Record: map[string]interface{}{"id": "m1"}}
+	if err := c.Notify(event); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	payload, env, _ := strings.Cut(string(data), "\n")
+	var got Event
+	if err := json.Unmarshal([]byte(payload), &got); err != nil {
+		t.Fatalf("stdin is not an event: %v", err)
+	}
+	if got.ID != "watch:m1" || got.Source != "watch" {
+		t.Errorf("event = %+v, want ID watch:m1 from watch", got)
+	}
+	if env != "watch watch:m1\n" {
+		t.Errorf("environment = %q, want source and event ID", env)
+	}
+}
+
+func TestCommandFailure(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	err := NewCommand([]string{"sh", "-c", "echo boom >&2; exit 3"}).Notify(Event{Source: "test"})
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("Notify error = %v, want one carrying stderr", err)
+	}
+}
+
+func TestFileDrop(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "drop")
+	f := NewFileDrop(dir)
+	event := Event{ID: "watch:m1", Source: "watch", Time: time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC)}
+
+	// Dropping the same event twice leaves a single file
+	for i := 0; i < 2; i++ {
+		if err := f.Notify(event); err != nil {
+			t.Fatalf("Notify: %v", err)
+		}
+	}
+	if err := f.Notify(Event{ID: "watch:m2", Source: "watch", Time: event.Time}); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("drop directory holds %d files, want 2", len(entries))
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") || !strings.HasPrefix(entry.Name(), "20250102T100000") {
+			t.Errorf("unexpected file %s", entry.Name())
+		}
+	}
+}
+
+// fakeNotifier records the events it receives and fails while err is set
+type fakeNotifier struct {
+	name   string
+	err    error
+	events []Event
+}
+
+func (f *fakeNotifier) Notify(event Event) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.events = append(f.events, event)
+	return nil
+}
+
+func (f *fakeNotifier) String() string {
+	return f.name
+}
+
+func TestMultiDeliver(t *testing.T) {
+	ok := &fakeNotifier{name: "ok"}
+	flaky := &fakeNotifier{name: "flaky", err: errors.New("down")}
+	m := Multi{ok, flaky}
+
+	delivered := map[string]bool{}
+	if err := m.Deliver(Event{ID: "e1"}, delivered); err == nil {
+		t.Fatal("Deliver succeeded with a failing sink")
+	}
+	if !delivered["ok"] || delivered["flaky"] {
+		t.Errorf("delivered = %v, want only ok", delivered)
+	}
+
+	// The retry only goes to the sink that failed
+	flaky.err = nil
+	if err := m.Deliver(Event{ID: "e1"}, delivered); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+	if len(ok.events) != 1 || len(flaky.events) != 1 {
+		t.Errorf("ok got %d event(s), flaky %d; want 1 each", len(ok.events), len(flaky.events))
+	}
+}
@@ -0,0 +1,111 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	// SignatureHeader carries the HMAC-SHA256 of the payload when a secret
+	// is configured
+	SignatureHeader = "X-Lolarchiver-Signature"
+
+	defaultRetries = 3
+	defaultBackoff = time.Second
+)
+
+// Webhook posts events as JSON to an HTTP endpoint
+type Webhook struct {
+	url     string
+	secret  string
+	headers map[string]string
+	retries int
+	backoff time.Duration
+	client  *http.Client
+}
+
+// NewWebhook creates a webhook notifier. A negative retries value selects
+// the default number of retries, and zero disables them.
+func NewWebhook(url, secret string, headers map[string]string, retries int) *Webhook {
+	if retries < 0 {
+		retries = defaultRetries
+	}
+	return &Webhook{
+		url:     url,
+		secret:  secret,
+		headers: headers,
+		retries: retries,
+		backoff: defaultBackoff,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// String implements Notifier
+func (w *Webhook) String() string {
+	return "webhook " + w.url
+}
+
+// Sign returns the signature header value for payload
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Notify posts the event, retrying with exponential backoff on network
+// errors, 429 and 5xx responses
+func (w *Webhook) Notify(event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	backoff := w.backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := w.post(payload)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= w.retries {
+			return fmt.Errorf("webhook %s: %w", w.url, err)
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// post performs one delivery attempt and reports whether it may be retried
+func (w *Webhook) post(payload []byte) (bool, error) {
+	req, err := http.NewRequest("POST", w.url, bytes.NewReader(payload))
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if w.secret != "" {
+		req.Header.Set(SignatureHeader, Sign(w.secret, payload))
+	}
+	for key, value := range w.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("failed to perform request: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("unexpected response (Status %d)", resp.StatusCode)
+}
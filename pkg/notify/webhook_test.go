@@ -0,0 +1,140 @@
+package notify
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestWebhook(url, secret string, headers map[string]string, retries int) *Webhook {
+	w := NewWebhook(url, secret, headers, retries)
+	w.backoff = time.Millisecond
+	return w
+}
+
+func TestWebhookPayload(t *testing.T) {
+	var got Event
+	var contentType, custom string
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("method = %s, want POST", r.Method)
+		}
+		contentType = r.Header.Get("Content-Type")
+		custom = r.Header.Get("X-Custom")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("failed to decode payload: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	when := time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC)
+	event := Event{Source: "twitch-messages-bob", Time: when, Record: map[string]interface{}{"id": "m1"}}
+	w := newTestWebhook(srv.URL, "", map[string]string{"X-Custom": "yes"}, 0)
+	if err := w.Notify(event); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	if contentType != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", contentType)
+	}
+	if custom != "yes" {
+		t.Errorf("X-Custom = %q, want yes", custom)
+	}
+	if got.Source != event.Source || !got.Time.Equal(when) {
+		t.Errorf("payload = %+v, want source %q at %v", got, event.Source, when)
+	}
+	if rec, ok := got.Record.(map[string]interface{}); !ok || rec["id"] != "m1" {
+		t.Errorf("record = %v, want id m1", got.Record)
+	}
+}
+
+func TestWebhookSignature(t *testing.T) {
+	var body []byte
+	var signature string
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get(SignatureHeader)
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	if err := newTestWebhook(srv.URL, "s3cret", nil, 0).Notify(Event{Source: "test"}); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if want := Sign("s3cret", body); signature != want {
+		t.Errorf("signature = %q, want %q", signature, want)
+	}
+
+	if err := newTestWebhook(srv.URL, "", nil, 0).Notify(Event{Source: "test"}); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if signature != "" {
+		t.Errorf("signature = %q without a secret, want none", signature)
+	}
+}
+
+func TestWebhookRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		retries  int
+		wantErr  bool
+		wantHits int32
+	}{
+		{"success", []int{200}, 3, false, 1},
+		{"recovers after 5xx", []int{503, 500, 200}, 3, false, 3},
+		{"recovers after 429", []int{429, 204}, 3, false, 2},
+		{"gives up after retries", []int{500, 500, 500, 500, 500}, 2, true, 3},
+		{"no retry on 4xx", []int{400, 200}, 3, true, 1},
+		{"retries disabled", []int{500, 200}, 0, true, 1},
+		{"default retries", []int{500, 500, 500, 500, 500}, -1, true, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				n := hits.Add(1)
+				rw.WriteHeader(tt.statuses[min(int(n)-1, len(tt.statuses)-1)])
+			}))
+			defer srv.Close()
+
+			err := newTestWebhook(srv.URL, "", nil, tt.retries).Notify(Event{Source: "test"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Notify error = %v, want error %v", err, tt.wantErr)
+			}
+			if got := hits.Load(); got != tt.wantHits {
+				t.Errorf("requests = %d, want %d", got, tt.wantHits)
+			}
+		})
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	var times []time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		times = append(times, time.Now())
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	w := NewWebhook(srv.URL, "", nil, 2)
+	w.backoff = 20 * time.Millisecond
+	if err := w.Notify(Event{Source: "test"}); err == nil {
+		t.Fatal("Notify succeeded, want error")
+	}
+
+	if len(times) != 3 {
+		t.Fatalf("requests = %d, want 3", len(times))
+	}
+	// The second wait doubles the first
+	if gap := times[1].Sub(times[0]); gap < 20*time.Millisecond {
+		t.Errorf("first retry after %v, want at least 20ms", gap)
+	}
+	if gap := times[2].Sub(times[1]); gap < 40*time.Millisecond {
+		t.Errorf("second retry after %v, want at least 40ms", gap)
+	}
+}
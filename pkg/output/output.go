@@ -0,0 +1,116 @@
+// Package output renders command results as JSON, JSON lines, CSV or an
+// aligned text table.
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+)
+
+// Supported formats
+const (
+	JSON  = "json"
+	JSONL = "jsonl"
+	CSV   = "csv"
+	Table = "table"
+)
+
+// Formats lists every supported format
+var Formats = []string{JSON, JSONL, CSV, Table}
+
+// Tabular is implemented by results that can be rendered as rows
+type Tabular interface {
+	Columns() []string
+	Rows() [][]string
+}
+
+// ParseFormat validates a format name
+func ParseFormat(name string) (string, error) {
+	for _, f := range Formats {
+		if name == f {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unknown output format %q (expected %s)", name, strings.Join(Formats, ", "))
+}
+
+// Write renders v in the given format. CSV and table output require v to
+// implement Tabular.
+func Write(w io.Writer, format string, v interface{}) error {
+	switch format {
+	case JSON:
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal output: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case JSONL:
+		return writeLines(w, v)
+	case CSV, Table:
+		t, ok := v.(Tabular)
+		if !ok {
+			return fmt.Errorf("%s output is not supported for this command", format)
+		}
+		if format == CSV {
+			return writeCSV(w, t)
+		}
+		return writeTable(w, t)
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+func writeLines(w io.Writer, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("failed to marshal output: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	}
+
+	for i := 0; i < rv.Len(); i++ {
+		data, err := json.Marshal(rv.Index(i).Interface())
+		if err != nil {
+			return fmt.Errorf("failed to marshal output: %w", err)
+		}
+		if _, err := fmt.Fprintln(w, string(data)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeCSV(w io.Writer, t Tabular) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(t.Columns()); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(t.Rows()); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeTable(w io.Writer, t Tabular) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(t.Columns(), "\t"))
+	for _, row := range t.Rows() {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			// Keep each row on one line
+			cells[i] = strings.Join(strings.Fields(cell), " ")
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
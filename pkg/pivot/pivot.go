@@ -0,0 +1,122 @@
+// Package pivot extracts identifiers from lookup results that are worth
+// querying next: email addresses, phone numbers and usernames.
+package pivot
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/api"
+	"github.com/ivan9253/lolarchiver-cli/pkg/breach"
+	"github.com/ivan9253/lolarchiver-cli/pkg/records"
+)
+
+// Kinds of identifier
+const (
+	Email    = "email"
+	Phone    = "phone"
+	Username = "username"
+)
+
+// Candidate is an identifier found in a result
+type Candidate struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+	Field string `json:"field"`
+}
+
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+// Extract returns the distinct identifiers found in recs, record by record
+// with fields in name order. Emails and phone numbers are normalised (phones
+// without a country code are read in region) and invalid values are
+// dropped. Values equal to one of exclude, typically the original query,
+// are skipped.
+func Extract(recs []records.Record, region string, exclude ...string) []Candidate {
+	e := &extractor{region: region, seen: make(map[string]bool)}
+	for _, x := range exclude {
+		for _, kind := range []string{Email, Phone, Username} {
+			if value, ok := e.normalise(kind, x); ok {
+				e.seen[kind+":"+strings.ToLower(value)] = true
+			}
+		}
+	}
+	for _, rec := range recs {
+		e.walk("", map[string]interface{}(rec))
+	}
+	return e.found
+}
+
+type extractor struct {
+	region string
+	seen   map[string]bool
+	found  []Candidate
+}
+
+func (e *extractor) walk(field string, v interface{}) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for key := range t {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			e.walk(key, t[key])
+		}
+	case []interface{}:
+		for _, child := range t {
+			e.walk(field, child)
+		}
+	case string:
+		switch breach.Category(field) {
+		case breach.Email:
+			e.add(Email, field, t)
+		case breach.Phone:
+			e.add(Phone, field, t)
+		case breach.Username:
+			e.add(Username, field, t)
+		default:
+			for _, m := range emailPattern.FindAllString(t, -1) {
+				e.add(Email, field, m)
+			}
+		}
+	case json.Number:
+		if breach.Category(field) == breach.Phone {
+			e.add(Phone, field, t.String())
+		}
+	}
+}
+
+func (e *extractor) add(kind, field, raw string) {
+	value, ok := e.normalise(kind, raw)
+	if !ok {
+		return
+	}
+	key := kind + ":" + strings.ToLower(value)
+	if e.seen[key] {
+		return
+	}
+	e.seen[key] = true
+	e.found = append(e.found, Candidate{Kind: kind, Value: value, Field: field})
+}
+
+func (e *extractor) normalise(kind, raw string) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	switch kind {
+	case Email:
+		v, err := api.NormalizeEmail(raw)
+		return v, err == nil
+	case Phone:
+		v, err := api.NormalizePhone(raw, e.region)
+		return v, err == nil
+	default:
+		v := strings.TrimPrefix(raw, "@")
+		if v == "" || len(v) > 64 || strings.ContainsAny(v, " \t@") {
+			return "", false
+		}
+		return v, true
+	}
+}
@@ -0,0 +1,82 @@
+package pivot
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/records"
+)
+
+func TestExtract(t *testing.T) {
+	recs := []records.Record{
+		{
+			"Email_Address": "Alice@Example.com",
+			"mobile":        "020 7946 0018",
+			"nickname":      "@alice",
+			"note":          "also bob@example.org, see alice@example.com",
+			"password":      "hunter2@example.net",
+		},
+		{
+			// Nested fields keep their own name, arrays the name of the field
+			"profile": map[string]interface{}{
+				"phones": []interface{}{json.Number("14155550100"), "not a number"},
+				"email":  "not an email",
+				"login":  "has space",
+			},
+		},
+	}
+
+	got := Extract(recs, "GB")
+	want := []Candidate{
+		{Kind: Email, Value: "alice@example.com", Field: "Email_Address"},
+		{Kind: Phone, Value: "+442079460018", Field: "mobile"},
+		{Kind: Username, Value: "alice", Field: "nickname"},
+		{Kind: Email, Value: "bob@example.org", Field: "note"},
+		// Passwords are scanned like free text
+		{Kind: Email, Value: "hunter2@example.net", Field: "password"},
+		{Kind: Phone, Value: "+4414155550100", Field: "phones"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Extract =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestExtractRegion(t *testing.T) {
+	recs := []records.Record{{"phone": "(415) 555-0100"}}
+
+	if got := Extract(recs, "US"); len(got) != 1 || got[0].Value != "+14155550100" {
+		t.Errorf("Extract in US = %+v, want +14155550100", got)
+	}
+	// Without a region the number is kept without a country code
+	if got := Extract(recs, ""); len(got) != 1 || got[0].Value != "4155550100" {
+		t.Errorf("Extract without region = %+v, want 4155550100", got)
+	}
+}
+
+func TestExtractExcludesQuery(t *testing.T) {
+	recs := []records.Record{{
+		"email":    "ALICE@example.com",
+		"phone":    "+1 415 555 0100",
+		"username": "alice",
+		"alt":      "carol@example.com",
+	}}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"alice@example.com", []string{"carol@example.com", "+14155550100", "alice"}},
+		{"(415) 555-0100", []string{"carol@example.com", "alice@example.com", "alice"}},
+		{"@Alice", []string{"carol@example.com", "alice@example.com", "+14155550100"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, c := range Extract(recs, "US", tt.query) {
+			got = append(got, c.Value)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Extract excluding %q = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+// Package records provides helpers for working with the loosely typed JSON
+// returned by the LoL Archiver API.
+package records
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Record represents a single decoded object from an API response
+type Record map[string]interface{}
+
+// IDKeys are the field names checked, in order, when identifying a record
+var IDKeys = []string{"id", "message_id", "msg_id", "comment_id", "_id"}
+
+// TimeKeys are the field names checked, in order, when looking for a timestamp
+var TimeKeys = []string{"timestamp", "time", "date", "created_at", "sent_at", "published_at", "datetime"}
+
+// listKeys are preferred container fields when a response wraps its records
+var listKeys = []string{"data", "results", "messages", "comments", "timeouts", "replies", "items"}
+
+// timeLayouts are the textual timestamp formats understood by Time
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04:05.000000",
+	"2006-01-02",
+}
+
+// Extract decodes an API response body and returns the records it contains.
+// A top-level array yields one record per element, an object wrapping an
+// array of objects yields the wrapped elements, and any other object is
+// returned as a single record.
+func Extract(body []byte) ([]Record, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 || bytes.Equal(body, []byte("null")) {
+		return nil, nil
+	}
+
+	var v interface{}
+	if err := Decode(body, &v); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	switch t := v.(type) {
+	case []interface{}:
+		return fromList(t), nil
+	case map[string]interface{}:
+		for _, key := range listKeys {
+			if list, ok := t[key].([]interface{}); ok {
+				return fromList(list), nil
+			}
+		}
+		keys := make([]string, 0, len(t))
+		for key := range t {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if list, ok := t[key].([]interface{}); ok && len(list) > 0 {
+				if _, isObj := list[0].(map[string]interface{}); isObj {
+					return fromList(list), nil
+				}
+			}
+		}
+		return []Record{Record(t)}, nil
+	default:
+		return []Record{{"value": t}}, nil
+	}
+}
+
+// Decode unmarshals JSON keeping numbers as json.Number so that large IDs
+// survive a round trip unchanged
+func Decode(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+func fromList(list []interface{}) []Record {
+	recs := make([]Record, 0, len(list))
+	for _, item := range list {
+		if obj, ok := item.(map[string]interface{}); ok {
+			recs = append(recs, Record(obj))
+		} else {
+			recs = append(recs, Record{"value": item})
+		}
+	}
+	return recs
+}
+
+// String returns the first non-empty field among keys, formatted as a string
+func (r Record) String(keys ...string) string {
+	for _, key := range keys {
+		v, ok := r[key]
+		if !ok || v == nil {
+			continue
+		}
+		var s string
+		switch t := v.(type) {
+		case string:
+			s = t
+		case json.Number:
+			s = t.String()
+		case map[string]interface{}, []interface{}:
+			data, _ := json.Marshal(t)
+			s = string(data)
+		default:
+			s = fmt.Sprint(t)
+		}
+		if s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// Time returns the first field among keys that parses as a timestamp.
+// Numeric values are treated as Unix seconds, or milliseconds when large;
+// zero and negative values mean unknown.
+func (r Record) Time(keys ...string) (time.Time, bool) {
+	for _, key := range keys {
+		if t, ok := ParseTime(r[key]); ok {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// ParseTime interprets a decoded JSON value as a timestamp
+func ParseTime(v interface{}) (time.Time, bool) {
+	var s string
+	switch t := v.(type) {
+	case string:
+		s = strings.TrimSpace(t)
+	case json.Number:
+		s = t.String()
+	case float64:
+		s = strconv.FormatFloat(t, 'f', -1, 64)
+	default:
+		return time.Time{}, false
+	}
+	if s == "" {
+		return time.Time{}, false
+	}
+
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		// APIs fill unknown dates with 0
+		if n <= 0 {
+			return time.Time{}, false
+		}
+		if n > 1e12 {
+			return time.UnixMilli(int64(n)).UTC(), true
+		}
+		return time.Unix(int64(n), 0).UTC(), true
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil && !t.IsZero() {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Key returns a stable identity for the record: its ID field when present,
+// otherwise a hash of its canonical JSON encoding
+func (r Record) Key() string {
+	if id := r.String(IDKeys...); id != "" {
+		return id
+	}
+	return r.Hash()
+}
+
+// Hash returns the hex SHA-256 of the record's canonical JSON encoding
+func (r Record) Hash() string {
+	data, _ := json.Marshal(r)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
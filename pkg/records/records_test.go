@@ -0,0 +1,118 @@
+package records
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []Record
+	}{
+		{"empty", "", nil},
+		{"null", " null ", nil},
+		{"bare array", `[{"id": 1}, {"id": 2}]`, []Record{{"id": json.Number("1")}, {"id": json.Number("2")}}},
+		{"array of scalars", `["a", 2]`, []Record{{"value": "a"}, {"value": json.Number("2")}}},
+		{"preferred wrapper", `{"count": 1, "results": [{"id": 1}], "other": [{"id": 2}]}`, []Record{{"id": json.Number("1")}}},
+		{"empty preferred wrapper", `{"data": [], "other": [{"id": 2}]}`, []Record{}},
+		{"any wrapped objects", `{"total": 2, "list": [{"id": 1}]}`, []Record{{"id": json.Number("1")}}},
+		{"wrapped scalars", `{"tags": ["a", "b"]}`, []Record{{"tags": []interface{}{"a", "b"}}}},
+		{"single object", `{"id": 1, "name": "foo"}`, []Record{{"id": json.Number("1"), "name": "foo"}}},
+		{"scalar", `"text"`, []Record{{"value": "text"}}},
+		{"number", `12345678901234567890`, []Record{{"value": json.Number("12345678901234567890")}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Extract([]byte(tt.body))
+			if err != nil {
+				t.Fatalf("Extract: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Extract = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := Extract([]byte(`{"a": `)); err == nil {
+		t.Errorf("Extract of invalid JSON returned no error")
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 30, 45, 0, time.UTC)
+	tests := []struct {
+		name string
+		v    interface{}
+		want time.Time
+		ok   bool
+	}{
+		{"seconds", json.Number("1709296245"), at, true},
+		{"seconds as text", "1709296245", at, true},
+		{"seconds as float", float64(1709296245), at, true},
+		{"milliseconds", json.Number("1709296245000"), at, true},
+		{"zero", json.Number("0"), time.Time{}, false},
+		{"zero as text", "0", time.Time{}, false},
+		{"negative", float64(-1), time.Time{}, false},
+		{"RFC 3339", "2024-03-01T12:30:45Z", at, true},
+		{"RFC 3339 with offset", "2024-03-01T14:30:45+02:00", at, true},
+		{"RFC 3339 with fraction", "2024-03-01T12:30:45.5Z", at.Add(500 * time.Millisecond), true},
+		{"no zone", "2024-03-01T12:30:45", at, true},
+		{"space", "2024-03-01 12:30:45", at, true},
+		{"microseconds", "2024-03-01 12:30:45.000000", at, true},
+		{"date", " 2024-03-01 ", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), true},
+		{"zero date", "0001-01-01T00:00:00Z", time.Time{}, false},
+		{"empty", "", time.Time{}, false},
+		{"text", "yesterday", time.Time{}, false},
+		{"nil", nil, time.Time{}, false},
+		{"bool", true, time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseTime(tt.v)
+			if ok != tt.ok || !got.Equal(tt.want) {
+				t.Errorf("ParseTime(%v) = %v, %v; want %v, %v", tt.v, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestRecordTime(t *testing.T) {
+	rec := Record{"timestamp": json.Number("0"), "date": "2024-03-01"}
+	got, ok := rec.Time(TimeKeys...)
+	if !ok || !got.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Time = %v, %v; want the date after the unknown timestamp", got, ok)
+	}
+}
+
+func TestKey(t *testing.T) {
+	a, _ := Extract([]byte(`{"b": 2, "a": {"y": 1, "x": [1, 2]}}`))
+	b, _ := Extract([]byte(`{"a": {"x": [1, 2], "y": 1}, "b": 2}`))
+	c, _ := Extract([]byte(`{"a": {"x": [2, 1], "y": 1}, "b": 2}`))
+
+	// Field order does not change the hash, values do
+	if a[0].Hash() != b[0].Hash() {
+		t.Errorf("Hash depends on field order: %s, %s", a[0].Hash(), b[0].Hash())
+	}
+	if a[0].Hash() == c[0].Hash() {
+		t.Errorf("Hash is the same for different values")
+	}
+	if a[0].Key() != a[0].Hash() {
+		t.Errorf("Key without an ID = %s, want the hash", a[0].Key())
+	}
+
+	// Large IDs keep every digit
+	d, _ := Extract([]byte(`{"message_id": 12345678901234567890, "text": "a"}`))
+	e, _ := Extract([]byte(`{"message_id": 12345678901234567890, "text": "edited"}`))
+	if d[0].Key() != "12345678901234567890" || d[0].Key() != e[0].Key() {
+		t.Errorf("Key = %s and %s, want the ID", d[0].Key(), e[0].Key())
+	}
+	if (Record{"id": ""}).Key() == "" {
+		t.Errorf("Key of an empty ID is empty, want the hash")
+	}
+}
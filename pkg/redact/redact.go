@@ -0,0 +1,310 @@
+// Package redact masks personal data (emails, phone numbers, passwords and
+// IP addresses) in decoded API responses, by field name and by pattern.
+package redact
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/config"
+	"github.com/ivan9253/lolarchiver-cli/pkg/fields"
+)
+
+// Masking styles
+const (
+	// Full replaces the value with a fixed placeholder
+	Full = "full"
+	// Partial keeps just enough of the value to tell records apart
+	Partial = "partial"
+	// Hashed replaces the value with a short HMAC-SHA-256 keyed per install,
+	// so equal values stay equal across outputs without being recoverable
+	// by hashing guesses
+	Hashed = "hashed"
+)
+
+// keyFile holds the key of the hashed style, next to the config file
+const keyFile = "redact.key"
+
+// Styles lists every masking style
+var Styles = []string{Full, Partial, Hashed}
+
+// Kinds of personal data
+const (
+	Email    = "email"
+	Phone    = "phone"
+	Password = "password"
+	IP       = "ip"
+)
+
+// fieldKinds maps the kinds of pkg/fields to the kinds masked here.
+// Usernames and names are not treated as personal data.
+var fieldKinds = map[string]string{
+	fields.Email:    Email,
+	fields.Phone:    Phone,
+	fields.Password: Password,
+	fields.Hash:     Password,
+	fields.IP:       IP,
+}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	ipv4Pattern  = regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4]\d|1?\d?\d)\.){3}(?:25[0-5]|2[0-4]\d|1?\d?\d)\b`)
+	// Candidates, including compressed forms such as 2001:db8::1, are
+	// confirmed with net.ParseIP so that times like 12:30:45 are left alone
+	ipv6Pattern = regexp.MustCompile(`\b[0-9A-Fa-f]{1,4}(?:::?[0-9A-Fa-f]{0,4}){1,7}`)
+	// Only international (+...) and formatted NANP numbers are matched, so
+	// that dates and numeric IDs are left alone
+	phonePattern = regexp.MustCompile(`\+\d[\d\s().\-]{5,}\d|\(?\b\d{3}\)?[\s.\-]\d{3}[\s.\-]\d{4}\b`)
+)
+
+// Redactor masks personal data using a single style
+type Redactor struct {
+	style string
+	key   []byte
+}
+
+// New creates a redactor for the given style. The hashed style needs the
+// key returned by LoadKey; the other styles ignore it.
+func New(style string, key []byte) (*Redactor, error) {
+	for _, s := range Styles {
+		if style != s {
+			continue
+		}
+		if style == Hashed && len(key) == 0 {
+			return nil, fmt.Errorf("the %s redaction style needs a key", Hashed)
+		}
+		return &Redactor{style: style, key: key}, nil
+	}
+	return nil, fmt.Errorf("unknown redaction style %q (expected %s)", style, strings.Join(Styles, ", "))
+}
+
+// LoadKey returns the key of the hashed style stored in dir, creating a
+// random one on first use
+func LoadKey(dir string) ([]byte, error) {
+	key, err := config.LoadKey(filepath.Join(dir, keyFile))
+	if err != nil {
+		return nil, fmt.Errorf("redaction key: %w", err)
+	}
+	return key, nil
+}
+
+// KindOf returns the kind of personal data a field name denotes, or ""
+func KindOf(field string) string {
+	return fieldKinds[fields.KindOf(field)]
+}
+
+// Value returns a copy of a decoded JSON value with personal data masked.
+// Fields whose name denotes personal data are masked entirely, including
+// everything nested below them unless a nested field names another kind;
+// other strings are scanned for patterns.
+func (r *Redactor) Value(v interface{}) interface{} {
+	return r.value("", v)
+}
+
+func (r *Redactor) value(kind string, v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for key, child := range t {
+			childKind := KindOf(key)
+			if childKind == "" {
+				childKind = kind
+			}
+			out[key] = r.value(childKind, child)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, child := range t {
+			out[i] = r.value(kind, child)
+		}
+		return out
+	case string:
+		return r.Field(kind, t)
+	case json.Number:
+		if kind != "" {
+			return r.Mask(kind, t.String())
+		}
+		return t
+	case float64:
+		if kind != "" {
+			return r.Mask(kind, fmt.Sprint(t))
+		}
+		return t
+	default:
+		return t
+	}
+}
+
+// Field masks a string value held in a field of the given kind, falling
+// back to pattern matching when the kind is unknown
+func (r *Redactor) Field(kind, s string) string {
+	if kind != "" {
+		if s == "" {
+			return s
+		}
+		return r.Mask(kind, s)
+	}
+	return r.String(s)
+}
+
+// String masks every email, IP address and phone number found in s
+func (r *Redactor) String(s string) string {
+	s = emailPattern.ReplaceAllStringFunc(s, func(m string) string { return r.Mask(Email, m) })
+	s = ipv4Pattern.ReplaceAllStringFunc(s, func(m string) string { return r.Mask(IP, m) })
+	s = ipv6Pattern.ReplaceAllStringFunc(s, func(m string) string {
+		if net.ParseIP(m) == nil {
+			return m
+		}
+		return r.Mask(IP, m)
+	})
+	s = phonePattern.ReplaceAllStringFunc(s, func(m string) string {
+		if digits := countDigits(m); digits < 7 || digits > 15 {
+			return m
+		}
+		return r.Mask(Phone, m)
+	})
+	return s
+}
+
+// Placeholder replaces values that cannot be redacted field by field
+const Placeholder = "[REDACTED]"
+
+// JSON redacts a JSON document. A body that does not parse cannot be
+// masked by field name, so it is replaced by Placeholder as a JSON string.
+func (r *Redactor) JSON(body []byte) []byte {
+	placeholder, _ := json.Marshal(Placeholder)
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return placeholder
+	}
+	data, err := json.Marshal(r.Value(v))
+	if err != nil {
+		return placeholder
+	}
+	return data
+}
+
+// Mask masks a single value of the given kind
+func (r *Redactor) Mask(kind, s string) string {
+	switch r.style {
+	case Hashed:
+		mac := hmac.New(sha256.New, r.key)
+		mac.Write([]byte(strings.ToLower(strings.TrimSpace(s))))
+		return kind + ":" + hex.EncodeToString(mac.Sum(nil))[:12]
+	case Partial:
+		return partial(kind, s)
+	default:
+		return "[REDACTED " + kind + "]"
+	}
+}
+
+func partial(kind, s string) string {
+	switch kind {
+	case Email:
+		local, domain, ok := strings.Cut(s, "@")
+		if !ok {
+			return stars(s, 1, 0)
+		}
+		name, tld := domain, ""
+		if i := strings.LastIndex(domain, "."); i > 0 {
+			name, tld = domain[:i], domain[i:]
+		}
+		return stars(local, 1, 0) + "@" + stars(name, 1, 0) + tld
+	case Phone:
+		// Keep the last two digits
+		digits := 0
+		out := []rune(s)
+		for i := len(out) - 1; i >= 0; i-- {
+			if out[i] >= '0' && out[i] <= '9' {
+				digits++
+				if digits > 2 {
+					out[i] = '*'
+				}
+			}
+		}
+		return string(out)
+	case IP:
+		if ip := net.ParseIP(s); ip != nil && ip.To4() != nil {
+			parts := strings.Split(s, ".")
+			return parts[0] + "." + parts[1] + ".*.*"
+		}
+		if i := strings.Index(s, ":"); i > 0 {
+			return s[:i] + ":****"
+		}
+		return "****"
+	default:
+		// Never reveal anything about secrets, not even their length
+		return "********"
+	}
+}
+
+// stars replaces all but the first keep and last tail characters with '*'
+func stars(s string, keep, tail int) string {
+	r := []rune(s)
+	if len(r) <= keep+tail {
+		return strings.Repeat("*", len(r))
+	}
+	return string(r[:keep]) + strings.Repeat("*", len(r)-keep-tail) + string(r[len(r)-tail:])
+}
+
+func countDigits(s string) int {
+	n := 0
+	for _, c := range s {
+		if c >= '0' && c <= '9' {
+			n++
+		}
+	}
+	return n
+}
+
+// tabular matches output.Tabular without importing it
+type tabular interface {
+	Columns() []string
+	Rows() [][]string
+}
+
+// Table wraps tabular output so that every cell is masked, using the
+// column name as the field name
+type Table struct {
+	r *Redactor
+	t tabular
+}
+
+// Table returns a masked view of t
+func (r *Redactor) Table(t tabular) Table {
+	return Table{r: r, t: t}
+}
+
+// Columns implements output.Tabular
+func (t Table) Columns() []string {
+	return t.t.Columns()
+}
+
+// Rows implements output.Tabular
+func (t Table) Rows() [][]string {
+	cols := t.t.Columns()
+	rows := t.t.Rows()
+	out := make([][]string, len(rows))
+	for i, row := range rows {
+		out[i] = make([]string, len(row))
+		for j, cell := range row {
+			kind := ""
+			if j < len(cols) {
+				kind = KindOf(cols[j])
+			}
+			out[i][j] = t.r.Field(kind, cell)
+		}
+	}
+	return out
+}
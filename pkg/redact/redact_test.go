@@ -0,0 +1,181 @@
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+func newRedactor(t *testing.T, style string) *Redactor {
+	t.Helper()
+	r, err := New(style, testKey)
+	if err != nil {
+		t.Fatalf("New(%q): %v", style, err)
+	}
+	return r
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New("sparkly", nil); err == nil {
+		t.Errorf("New accepted an unknown style")
+	}
+	if _, err := New(Hashed, nil); err == nil {
+		t.Errorf("New accepted the hashed style without a key")
+	}
+	if _, err := New(Full, nil); err != nil {
+		t.Errorf("New(full) without a key: %v", err)
+	}
+}
+
+func TestStringPatterns(t *testing.T) {
+	r := newRedactor(t, Full)
+	tests := []struct {
+		in, want string
+	}{
+		{"mail john.doe+x@example.co.uk now", "mail [REDACTED email] now"},
+		{"from 192.168.1.20", "from [REDACTED ip]"},
+		{"not an ip 999.1.1.1", "not an ip 999.1.1.1"},
+		{"v6 2001:0db8:85a3:0000:0000:8a2e:0370:7334", "v6 [REDACTED ip]"},
+		{"v6 fe80::1 and 2001:db8::", "v6 [REDACTED ip] and [REDACTED ip]"},
+		{"time 12:30:45", "time 12:30:45"},
+		{"mac aa:bb:cc:dd:ee:ff", "mac aa:bb:cc:dd:ee:ff"},
+		{"call +44 20 7946 0958", "call [REDACTED phone]"},
+		{"call (415) 555-0100", "call [REDACTED phone]"},
+		{"call 415.555.0100", "call [REDACTED phone]"},
+		{"date 2024-01-02", "date 2024-01-02"},
+		{"id 123456789012", "id 123456789012"},
+		{"too short +12 34", "too short +12 34"},
+	}
+	for _, tt := range tests {
+		if got := r.String(tt.in); got != tt.want {
+			t.Errorf("String(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMaskStyles(t *testing.T) {
+	tests := []struct {
+		style, kind, in, want string
+	}{
+		{Full, Email, "john@example.com", "[REDACTED email]"},
+		{Full, Password, "hunter2", "[REDACTED password]"},
+		{Partial, Email, "john@example.com", "j***@e******.com"},
+		{Partial, Phone, "+1 415 555 0100", "+* *** *** **00"},
+		{Partial, IP, "192.168.1.20", "192.168.*.*"},
+		{Partial, IP, "2001:db8::1", "2001:****"},
+		{Partial, Password, "hunter2", "********"},
+	}
+	for _, tt := range tests {
+		if got := newRedactor(t, tt.style).Mask(tt.kind, tt.in); got != tt.want {
+			t.Errorf("%s Mask(%s, %q) = %q, want %q", tt.style, tt.kind, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestHashedStyle(t *testing.T) {
+	r := newRedactor(t, Hashed)
+	a := r.Mask(Email, "John@Example.com")
+	if !strings.HasPrefix(a, "email:") || len(a) != len("email:")+12 {
+		t.Fatalf("Mask = %q, want email: and 12 hex digits", a)
+	}
+	if b := r.Mask(Email, " john@example.com "); b != a {
+		t.Errorf("equal values hashed differently: %q and %q", a, b)
+	}
+	if c := r.Mask(Email, "jane@example.com"); c == a {
+		t.Errorf("different values hashed the same: %q", c)
+	}
+
+	// Without the key the hash cannot be reproduced
+	other, err := New(Hashed, []byte("another key"))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if other.Mask(Email, "john@example.com") == a {
+		t.Errorf("hash does not depend on the key")
+	}
+}
+
+func TestValueByFieldName(t *testing.T) {
+	r := newRedactor(t, Full)
+	got := r.Value(map[string]interface{}{
+		"email":    "john@example.com",
+		"password": "hunter2",
+		"username": "johnny",
+		"bio":      "reach me at john@example.com",
+		"emails":   []interface{}{"a@example.com"},
+	}).(map[string]interface{})
+
+	want := map[string]string{
+		"email":    "[REDACTED email]",
+		"password": "[REDACTED password]",
+		"username": "johnny",
+		"bio":      "reach me at [REDACTED email]",
+	}
+	for key, w := range want {
+		if got[key] != w {
+			t.Errorf("%s = %v, want %q", key, got[key], w)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	r := newRedactor(t, Full)
+	got := r.JSON([]byte(`[{"ip":"1.2.3.4","n":1}]`))
+	if want := `[{"ip":"[REDACTED ip]","n":1}]`; string(got) != want {
+		t.Errorf("JSON = %s, want %s", got, want)
+	}
+
+	// Bodies that are not JSON cannot be masked by field, so nothing of
+	// them is kept
+	got = r.JSON([]byte("error for john@example.com, password hunter2"))
+	if want := `"[REDACTED]"`; string(got) != want {
+		t.Errorf("JSON = %s, want %s", got, want)
+	}
+}
+
+func TestValueNestedKinds(t *testing.T) {
+	r := newRedactor(t, Full)
+	in := map[string]interface{}{
+		"password": map[string]interface{}{"value": "hunter2"},
+		"email":    map[string]interface{}{"address": "x", "list": []interface{}{"y"}},
+		"contact":  map[string]interface{}{"phone": "12345", "note": "call me"},
+	}
+	got, _ := json.Marshal(r.Value(in))
+	want := `{"contact":{"note":"call me","phone":"[REDACTED phone]"},` +
+		`"email":{"address":"[REDACTED email]","list":["[REDACTED email]"]},` +
+		`"password":{"value":"[REDACTED password]"}}`
+	if string(got) != want {
+		t.Errorf("Value = %s, want %s", got, want)
+	}
+}
+
+func TestLoadKey(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "config")
+	key, err := LoadKey(dir)
+	if err != nil {
+		t.Fatalf("LoadKey: %v", err)
+	}
+	if len(key) != 32 {
+		t.Errorf("key is %d bytes, want 32", len(key))
+	}
+	info, err := os.Stat(filepath.Join(dir, keyFile))
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("key file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	again, err := LoadKey(dir)
+	if err != nil {
+		t.Fatalf("LoadKey: %v", err)
+	}
+	if !bytes.Equal(key, again) {
+		t.Errorf("LoadKey returned a different key on the second call")
+	}
+}
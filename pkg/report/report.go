@@ -0,0 +1,298 @@
+// Package report renders case entries and archived responses as HTML or
+// Markdown documents, using a dedicated template for each kind of result.
+package report
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/api"
+	"github.com/ivan9253/lolarchiver-cli/pkg/archive"
+	"github.com/ivan9253/lolarchiver-cli/pkg/breach"
+	"github.com/ivan9253/lolarchiver-cli/pkg/cases"
+	"github.com/ivan9253/lolarchiver-cli/pkg/follows"
+	"github.com/ivan9253/lolarchiver-cli/pkg/records"
+	"github.com/ivan9253/lolarchiver-cli/pkg/timeline"
+)
+
+// Output formats
+const (
+	HTML     = "html"
+	Markdown = "md"
+)
+
+// Formats lists every supported format
+var Formats = []string{HTML, Markdown}
+
+// Section kinds, each rendered by the template of the same name
+const (
+	KindMessages = "messages"
+	KindHistory  = "history"
+	KindFollows  = "follows"
+	KindLookup   = "lookup"
+	KindRaw      = "raw"
+)
+
+// Report is the data passed to the report template
+type Report struct {
+	Title     string
+	Generated time.Time
+	Case      *cases.Case
+	Queries   []Query
+	Sections  []Section
+}
+
+// Query is one request listed in the queries table
+type Query struct {
+	Seq        int
+	Time       time.Time
+	Endpoint   string
+	Params     string
+	StatusCode int
+	Credits    int
+	Error      string
+}
+
+// Section is one result rendered with the template named by Kind. Only the
+// field matching Kind is set.
+type Section struct {
+	Kind     string
+	Title    string
+	Source   string
+	Time     time.Time
+	Events   timeline.Timeline
+	Follows  []follows.Follow
+	Lookup   *Lookup
+	Raw      string
+	Problems []string
+}
+
+// Lookup holds a parsed reverse or database lookup
+type Lookup struct {
+	Summary breach.Summary
+	Values  breach.ByField
+}
+
+// endpoint describes how results of one endpoint are rendered
+type endpoint struct {
+	title    string
+	kind     string
+	platform string
+	event    string
+}
+
+var endpoints = map[string]endpoint{
+	api.PathTwitchUserMessages:    {"Twitch messages", KindMessages, timeline.Twitch, timeline.KindMessage},
+	api.PathTwitchUserTimeouts:    {"Twitch timeouts", KindMessages, timeline.Twitch, timeline.KindTimeout},
+	api.PathKickUserMessages:      {"Kick messages", KindMessages, timeline.Kick, timeline.KindMessage},
+	api.PathKickUserTimeouts:      {"Kick timeouts", KindMessages, timeline.Kick, timeline.KindTimeout},
+	api.PathYouTubeUserComments:   {"YouTube comments", KindMessages, timeline.YouTube, timeline.KindComment},
+	api.PathYouTubeCommentReplies: {"YouTube replies", KindMessages, timeline.YouTube, timeline.KindComment},
+	api.PathTwitchUserHistory:     {"Twitch account history", KindHistory, timeline.Twitch, timeline.KindUsername},
+	api.PathTwitterHistoryLookup:  {"Twitter account history", KindHistory, timeline.Twitter, timeline.KindUsername},
+	api.PathTwitchFollowers:       {"Twitch followers", KindFollows, timeline.Twitch, ""},
+	api.PathTwitchFollowage:       {"Twitch followed channels", KindFollows, timeline.Twitch, ""},
+	api.PathKickUserModChannels:   {"Kick channels moderated", KindFollows, timeline.Kick, ""},
+	api.PathKickUserSubscribers:   {"Kick subscriptions", KindFollows, timeline.Kick, ""},
+	api.PathReversePhoneLookup:    {"Reverse phone lookup", KindLookup, "", ""},
+	api.PathReverseEmailLookup:    {"Reverse email lookup", KindLookup, "", ""},
+	api.PathDatabaseLookup:        {"Database lookup", KindLookup, "", ""},
+}
+
+// archiveEndpoints maps archive kinds to the endpoint that produced them
+var archiveEndpoints = map[string]string{
+	"twitter":          api.PathTwitterHistoryLookup,
+	"twitter-by-old":   api.PathTwitterHistoryLookup,
+	"kick-messages":    api.PathKickUserMessages,
+	"youtube-comments": api.PathYouTubeUserComments,
+}
+
+// subjectKeys are the request parameters naming the subject of a query
+var subjectKeys = []string{"username", "handle", "id", "user_id", "channel_id", "comment_id", "email", "phone", "query"}
+
+// Options controls how results are turned into sections
+type Options struct {
+	// Filter, when set, is applied to every response body before it is
+	// parsed, e.g. to mask personal data
+	Filter func(body []byte) []byte
+	// FilterField is applied to parameter values shown in the report, with
+	// the name of the parameter so that it can be masked by field
+	FilterField func(field, s string) string
+}
+
+func (o Options) body(b []byte) []byte {
+	if o.Filter == nil {
+		return b
+	}
+	return o.Filter(b)
+}
+
+func (o Options) field(field, s string) string {
+	if o.FilterField == nil {
+		return s
+	}
+	return o.FilterField(field, s)
+}
+
+// New creates an empty report
+func New(title string) *Report {
+	return &Report{Title: title, Generated: time.Now().UTC()}
+}
+
+// AddCase adds every entry of c to the queries table, and a section for
+// every entry that stored a successful response
+func (r *Report) AddCase(c *cases.Case, opts Options) error {
+	entries, err := c.Entries()
+	if err != nil {
+		return err
+	}
+	r.Case = c
+
+	for _, e := range entries {
+		params := formatParams(e.Params, opts)
+		r.Queries = append(r.Queries, Query{
+			Seq:        e.Seq,
+			Time:       e.Time,
+			Endpoint:   e.Endpoint,
+			Params:     params,
+			StatusCode: e.StatusCode,
+			Credits:    e.Credits,
+			Error:      e.Error,
+		})
+		if e.Error != "" || e.StatusCode != 200 || e.ResultFile == "" {
+			continue
+		}
+
+		body, err := c.Result(e)
+		if err != nil {
+			return err
+		}
+		key, value := subjectOf(e.Params)
+		subject := opts.field(key, value)
+		source := fmt.Sprintf("case %s, request #%d", c.Name, e.Seq)
+		r.Sections = append(r.Sections, newSection(e.Endpoint, subject, source, e.Time, opts.body(body)))
+	}
+	return nil
+}
+
+// AddArchive adds a section for the latest archived snapshot of kind and key
+func (r *Report) AddArchive(kind, key string, opts Options) error {
+	snap, err := archive.Latest(kind, key)
+	if err != nil {
+		return err
+	}
+	if snap == nil {
+		return fmt.Errorf("no archived %s snapshot for %s", kind, key)
+	}
+
+	path, ok := archiveEndpoints[kind]
+	switch {
+	case ok:
+	case strings.HasPrefix(kind, "twitch-history-"):
+		path = api.PathTwitchUserHistory
+	case strings.HasPrefix(kind, "twitch-messages-"):
+		path = api.PathTwitchUserMessages
+	}
+	source := fmt.Sprintf("archived %s snapshot", kind)
+	r.Sections = append(r.Sections, newSection(path, opts.field("", key), source, snap.Time, opts.body(snap.Body)))
+	return nil
+}
+
+func newSection(path, subject, source string, t time.Time, body []byte) Section {
+	ep, ok := endpoints[path]
+	if !ok {
+		ep = endpoint{title: path, kind: KindRaw}
+	}
+	s := Section{Kind: ep.kind, Title: ep.title, Source: source, Time: t}
+	if subject != "" {
+		s.Title += ": " + subject
+	}
+
+	switch ep.kind {
+	case KindMessages, KindHistory:
+		recs, err := records.Extract(body)
+		if err != nil {
+			return rawSection(s, body, err)
+		}
+		if ep.kind == KindMessages {
+			s.Events = timeline.FromActivity(ep.platform, ep.event, subject, recs)
+		} else {
+			s.Events = timeline.FromHistory(ep.platform, ep.event, subject, recs)
+		}
+		s.Events.Sort()
+	case KindFollows:
+		list, err := follows.Parse(body)
+		if err != nil {
+			return rawSection(s, body, err)
+		}
+		s.Follows = list
+	case KindLookup:
+		hits, err := breach.Parse(body)
+		if err != nil {
+			return rawSection(s, body, err)
+		}
+		hits, _ = breach.Dedupe(hits)
+		s.Lookup = &Lookup{Summary: breach.Summarise(hits), Values: breach.GroupByField(hits)}
+	default:
+		s.Raw = string(body)
+	}
+	return s
+}
+
+func rawSection(s Section, body []byte, err error) Section {
+	s.Kind = KindRaw
+	s.Raw = string(body)
+	s.Problems = append(s.Problems, err.Error())
+	return s
+}
+
+// subjectOf returns the name and value of the parameter naming the
+// subject of a query
+func subjectOf(params map[string]interface{}) (string, string) {
+	for _, key := range subjectKeys {
+		if v, ok := params[key]; ok && fmt.Sprint(v) != "" && fmt.Sprint(v) != "0" {
+			return key, fmt.Sprint(v)
+		}
+	}
+	return "", ""
+}
+
+func formatParams(params map[string]interface{}, opts Options) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, key+"="+opts.field(key, fmt.Sprint(params[key])))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Render writes the report in format. Each override file is parsed after
+// the built-in templates and may redefine any of them ("report", "queries",
+// "messages", "history", "follows", "lookup" or "raw").
+func (r *Report) Render(w io.Writer, format string, overrides []string) error {
+	var sources []string
+	for _, path := range overrides {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read template: %w", err)
+		}
+		sources = append(sources, string(data))
+	}
+
+	switch format {
+	case HTML:
+		return renderHTML(w, r, sources)
+	case Markdown:
+		return renderMarkdown(w, r, sources)
+	default:
+		return fmt.Errorf("unknown report format %q (expected %s)", format, strings.Join(Formats, ", "))
+	}
+}
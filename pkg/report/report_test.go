@@ -0,0 +1,120 @@
+package report
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/api"
+)
+
+func TestNewSection(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		body    string
+		kind    string
+		title   string
+		entries int
+	}{
+		{"messages", api.PathTwitchUserMessages, `[{"timestamp": "2024-03-01T12:00:00Z", "channel": "#foo", "message": "hi"}]`, KindMessages, "Twitch messages: alice", 1},
+		{"history", api.PathTwitterHistoryLookup, `{"results": [{"date": "2020-01-01", "username": "old"}, {"date": "2021-01-01", "username": "new"}]}`, KindHistory, "Twitter account history: alice", 2},
+		{"follows", api.PathTwitchFollowers, `[{"user_login": "Bob", "followed_at": "2024-01-01T00:00:00Z"}]`, KindFollows, "Twitch followers: alice", 1},
+		{"lookup", api.PathDatabaseLookup, `{"results": [{"source": "siteA", "email": "a@example.com"}, {"source": "siteB", "email": "a@example.com"}]}`, KindLookup, "Database lookup: alice", 1},
+		{"unknown endpoint", "/unknown", `{"a": 1}`, KindRaw, "/unknown: alice", 0},
+		{"unparsable body", api.PathTwitchFollowers, `not json`, KindRaw, "Twitch followers: alice", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSection(tt.path, "alice", "test", time.Time{}, []byte(tt.body))
+			if s.Kind != tt.kind || s.Title != tt.title {
+				t.Fatalf("section = %s %q, want %s %q", s.Kind, s.Title, tt.kind, tt.title)
+			}
+			var n int
+			switch s.Kind {
+			case KindMessages, KindHistory:
+				n = len(s.Events)
+			case KindFollows:
+				n = len(s.Follows)
+			case KindLookup:
+				// Identical hits are merged, so the total counts one
+				total := s.Lookup.Summary[len(s.Lookup.Summary)-1]
+				n = total.Hits
+			default:
+				if s.Raw != tt.body {
+					t.Errorf("Raw = %q, want the body", s.Raw)
+				}
+			}
+			if n != tt.entries {
+				t.Errorf("section holds %d entries, want %d", n, tt.entries)
+			}
+		})
+	}
+
+	if s := newSection(api.PathTwitchFollowers, "", "test", time.Time{}, []byte(`not json`)); len(s.Problems) != 1 {
+		t.Errorf("unparsable body recorded %d problem(s), want 1", len(s.Problems))
+	}
+}
+
+func TestFormatParams(t *testing.T) {
+	params := map[string]interface{}{"phone": "+14155550100", "limit": 10, "query": "x"}
+
+	if got := formatParams(params, Options{}); got != "limit=10 phone=+14155550100 query=x" {
+		t.Errorf("formatParams = %q", got)
+	}
+
+	opts := Options{FilterField: func(field, s string) string {
+		if field == "phone" {
+			return "[" + field + "]"
+		}
+		return s
+	}}
+	if got := formatParams(params, opts); got != "limit=10 phone=[phone] query=x" {
+		t.Errorf("formatParams with a filter = %q, want the phone masked by field", got)
+	}
+}
+
+func TestRenderOverrides(t *testing.T) {
+	r := New("Test <report>")
+	r.Sections = append(r.Sections, newSection("/unknown", "", "test", time.Time{}, []byte(`{"a": "<b>"}`)))
+
+	dir := t.TempDir()
+	override := filepath.Join(dir, "raw.tmpl")
+	if err := os.WriteFile(override, []byte(`{{define "raw"}}RAW[{{.Raw}}]{{end}}`), 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{Markdown, []string{"# Test <report>", `RAW[{"a": "<b>"}]`}},
+		// HTML templates escape the data, overrides included
+		{HTML, []string{"Test &lt;report&gt;", `RAW[{&#34;a&#34;: &#34;&lt;b&gt;&#34;}]`}},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := r.Render(&buf, tt.format, []string{override}); err != nil {
+			t.Fatalf("Render(%s): %v", tt.format, err)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(buf.String(), want) {
+				t.Errorf("Render(%s) is missing %q:\n%s", tt.format, want, buf.String())
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := r.Render(&buf, Markdown, []string{filepath.Join(dir, "missing.tmpl")}); err == nil {
+		t.Errorf("Render with a missing template succeeded")
+	}
+	bad := filepath.Join(dir, "bad.tmpl")
+	os.WriteFile(bad, []byte(`{{define "raw"}}{{.Raw`), 0600)
+	if err := r.Render(&buf, Markdown, []string{bad}); err == nil {
+		t.Errorf("Render with an invalid template succeeded")
+	}
+}
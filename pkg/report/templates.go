@@ -0,0 +1,251 @@
+package report
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// funcs are available to every template
+var funcs = map[string]interface{}{
+	"datetime": func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.UTC().Format("2006-01-02 15:04:05 UTC")
+	},
+	"date": func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.UTC().Format(time.DateOnly)
+	},
+	"cell": func(s string) string {
+		s = strings.Join(strings.Fields(s), " ")
+		return strings.ReplaceAll(s, "|", `\|`)
+	},
+	"join": strings.Join,
+}
+
+// dispatch renders a section with the template named after its kind. Go
+// templates cannot pick a template by a computed name, hence the chain.
+const dispatch = `{{define "section"}}
+{{- if eq .Kind "messages"}}{{template "messages" .}}
+{{- else if eq .Kind "history"}}{{template "history" .}}
+{{- else if eq .Kind "follows"}}{{template "follows" .}}
+{{- else if eq .Kind "lookup"}}{{template "lookup" .}}
+{{- else}}{{template "raw" .}}{{end}}
+{{- end}}`
+
+const markdownTemplates = `{{define "report" -}}
+# {{.Title}}
+
+Generated {{datetime .Generated}}
+{{- with .Case}} from case ` + "`{{.Name}}`" + ` ({{.Status}}, opened {{datetime .Created}}){{end}}.
+{{if .Queries}}
+{{template "queries" .}}
+{{- end}}
+{{- range .Sections}}
+## {{.Title}}
+
+_{{.Source}}, retrieved {{datetime .Time}}_
+{{range .Problems}}
+> Could not parse this result: {{.}}
+{{end}}
+{{template "section" .}}
+{{- end}}
+{{- end}}
+
+{{define "queries" -}}
+## Queries
+
+| # | Time | Endpoint | Parameters | Status | Credits |
+|---|------|----------|------------|--------|---------|
+{{range .Queries -}}
+| {{.Seq}} | {{datetime .Time}} | ` + "`{{.Endpoint}}`" + ` | {{cell .Params}} | {{if .Error}}{{cell .Error}}{{else}}{{.StatusCode}}{{end}} | {{.Credits}} |
+{{end -}}
+{{end}}
+
+{{define "messages" -}}
+{{if .Events -}}
+| Time | Channel | User | Message |
+|------|---------|------|---------|
+{{range .Events -}}
+| {{datetime .Time}} | {{cell .Channel}} | {{cell .Actor}} | {{cell .Text}} |
+{{end -}}
+{{else}}No messages.
+{{end -}}
+{{end}}
+
+{{define "history" -}}
+{{if .Events -}}
+| Date | Value |
+|------|-------|
+{{range .Events -}}
+| {{datetime .Time}} | {{cell .Text}} |
+{{end -}}
+{{else}}No history entries.
+{{end -}}
+{{end}}
+
+{{define "follows" -}}
+{{if .Follows -}}
+| Account | Since |
+|---------|-------|
+{{range .Follows -}}
+| {{cell .User}} | {{date .Date}} |
+{{end -}}
+{{else}}No accounts.
+{{end -}}
+{{end}}
+
+{{define "lookup" -}}
+{{with .Lookup -}}
+| {{join .Summary.Columns " | "}} |
+|{{range .Summary.Columns}}---|{{end}}
+{{range .Summary.Rows -}}
+| {{join . " | "}} |
+{{end}}
+{{if .Values -}}
+| Kind | Value | Hits | Sources |
+|------|-------|------|---------|
+{{range .Values -}}
+| {{.Category}} | {{cell .Value}} | {{.Hits}} | {{cell (join .Sources ", ")}} |
+{{end -}}
+{{end -}}
+{{end -}}
+{{end}}
+
+{{define "raw" -}}
+` + "```json" + `
+{{.Raw}}
+` + "```" + `
+{{end}}`
+
+const htmlTemplates = `{{define "report" -}}
+<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; vertical-align: top; }
+th { background: #f0f0f0; }
+.meta { color: #666; font-style: italic; }
+.problem { color: #a00; }
+pre { background: #f6f6f6; padding: 1em; overflow-x: auto; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="meta">Generated {{datetime .Generated}}
+{{- with .Case}} from case <code>{{.Name}}</code> ({{.Status}}, opened {{datetime .Created}}){{end}}.</p>
+{{if .Queries}}{{template "queries" .}}{{end}}
+{{range .Sections -}}
+<section>
+<h2>{{.Title}}</h2>
+<p class="meta">{{.Source}}, retrieved {{datetime .Time}}</p>
+{{range .Problems}}<p class="problem">Could not parse this result: {{.}}</p>
+{{end -}}
+{{template "section" .}}
+</section>
+{{end -}}
+</body>
+</html>
+{{end}}
+
+{{define "queries" -}}
+<h2>Queries</h2>
+<table>
+<tr><th>#</th><th>Time</th><th>Endpoint</th><th>Parameters</th><th>Status</th><th>Credits</th></tr>
+{{range .Queries -}}
+<tr><td>{{.Seq}}</td><td>{{datetime .Time}}</td><td><code>{{.Endpoint}}</code></td><td>{{.Params}}</td><td>{{if .Error}}{{.Error}}{{else}}{{.StatusCode}}{{end}}</td><td>{{.Credits}}</td></tr>
+{{end -}}
+</table>
+{{end}}
+
+{{define "messages" -}}
+{{if .Events -}}
+<table>
+<tr><th>Time</th><th>Channel</th><th>User</th><th>Message</th></tr>
+{{range .Events -}}
+<tr><td>{{datetime .Time}}</td><td>{{.Channel}}</td><td>{{.Actor}}</td><td>{{.Text}}</td></tr>
+{{end -}}
+</table>
+{{else}}<p>No messages.</p>
+{{end -}}
+{{end}}
+
+{{define "history" -}}
+{{if .Events -}}
+<table>
+<tr><th>Date</th><th>Value</th></tr>
+{{range .Events -}}
+<tr><td>{{datetime .Time}}</td><td>{{.Text}}</td></tr>
+{{end -}}
+</table>
+{{else}}<p>No history entries.</p>
+{{end -}}
+{{end}}
+
+{{define "follows" -}}
+{{if .Follows -}}
+<table>
+<tr><th>Account</th><th>Since</th></tr>
+{{range .Follows -}}
+<tr><td>{{.User}}</td><td>{{date .Date}}</td></tr>
+{{end -}}
+</table>
+{{else}}<p>No accounts.</p>
+{{end -}}
+{{end}}
+
+{{define "lookup" -}}
+{{with .Lookup -}}
+<table>
+<tr>{{range .Summary.Columns}}<th>{{.}}</th>{{end}}</tr>
+{{range .Summary.Rows -}}
+<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end -}}
+</table>
+{{if .Values -}}
+<table>
+<tr><th>Kind</th><th>Value</th><th>Hits</th><th>Sources</th></tr>
+{{range .Values -}}
+<tr><td>{{.Category}}</td><td>{{.Value}}</td><td>{{.Hits}}</td><td>{{join .Sources ", "}}</td></tr>
+{{end -}}
+</table>
+{{end -}}
+{{end -}}
+{{end}}
+
+{{define "raw" -}}
+<pre>{{.Raw}}</pre>
+{{end}}`
+
+func renderMarkdown(w io.Writer, r *Report, overrides []string) error {
+	t := template.New("report").Funcs(funcs)
+	for _, src := range append([]string{markdownTemplates, dispatch}, overrides...) {
+		var err error
+		if t, err = t.Parse(src); err != nil {
+			return fmt.Errorf("failed to parse template: %w", err)
+		}
+	}
+	return t.ExecuteTemplate(w, "report", r)
+}
+
+func renderHTML(w io.Writer, r *Report, overrides []string) error {
+	t := htmltemplate.New("report").Funcs(funcs)
+	for _, src := range append([]string{htmlTemplates, dispatch}, overrides...) {
+		var err error
+		if t, err = t.Parse(src); err != nil {
+			return fmt.Errorf("failed to parse template: %w", err)
+		}
+	}
+	return t.ExecuteTemplate(w, "report", r)
+}
@@ -0,0 +1,254 @@
+// Package stats computes activity statistics over chat messages and
+// comments: when a user is active, where, and what they write.
+package stats
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/timeline"
+)
+
+// Count is a name with the number of times it occurred
+type Count struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// Stats summarises a set of messages
+type Stats struct {
+	Messages   int        `json:"messages"`
+	First      *time.Time `json:"first,omitempty"`
+	Last       *time.Time `json:"last,omitempty"`
+	ActiveDays int        `json:"active_days"`
+	AvgLength  float64    `json:"average_length"`
+	Timezone   string     `json:"timezone"`
+	Heatmap    [7][24]int `json:"heatmap"`
+	Channels   []Count    `json:"channels"`
+	Terms      []Count    `json:"terms"`
+	Emotes     []Count    `json:"emotes"`
+}
+
+// Stopwords are left out of term frequencies
+var Stopwords = map[string]bool{}
+
+func init() {
+	for _, w := range strings.Fields(`a about above after again against all am an and any are as at be because
+		been before being below between both but by can could did do does doing down during each few for from
+		further had has have having he her here hers herself him himself his how i if in into is it its itself
+		just me more most my myself no nor not now of off on once only or other our ours ourselves out over own
+		same she should so some such than that the their theirs them themselves then there these they this those
+		through to too under until up very was we were what when where which while who whom why will with would
+		you your yours yourself yourselves im dont its thats youre ive ill id cant wont didnt doesnt isnt arent
+		wasnt lol lmao yeah yes ok okay oh u ur like get got also one`) {
+		Stopwords[w] = true
+	}
+}
+
+// KnownEmotes are common global and third-party emote names that cannot be
+// told apart from words by their shape
+var KnownEmotes = map[string]bool{}
+
+func init() {
+	for _, e := range strings.Fields(`Kappa KappaPride Keepo LUL OMEGALUL KEKW PogChamp Pog POGGERS PogU
+		monkaS monkaW PepeHands PepeLaugh Sadge Copium Aware Clap EZ 4Head ResidentSleeper BibleThump Kreygasm
+		TriHard WutFace SeemsGood NotLikeThis HeyGuys CoolCat DansGame FailFish Jebaited MingLee PJSalt SMOrc
+		SwiftRage VoHiYo BabyRage FeelsBadMan FeelsGoodMan FeelsStrongMan FeelsOkayMan widepeepoHappy
+		peepoHappy peepoSad catJAM HYPERS Kekw KEKL ICANT Stare Okayge Clueless WeirdChamp modCheck
+		NODDERS NOPERS xdd`) {
+		KnownEmotes[e] = true
+	}
+}
+
+var (
+	// Kick renders emotes as [emote:ID:name]
+	kickEmote = regexp.MustCompile(`\[emote:\d+:([^\]]+)\]`)
+	// YouTube renders custom emoji as :name:
+	youtubeEmote = regexp.MustCompile(`^:[A-Za-z0-9_-]+:$`)
+	// Channel emotes are a lowercase prefix followed by a capitalised name,
+	// e.g. xqcL or forsenE
+	channelEmote = regexp.MustCompile(`^[a-z][a-z0-9]{2,}[A-Z][A-Za-z0-9]*$`)
+	urlPattern   = regexp.MustCompile(`^(https?://|www\.)`)
+)
+
+// Compute summarises events. Hours and days are taken in loc, and the top
+// most frequent channels, terms and emotes are kept (all when top is 0).
+func Compute(events timeline.Timeline, loc *time.Location, top int) *Stats {
+	s := &Stats{Timezone: loc.String()}
+	channels := make(map[string]int)
+	terms := make(map[string]int)
+	emotes := make(map[string]int)
+	days := make(map[string]bool)
+	totalLength := 0
+
+	for _, ev := range events {
+		s.Messages++
+		totalLength += utf8.RuneCountInString(ev.Text)
+		if ev.Channel != "" {
+			channels[ev.Channel]++
+		}
+
+		if !ev.Time.IsZero() {
+			t := ev.Time.In(loc)
+			if s.First == nil || t.Before(*s.First) {
+				s.First = &t
+			}
+			if s.Last == nil || t.After(*s.Last) {
+				s.Last = &t
+			}
+			days[t.Format(time.DateOnly)] = true
+			// Rows start on Monday
+			s.Heatmap[(int(t.Weekday())+6)%7][t.Hour()]++
+		}
+
+		text := kickEmote.ReplaceAllStringFunc(ev.Text, func(m string) string {
+			emotes[kickEmote.FindStringSubmatch(m)[1]]++
+			return " "
+		})
+		for _, token := range strings.Fields(text) {
+			if isEmote(token) {
+				emotes[token]++
+				continue
+			}
+			if term := normaliseTerm(token); term != "" {
+				terms[term]++
+			}
+		}
+	}
+
+	s.ActiveDays = len(days)
+	if s.Messages > 0 {
+		s.AvgLength = float64(totalLength) / float64(s.Messages)
+	}
+	s.Channels = topCounts(channels, top)
+	s.Terms = topCounts(terms, top)
+	s.Emotes = topCounts(emotes, top)
+	return s
+}
+
+func isEmote(token string) bool {
+	return KnownEmotes[token] || youtubeEmote.MatchString(token) || channelEmote.MatchString(token)
+}
+
+// normaliseTerm lowercases a token and strips surrounding punctuation,
+// returning "" for stopwords, links, mentions, numbers and single letters
+func normaliseTerm(token string) string {
+	if urlPattern.MatchString(token) || strings.HasPrefix(token, "@") {
+		return ""
+	}
+	term := strings.ToLower(strings.TrimFunc(token, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}))
+	term = strings.ReplaceAll(term, "'", "")
+	if utf8.RuneCountInString(term) < 2 || Stopwords[term] {
+		return ""
+	}
+	if strings.IndexFunc(term, unicode.IsLetter) < 0 {
+		return ""
+	}
+	return term
+}
+
+func topCounts(m map[string]int, top int) []Count {
+	counts := make([]Count, 0, len(m))
+	for name, n := range m {
+		counts = append(counts, Count{Name: name, Count: n})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Name < counts[j].Name
+	})
+	if top > 0 && len(counts) > top {
+		counts = counts[:top]
+	}
+	return counts
+}
+
+// shades are used for heatmap cells, from no activity to the busiest hour
+const shades = " .:-=+*#%@"
+
+var weekdays = []string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"}
+
+// WriteText renders the statistics as terminal tables and an ASCII heatmap
+func (s *Stats) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Messages\t%d\n", s.Messages)
+	if s.First != nil {
+		fmt.Fprintf(tw, "Active range\t%s to %s\n", s.First.Format(time.DateTime), s.Last.Format(time.DateTime))
+	}
+	fmt.Fprintf(tw, "Active days\t%d\n", s.ActiveDays)
+	fmt.Fprintf(tw, "Average length\t%.1f characters\n", s.AvgLength)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "\nActivity by hour (%s)\n", s.Timezone)
+	fmt.Fprintf(w, "     %s\n", "0         1         2   ")
+	fmt.Fprintf(w, "     %s\n", "012345678901234567890123")
+	peak := 0
+	for _, row := range s.Heatmap {
+		for _, n := range row {
+			if n > peak {
+				peak = n
+			}
+		}
+	}
+	for d, row := range s.Heatmap {
+		var b strings.Builder
+		total := 0
+		for _, n := range row {
+			total += n
+			b.WriteByte(shade(n, peak))
+		}
+		fmt.Fprintf(w, "%s  %s  %d\n", weekdays[d], b.String(), total)
+	}
+	fmt.Fprintf(w, "Scale: %q, peak %d message(s) in one hour slot\n", shades, peak)
+
+	for _, section := range []struct {
+		title  string
+		counts []Count
+	}{
+		{"Top channels", s.Channels},
+		{"Top terms", s.Terms},
+		{"Top emotes", s.Emotes},
+	} {
+		fmt.Fprintf(w, "\n%s\n", section.title)
+		if len(section.counts) == 0 {
+			fmt.Fprintln(w, "  (none)")
+			continue
+		}
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, c := range section.counts {
+			fmt.Fprintf(tw, "  %s\t%d\t%s\n", c.Name, c.Count, bar(c.Count, section.counts[0].Count))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func shade(n, peak int) byte {
+	if n == 0 || peak == 0 {
+		return shades[0]
+	}
+	// Any activity gets at least the lightest visible shade
+	return shades[1+(n*(len(shades)-2))/peak]
+}
+
+func bar(n, max int) string {
+	const width = 30
+	if max == 0 {
+		return ""
+	}
+	return strings.Repeat("#", (n*width+max-1)/max)
+}
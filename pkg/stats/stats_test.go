@@ -0,0 +1,109 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/timeline"
+)
+
+func TestNormaliseTerm(t *testing.T) {
+	tests := []struct {
+		token string
+		want  string
+	}{
+		{"Hello", "hello"},
+		{"(world!)", "world"},
+		{"don't", ""},
+		{"can't-stop", "cant-stop"},
+		{"x", ""},
+		{"the", ""},
+		{"12345", ""},
+		{"2024!", ""},
+		{"4head", "4head"},
+		{"@someone", ""},
+		{"https://example.com", ""},
+		{"www.example.com", ""},
+		{"...", ""},
+		{"Über", "über"},
+	}
+	for _, tt := range tests {
+		if got := normaliseTerm(tt.token); got != tt.want {
+			t.Errorf("normaliseTerm(%q) = %q, want %q", tt.token, got, tt.want)
+		}
+	}
+}
+
+func TestIsEmote(t *testing.T) {
+	tests := []struct {
+		token string
+		want  bool
+	}{
+		{"Kappa", true},
+		{"KEKW", true},
+		{":face-blue-smiling:", true},
+		{"xqcL", true},
+		{"forsenE", true},
+		{"kappa", false},
+		{"Hello", false},
+		{"iPhone", false},
+		{"hello", false},
+		{":not an emote:", false},
+	}
+	for _, tt := range tests {
+		if got := isEmote(tt.token); got != tt.want {
+			t.Errorf("isEmote(%q) = %v, want %v", tt.token, got, tt.want)
+		}
+	}
+}
+
+func TestComputeHeatmap(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	events := timeline.Timeline{
+		// Monday 2024-01-01 10:00 UTC
+		{Time: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC), Text: "hello Kappa"},
+		// Sunday 2024-01-07 23:30 UTC is Monday 00:30 in Berlin
+		{Time: time.Date(2024, 1, 7, 23, 30, 0, 0, time.UTC), Text: "hello [emote:123:catJAM]"},
+		{Text: "no time"},
+	}
+
+	s := Compute(events, time.UTC, 0)
+	if s.Heatmap[0][10] != 1 || s.Heatmap[6][23] != 1 {
+		t.Errorf("UTC heatmap Monday 10h = %d, Sunday 23h = %d, want 1 and 1", s.Heatmap[0][10], s.Heatmap[6][23])
+	}
+	if s.Messages != 3 || s.ActiveDays != 2 {
+		t.Errorf("Messages = %d, ActiveDays = %d, want 3 and 2", s.Messages, s.ActiveDays)
+	}
+	if len(s.Terms) != 2 || s.Terms[0] != (Count{"hello", 2}) {
+		t.Errorf("Terms = %v, want hello twice first", s.Terms)
+	}
+	if len(s.Emotes) != 2 {
+		t.Errorf("Emotes = %v, want Kappa and catJAM", s.Emotes)
+	}
+
+	s = Compute(events, berlin, 0)
+	if s.Heatmap[0][11] != 1 || s.Heatmap[0][0] != 1 || s.Heatmap[6][23] != 0 {
+		t.Errorf("Berlin heatmap Monday = %v, Sunday = %v", s.Heatmap[0], s.Heatmap[6])
+	}
+}
+
+func TestShade(t *testing.T) {
+	tests := []struct {
+		n, peak int
+		want    byte
+	}{
+		{0, 0, ' '},
+		{0, 10, ' '},
+		{1, 1000, '.'},
+		{5, 10, '+'},
+		{10, 10, '@'},
+	}
+	for _, tt := range tests {
+		if got := shade(tt.n, tt.peak); got != tt.want {
+			t.Errorf("shade(%d, %d) = %q, want %q", tt.n, tt.peak, got, tt.want)
+		}
+	}
+}
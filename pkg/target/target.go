@@ -0,0 +1,311 @@
+// Package target recognises the account identifiers and profile URLs that
+// users paste, and resolves them locally into the field the API expects.
+package target
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/api"
+)
+
+// Platforms
+const (
+	Twitter = "twitter"
+	Twitch  = "twitch"
+	Kick    = "kick"
+	YouTube = "youtube"
+)
+
+// Kinds of identifier
+const (
+	// ChannelID is a YouTube channel ID (UC followed by 22 characters)
+	ChannelID = "channel_id"
+	// Handle is a Twitter handle, or a YouTube @handle or legacy custom URL
+	// name
+	Handle = "handle"
+	// UserID is a legacy YouTube username from a /user/ URL
+	UserID = "user_id"
+	// ID is a numeric Twitter account ID
+	ID = "id"
+	// Username is a Twitch or Kick login
+	Username = "username"
+	// CommentID is a YouTube comment ID from a permalink's lc parameter
+	CommentID = "comment_id"
+)
+
+// Target is an identifier resolved to a platform and field
+type Target struct {
+	Platform string `json:"platform"`
+	Kind     string `json:"kind"`
+	Value    string `json:"value"`
+}
+
+// String describes the target, e.g. "YouTube handle @name"
+func (t Target) String() string {
+	value := t.Value
+	if t.Kind == Handle {
+		value = "@" + value
+	}
+	return fmt.Sprintf("%s %s %s", platformNames[t.Platform], kindNames[t.Kind], value)
+}
+
+var platformNames = map[string]string{
+	Twitter: "Twitter",
+	Twitch:  "Twitch",
+	Kick:    "Kick",
+	YouTube: "YouTube",
+}
+
+var kindNames = map[string]string{
+	ChannelID: "channel ID",
+	Handle:    "handle",
+	UserID:    "user",
+	ID:        "user ID",
+	Username:  "username",
+	CommentID: "comment",
+}
+
+var (
+	youtubeChannelID = regexp.MustCompile(`^UC[A-Za-z0-9_-]{22}$`)
+	youtubeHandle    = regexp.MustCompile(`^[A-Za-z0-9._-]{3,30}$`)
+	youtubeUser      = regexp.MustCompile(`^[A-Za-z0-9._-]{1,100}$`)
+	youtubeComment   = regexp.MustCompile(`^[A-Za-z0-9._-]{10,}$`)
+	twitterID        = regexp.MustCompile(`^[0-9]{1,19}$`)
+	twitchLogin      = regexp.MustCompile(`^[A-Za-z0-9_]{3,25}$`)
+
+	// Handles follow the same rules as the API client validates
+	twitterHandle = api.UsernamePattern(api.PlatformTwitter)
+	kickLogin     = api.UsernamePattern(api.PlatformKick)
+)
+
+// hosts maps every recognised host name to its platform
+var hosts = map[string]string{
+	"twitter.com":        Twitter,
+	"www.twitter.com":    Twitter,
+	"mobile.twitter.com": Twitter,
+	"x.com":              Twitter,
+	"www.x.com":          Twitter,
+	"mobile.x.com":       Twitter,
+	"twitch.tv":          Twitch,
+	"www.twitch.tv":      Twitch,
+	"m.twitch.tv":        Twitch,
+	"kick.com":           Kick,
+	"www.kick.com":       Kick,
+	"youtube.com":        YouTube,
+	"www.youtube.com":    YouTube,
+	"m.youtube.com":      YouTube,
+	"music.youtube.com":  YouTube,
+	"youtu.be":           YouTube,
+}
+
+// reservedPaths are first path segments that name site pages rather than
+// accounts
+var reservedPaths = map[string]map[string]bool{
+	Twitter: set("home", "explore", "search", "notifications", "messages", "settings", "i", "intent", "share", "login", "signup", "tos", "privacy", "hashtag"),
+	Twitch:  set("directory", "videos", "settings", "subscriptions", "inventory", "wallet", "search", "downloads", "jobs", "p", "popout", "login", "signup"),
+	Kick:    set("categories", "browse", "following", "search", "settings", "dashboard", "video", "terms-of-service", "privacy-policy", "popout", "login", "signup"),
+}
+
+func set(values ...string) map[string]bool {
+	m := make(map[string]bool, len(values))
+	for _, v := range values {
+		m[v] = true
+	}
+	return m
+}
+
+// Parse resolves a profile or comment URL on any supported platform
+func Parse(s string) (Target, error) {
+	s = strings.TrimSpace(s)
+	if !LooksLikeURL(s) {
+		return Target{}, fmt.Errorf("cannot resolve %q: expected a Twitter, Twitch, Kick or YouTube URL", s)
+	}
+	u, err := parseURL(s)
+	if err != nil {
+		return Target{}, fmt.Errorf("cannot resolve %q: malformed URL", s)
+	}
+
+	switch hosts[strings.ToLower(u.Hostname())] {
+	case Twitter:
+		return parseTwitter(s, u)
+	case Twitch:
+		return parseLogin(s, u, Twitch, twitchLogin)
+	case Kick:
+		return parseLogin(s, u, Kick, kickLogin)
+	case YouTube:
+		return parseYouTubeURL(s, u)
+	default:
+		return Target{}, fmt.Errorf("cannot resolve %q: unsupported site %s", s, u.Hostname())
+	}
+}
+
+// ParseYouTube resolves a YouTube channel ID, @handle, bare handle or
+// channel URL (/channel/, /c/, /user/ or /@handle). Handles are returned
+// without the leading @.
+func ParseYouTube(s string) (Target, error) {
+	s = strings.TrimSpace(s)
+	invalid := func(reason string) (Target, error) {
+		return Target{}, fmt.Errorf("cannot resolve %q as a YouTube identifier: %s", s, reason)
+	}
+	if s == "" {
+		return invalid("empty")
+	}
+
+	if LooksLikeURL(s) {
+		u, err := parseURL(s)
+		if err != nil {
+			return invalid("malformed URL")
+		}
+		if hosts[strings.ToLower(u.Hostname())] != YouTube {
+			return invalid("not a youtube.com URL")
+		}
+		t, err := parseYouTubeURL(s, u)
+		if err != nil {
+			return Target{}, err
+		}
+		if t.Kind == CommentID {
+			return invalid("the link points to a comment, not a channel")
+		}
+		return t, nil
+	}
+
+	if strings.HasPrefix(s, "@") {
+		return youtubeTarget(Handle, s[1:])
+	}
+	if youtubeChannelID.MatchString(s) {
+		return youtubeTarget(ChannelID, s)
+	}
+	return youtubeTarget(Handle, s)
+}
+
+func parseYouTubeURL(s string, u *url.URL) (Target, error) {
+	invalid := func(reason string) (Target, error) {
+		return Target{}, fmt.Errorf("cannot resolve %q as a YouTube identifier: %s", s, reason)
+	}
+
+	// Comment permalinks carry the comment ID in lc on any video page
+	if lc := u.Query().Get("lc"); lc != "" {
+		if !youtubeComment.MatchString(lc) {
+			return invalid("malformed comment ID")
+		}
+		return Target{Platform: YouTube, Kind: CommentID, Value: lc}, nil
+	}
+	if strings.ToLower(u.Hostname()) == "youtu.be" {
+		return invalid("video links do not identify a channel")
+	}
+
+	parts := pathParts(u)
+	switch {
+	case len(parts) == 0:
+		return invalid("URL is not a channel page")
+	case strings.HasPrefix(parts[0], "@"):
+		return youtubeTarget(Handle, strings.TrimPrefix(parts[0], "@"))
+	case len(parts) >= 2 && parts[0] == "channel":
+		return youtubeTarget(ChannelID, parts[1])
+	case len(parts) >= 2 && parts[0] == "c":
+		return youtubeTarget(Handle, parts[1])
+	case len(parts) >= 2 && parts[0] == "user":
+		return youtubeTarget(UserID, parts[1])
+	default:
+		return invalid("URL is not a channel page")
+	}
+}
+
+func youtubeTarget(kind, value string) (Target, error) {
+	var ok bool
+	switch kind {
+	case ChannelID:
+		ok = youtubeChannelID.MatchString(value)
+	case Handle:
+		ok = youtubeHandle.MatchString(value)
+	case UserID:
+		ok = youtubeUser.MatchString(value)
+	}
+	if !ok {
+		return Target{}, fmt.Errorf("cannot resolve %q as a YouTube %s", value, kindNames[kind])
+	}
+	return Target{Platform: YouTube, Kind: kind, Value: value}, nil
+}
+
+// parseTwitter handles /handle, /handle/status/..., /i/user/ID and
+// /intent/user?user_id=ID
+func parseTwitter(s string, u *url.URL) (Target, error) {
+	invalid := func(reason string) (Target, error) {
+		return Target{}, fmt.Errorf("cannot resolve %q as a Twitter account: %s", s, reason)
+	}
+
+	parts := pathParts(u)
+	switch {
+	case len(parts) >= 3 && parts[0] == "i" && parts[1] == "user":
+		if !twitterID.MatchString(parts[2]) {
+			return invalid("malformed user ID")
+		}
+		return Target{Platform: Twitter, Kind: ID, Value: parts[2]}, nil
+	case len(parts) >= 2 && parts[0] == "intent" && (parts[1] == "user" || parts[1] == "follow"):
+		q := u.Query()
+		if id := q.Get("user_id"); id != "" {
+			if !twitterID.MatchString(id) {
+				return invalid("malformed user ID")
+			}
+			return Target{Platform: Twitter, Kind: ID, Value: id}, nil
+		}
+		if name := q.Get("screen_name"); twitterHandle.MatchString(name) {
+			return Target{Platform: Twitter, Kind: Handle, Value: name}, nil
+		}
+		return invalid("intent link without user_id or screen_name")
+	case len(parts) == 0 || reservedPaths[Twitter][strings.ToLower(parts[0])]:
+		return invalid("URL is not a profile page")
+	case !twitterHandle.MatchString(parts[0]):
+		return invalid("malformed handle")
+	default:
+		return Target{Platform: Twitter, Kind: Handle, Value: parts[0]}, nil
+	}
+}
+
+// parseLogin handles Twitch and Kick channel URLs, whose first path segment
+// is the login
+func parseLogin(s string, u *url.URL, platform string, pattern *regexp.Regexp) (Target, error) {
+	parts := pathParts(u)
+	if len(parts) >= 2 && parts[0] == "popout" {
+		parts = parts[1:]
+	}
+	if len(parts) == 0 || reservedPaths[platform][strings.ToLower(parts[0])] {
+		return Target{}, fmt.Errorf("cannot resolve %q as a %s account: URL is not a channel page", s, platformNames[platform])
+	}
+	if !pattern.MatchString(parts[0]) {
+		return Target{}, fmt.Errorf("cannot resolve %q as a %s account: malformed username", s, platformNames[platform])
+	}
+	return Target{Platform: platform, Kind: Username, Value: strings.ToLower(parts[0])}, nil
+}
+
+// pathParts splits the unescaped URL path into its non-empty segments
+func pathParts(u *url.URL) []string {
+	var parts []string
+	for _, p := range strings.Split(u.Path, "/") {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return parts
+}
+
+// LooksLikeURL reports whether s has a scheme or starts with a host name
+func LooksLikeURL(s string) bool {
+	lower := strings.ToLower(s)
+	if strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") {
+		return true
+	}
+	host, _, _ := strings.Cut(lower, "/")
+	return strings.Contains(host, ".") && strings.Contains(s, "/")
+}
+
+// parseURL parses s, adding a scheme when it was pasted without one
+func parseURL(s string) (*url.URL, error) {
+	if !strings.Contains(s, "://") {
+		s = "https://" + s
+	}
+	return url.Parse(s)
+}
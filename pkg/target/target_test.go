@@ -0,0 +1,93 @@
+package target
+
+import "testing"
+
+func TestParseYouTube(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Target
+		ok   bool
+	}{
+		{"@SomeChannel", Target{YouTube, Handle, "SomeChannel"}, true},
+		{" SomeChannel ", Target{YouTube, Handle, "SomeChannel"}, true},
+		{"UCabcdefghijklmnopqrstuv", Target{YouTube, ChannelID, "UCabcdefghijklmnopqrstuv"}, true},
+		{"https://www.youtube.com/@SomeChannel/videos", Target{YouTube, Handle, "SomeChannel"}, true},
+		{"youtube.com/channel/UCabcdefghijklmnopqrstuv", Target{YouTube, ChannelID, "UCabcdefghijklmnopqrstuv"}, true},
+		{"https://m.youtube.com/c/CustomName", Target{YouTube, Handle, "CustomName"}, true},
+		{"https://www.youtube.com/user/legacyname", Target{YouTube, UserID, "legacyname"}, true},
+		{"", Target{}, false},
+		{"@ab", Target{}, false},
+		{"https://www.youtube.com/channel/UCshort", Target{}, false},
+		{"https://www.youtube.com/watch?v=abc&lc=UgxAbCdEfGhIjKlMn", Target{}, false},
+		{"https://youtu.be/dQw4w9WgXcQ", Target{}, false},
+		{"https://www.youtube.com/feed/trending", Target{}, false},
+		{"https://twitter.com/someone", Target{}, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseYouTube(tt.in)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseYouTube(%q) error = %v, want ok %v", tt.in, err, tt.ok)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseYouTube(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Target
+		ok   bool
+	}{
+		// Twitter
+		{"https://twitter.com/SomeUser", Target{Twitter, Handle, "SomeUser"}, true},
+		{"x.com/SomeUser/status/123456789", Target{Twitter, Handle, "SomeUser"}, true},
+		{"https://mobile.x.com/SomeUser?s=20", Target{Twitter, Handle, "SomeUser"}, true},
+		{"https://x.com/i/user/783214", Target{Twitter, ID, "783214"}, true},
+		{"https://twitter.com/intent/user?user_id=783214", Target{Twitter, ID, "783214"}, true},
+		{"https://twitter.com/intent/follow?screen_name=SomeUser", Target{Twitter, Handle, "SomeUser"}, true},
+		{"https://twitter.com/intent/user?user_id=12ab", Target{}, false},
+		{"https://twitter.com/intent/user", Target{}, false},
+		{"https://x.com/home", Target{}, false},
+		{"https://x.com/Explore", Target{}, false},
+		{"https://x.com/", Target{}, false},
+
+		// Twitch
+		{"https://www.twitch.tv/SomeStreamer", Target{Twitch, Username, "somestreamer"}, true},
+		{"twitch.tv/somestreamer/videos", Target{Twitch, Username, "somestreamer"}, true},
+		{"https://www.twitch.tv/popout/somestreamer/chat?popout=", Target{Twitch, Username, "somestreamer"}, true},
+		{"https://m.twitch.tv/directory/all", Target{}, false},
+		{"https://www.twitch.tv/popout", Target{}, false},
+		{"https://www.twitch.tv/ab", Target{}, false},
+
+		// Kick
+		{"https://kick.com/SomeStreamer", Target{Kick, Username, "somestreamer"}, true},
+		{"https://kick.com/popout/somestreamer/chat", Target{Kick, Username, "somestreamer"}, true},
+		{"https://kick.com/categories/just-chatting", Target{}, false},
+
+		// YouTube
+		{"https://www.youtube.com/@SomeChannel", Target{YouTube, Handle, "SomeChannel"}, true},
+		{"https://www.youtube.com/c/CustomName/about", Target{YouTube, Handle, "CustomName"}, true},
+		{"https://www.youtube.com/user/legacyname", Target{YouTube, UserID, "legacyname"}, true},
+		{"https://www.youtube.com/watch?v=abc&lc=UgxAbCdEfGhIjKlMn", Target{YouTube, CommentID, "UgxAbCdEfGhIjKlMn"}, true},
+		{"https://youtu.be/dQw4w9WgXcQ?lc=UgxAbCdEfGhIjKlMn", Target{YouTube, CommentID, "UgxAbCdEfGhIjKlMn"}, true},
+		{"https://youtu.be/dQw4w9WgXcQ", Target{}, false},
+		{"https://www.youtube.com/watch?v=abc&lc=bad!", Target{}, false},
+
+		// Not URLs of a supported site
+		{"SomeUser", Target{}, false},
+		{"https://example.com/SomeUser", Target{}, false},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if (err == nil) != tt.ok {
+			t.Errorf("Parse(%q) error = %v, want ok %v", tt.in, err, tt.ok)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+// Package timeline normalises records from the different platforms into a
+// common event model that can be merged and sorted chronologically.
+package timeline
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/records"
+)
+
+// Platforms
+const (
+	Twitter = "twitter"
+	Twitch  = "twitch"
+	Kick    = "kick"
+	YouTube = "youtube"
+)
+
+// Event kinds
+const (
+	KindMessage  = "message"
+	KindComment  = "comment"
+	KindTimeout  = "timeout"
+	KindUsername = "username"
+)
+
+// Field names checked, in order, when normalising records
+var (
+	ActorKeys   = []string{"username", "user", "user_name", "author", "display_name", "handle", "screen_name", "name"}
+	ChannelKeys = []string{"channel", "channel_name", "room", "streamer", "broadcaster", "video_title", "video_id", "video"}
+	TextKeys    = []string{"message", "text", "content", "msg", "body", "comment", "reason"}
+	NameKeys    = []string{"username", "screen_name", "handle", "name", "utype", "btype", "type", "value"}
+)
+
+// Event represents a single normalised activity
+type Event struct {
+	Time     time.Time `json:"time"`
+	Platform string    `json:"platform"`
+	Kind     string    `json:"kind"`
+	Actor    string    `json:"actor"`
+	Channel  string    `json:"channel,omitempty"`
+	Text     string    `json:"text,omitempty"`
+}
+
+// Timeline is a list of events
+type Timeline []Event
+
+// FromActivity normalises message, comment or timeout records. The actor
+// falls back to the queried identifier when a record does not name one.
+func FromActivity(platform, kind, actor string, recs []records.Record) Timeline {
+	events := make(Timeline, 0, len(recs))
+	for _, rec := range recs {
+		t, _ := rec.Time(records.TimeKeys...)
+		ev := Event{
+			Time:     t,
+			Platform: platform,
+			Kind:     kind,
+			Actor:    rec.String(ActorKeys...),
+			Channel:  rec.String(ChannelKeys...),
+			Text:     rec.String(TextKeys...),
+		}
+		if ev.Actor == "" {
+			ev.Actor = actor
+		}
+		events = append(events, ev)
+	}
+	return events
+}
+
+// FromHistory normalises account history records, where each record holds
+// a name or type the account had at some point
+func FromHistory(platform, kind, actor string, recs []records.Record) Timeline {
+	events := make(Timeline, 0, len(recs))
+	for _, rec := range recs {
+		t, _ := rec.Time(records.TimeKeys...)
+		events = append(events, Event{
+			Time:     t,
+			Platform: platform,
+			Kind:     kind,
+			Actor:    actor,
+			Text:     rec.String(NameKeys...),
+		})
+	}
+	return events
+}
+
+// Sort orders the timeline chronologically, keeping events without a
+// timestamp at the end
+func (tl Timeline) Sort() {
+	sort.SliceStable(tl, func(i, j int) bool {
+		a, b := tl[i].Time, tl[j].Time
+		if a.IsZero() != b.IsZero() {
+			return b.IsZero()
+		}
+		return a.Before(b)
+	})
+}
+
+// Columns implements output.Tabular
+func (tl Timeline) Columns() []string {
+	return []string{"TIME", "PLATFORM", "KIND", "ACTOR", "CHANNEL", "TEXT"}
+}
+
+// Rows implements output.Tabular
+func (tl Timeline) Rows() [][]string {
+	rows := make([][]string, 0, len(tl))
+	for _, ev := range tl {
+		t := ""
+		if !ev.Time.IsZero() {
+			t = ev.Time.Format(time.RFC3339)
+		}
+		rows = append(rows, []string{t, ev.Platform, ev.Kind, ev.Actor, ev.Channel, ev.Text})
+	}
+	return rows
+}
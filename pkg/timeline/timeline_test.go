@@ -0,0 +1,79 @@
+package timeline
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/records"
+)
+
+func TestFromActivity(t *testing.T) {
+	recs := []records.Record{
+		{"timestamp": "2024-03-01T12:00:00Z", "username": "alice", "channel": "#foo", "message": "hi"},
+		{"created_at": "1709294400", "room": "bar", "text": "yo"},
+		{"msg": "no time"},
+	}
+	got := FromActivity(Twitch, KindMessage, "queried", recs)
+
+	want := Timeline{
+		{Time: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), Platform: Twitch, Kind: KindMessage, Actor: "alice", Channel: "#foo", Text: "hi"},
+		{Time: time.Unix(1709294400, 0).UTC(), Platform: Twitch, Kind: KindMessage, Actor: "queried", Channel: "bar", Text: "yo"},
+		{Platform: Twitch, Kind: KindMessage, Actor: "queried", Text: "no time"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FromActivity = %+v, want %+v", got, want)
+	}
+}
+
+func TestFromHistory(t *testing.T) {
+	recs := []records.Record{
+		{"date": "2020-01-01", "username": "old_name"},
+		{"date": "2022-06-15", "btype": "affiliate"},
+	}
+	got := FromHistory(Twitch, KindUsername, "current", recs)
+
+	want := Timeline{
+		{Time: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Platform: Twitch, Kind: KindUsername, Actor: "current", Text: "old_name"},
+		{Time: time.Date(2022, 6, 15, 0, 0, 0, 0, time.UTC), Platform: Twitch, Kind: KindUsername, Actor: "current", Text: "affiliate"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FromHistory = %+v, want %+v", got, want)
+	}
+}
+
+func TestSort(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	tl := Timeline{
+		{Text: "undated 1"},
+		{Time: day(3), Text: "third"},
+		{Time: day(1), Text: "first"},
+		{Text: "undated 2"},
+		{Time: day(2), Text: "second"},
+		{Time: day(1), Text: "first again"},
+	}
+	tl.Sort()
+
+	var got []string
+	for _, ev := range tl {
+		got = append(got, ev.Text)
+	}
+	want := []string{"first", "first again", "second", "third", "undated 1", "undated 2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Sort = %v, want %v", got, want)
+	}
+}
+
+func TestRows(t *testing.T) {
+	tl := Timeline{
+		{Time: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Platform: Kick, Kind: KindTimeout, Actor: "a", Channel: "c", Text: "spam"},
+		{Platform: YouTube, Kind: KindComment, Actor: "b"},
+	}
+	want := [][]string{
+		{"2024-01-01T00:00:00Z", "kick", "timeout", "a", "c", "spam"},
+		{"", "youtube", "comment", "b", "", ""},
+	}
+	if got := tl.Rows(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Rows = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,454 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/records"
+	"github.com/ivan9253/lolarchiver-cli/pkg/timeline"
+)
+
+// maxColumnWidth caps the width of every column but the last visible one
+const maxColumnWidth = 40
+
+// helpLine is shown at the bottom of the screen when no search is active
+const helpLine = "↑↓ move  PgUp/PgDn page  ←→ columns  / search  s sort  S reverse  enter detail  m more  q quit"
+
+// Pager returns the next page of records, or none once the source is
+// exhausted
+type Pager func() ([]records.Record, error)
+
+// Browser is a scrollable, searchable and sortable table of records
+type Browser struct {
+	title   string
+	more    Pager
+	done    bool
+	pages   int
+	recs    []records.Record
+	columns []string
+	widths  []int
+
+	// view holds the indices into recs that match the query, in display
+	// order
+	view      []int
+	cursor    int
+	top       int
+	colOffset int
+	sortCol   int
+	sortDesc  bool
+	query     string
+	searching bool
+	detail    bool
+	status    string
+}
+
+// NewBrowser creates a browser that loads its records from more
+func NewBrowser(title string, more Pager) *Browser {
+	return &Browser{title: title, more: more, sortCol: -1}
+}
+
+// Load fetches the next page. It returns the number of new records.
+func (b *Browser) Load() (int, error) {
+	if b.done {
+		return 0, nil
+	}
+	recs, err := b.more()
+	if err != nil {
+		return 0, err
+	}
+	if len(recs) == 0 {
+		b.done = true
+		return 0, nil
+	}
+	b.pages++
+	b.recs = append(b.recs, recs...)
+	b.columns = columnsOf(b.recs)
+	b.refresh()
+	return len(recs), nil
+}
+
+// Run draws the browser and handles keys until the user quits
+func (b *Browser) Run(t *Terminal) error {
+	for {
+		rows, cols := t.Size()
+		b.draw(t.Writer(), rows, cols)
+		if err := t.Writer().Flush(); err != nil {
+			return err
+		}
+
+		key, err := t.ReadKey()
+		if err != nil {
+			return err
+		}
+		if key == KeyInterrupt {
+			return nil
+		}
+		if b.searching {
+			b.searchKey(key)
+			continue
+		}
+
+		page := max(b.tableHeight(rows)-1, 1)
+		switch key {
+		case "q":
+			return nil
+		case KeyUp, "k":
+			b.move(-1)
+		case KeyDown, "j":
+			b.move(1)
+		case KeyPageUp, "b":
+			b.move(-page)
+		case KeyPageDown, " ":
+			b.move(page)
+		case KeyHome, "g":
+			b.cursor = 0
+		case KeyEnd, "G":
+			b.cursor = max(len(b.view)-1, 0)
+		case KeyLeft, "h":
+			b.colOffset = max(b.colOffset-1, 0)
+		case KeyRight, "l":
+			b.colOffset = min(b.colOffset+1, max(len(b.columns)-1, 0))
+		case "/":
+			b.searching = true
+		case KeyEscape:
+			b.query = ""
+			b.refresh()
+		case "s":
+			b.sortCol++
+			if b.sortCol >= len(b.columns) {
+				b.sortCol = -1
+			}
+			b.refresh()
+		case "S":
+			b.sortDesc = !b.sortDesc
+			b.refresh()
+		case KeyEnter:
+			b.detail = !b.detail
+		case "m":
+			b.loadMore(t, rows, cols)
+		}
+
+		// Moving past the last record loads the next page
+		if (key == KeyDown || key == "j" || key == KeyPageDown || key == " ") && b.cursor == len(b.view)-1 && !b.done {
+			b.loadMore(t, rows, cols)
+		}
+	}
+}
+
+func (b *Browser) loadMore(t *Terminal, rows, cols int) {
+	if b.done {
+		b.status = "No more results"
+		return
+	}
+	b.status = "Loading..."
+	b.draw(t.Writer(), rows, cols)
+	t.Writer().Flush()
+
+	n, err := b.Load()
+	switch {
+	case err != nil:
+		b.status = "Error: " + err.Error()
+	case n == 0:
+		b.status = "No more results"
+	default:
+		b.status = fmt.Sprintf("Loaded %d more record(s)", n)
+	}
+}
+
+func (b *Browser) searchKey(key string) {
+	switch key {
+	case KeyEnter:
+		b.searching = false
+		return
+	case KeyEscape:
+		b.searching = false
+		b.query = ""
+	case KeyBackspace:
+		if b.query != "" {
+			_, size := utf8.DecodeLastRuneInString(b.query)
+			b.query = b.query[:len(b.query)-size]
+		}
+	default:
+		if utf8.RuneCountInString(key) != 1 {
+			return
+		}
+		b.query += key
+	}
+	b.refresh()
+}
+
+func (b *Browser) move(delta int) {
+	b.cursor = max(min(b.cursor+delta, len(b.view)-1), 0)
+}
+
+// refresh rebuilds the view after the records, query or sort order change,
+// keeping the cursor on the same record when it is still shown
+func (b *Browser) refresh() {
+	selected := -1
+	if b.cursor < len(b.view) {
+		selected = b.view[b.cursor]
+	}
+
+	query := strings.ToLower(b.query)
+	b.view = b.view[:0]
+	for i, rec := range b.recs {
+		if query == "" || b.matches(rec, query) {
+			b.view = append(b.view, i)
+		}
+	}
+
+	if b.sortCol >= 0 && b.sortCol < len(b.columns) {
+		col := b.columns[b.sortCol]
+		sort.SliceStable(b.view, func(i, j int) bool {
+			c := compare(b.recs[b.view[i]].String(col), b.recs[b.view[j]].String(col))
+			if b.sortDesc {
+				return c > 0
+			}
+			return c < 0
+		})
+	}
+
+	b.cursor = 0
+	for i, idx := range b.view {
+		if idx == selected {
+			b.cursor = i
+			break
+		}
+	}
+	b.widths = b.columnWidths()
+}
+
+func (b *Browser) matches(rec records.Record, query string) bool {
+	for _, col := range b.columns {
+		if strings.Contains(strings.ToLower(rec.String(col)), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// compare orders two cells as timestamps or numbers when both parse as
+// such, and as case-insensitive text otherwise
+func compare(a, b string) int {
+	if ta, ok := records.ParseTime(a); ok && !isNumber(a) {
+		if tb, ok := records.ParseTime(b); ok && !isNumber(b) {
+			return ta.Compare(tb)
+		}
+	}
+	if fa, err := strconv.ParseFloat(a, 64); err == nil {
+		if fb, err := strconv.ParseFloat(b, 64); err == nil {
+			switch {
+			case fa < fb:
+				return -1
+			case fa > fb:
+				return 1
+			}
+			return 0
+		}
+	}
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+func isNumber(s string) bool {
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+// columnsOf lists every field of recs, with timestamps, channels, actors
+// and text first and the rest in alphabetical order
+func columnsOf(recs []records.Record) []string {
+	present := make(map[string]bool)
+	for _, rec := range recs {
+		for key := range rec {
+			present[key] = true
+		}
+	}
+
+	var columns []string
+	for _, keys := range [][]string{records.TimeKeys, timeline.ChannelKeys, timeline.ActorKeys, timeline.TextKeys} {
+		for _, key := range keys {
+			if present[key] {
+				columns = append(columns, key)
+				delete(present, key)
+			}
+		}
+	}
+	rest := make([]string, 0, len(present))
+	for key := range present {
+		rest = append(rest, key)
+	}
+	sort.Strings(rest)
+	return append(columns, rest...)
+}
+
+func (b *Browser) columnWidths() []int {
+	widths := make([]int, len(b.columns))
+	for i, col := range b.columns {
+		widths[i] = utf8.RuneCountInString(col)
+	}
+	for _, idx := range b.view {
+		for i, col := range b.columns {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell(b.recs[idx], col)))
+		}
+	}
+	for i := range widths {
+		widths[i] = min(widths[i], maxColumnWidth)
+	}
+	return widths
+}
+
+// layout returns the indices and widths of the columns that fit in width,
+// starting at the horizontal offset. The last column takes the remaining
+// space.
+func (b *Browser) layout(width int) ([]int, []int) {
+	var cols, widths []int
+	used := 0
+	for i := b.colOffset; i < len(b.columns); i++ {
+		w := b.widths[i]
+		if used+w > width || i == len(b.columns)-1 {
+			w = width - used
+			if w < 4 && len(cols) > 0 {
+				break
+			}
+			cols = append(cols, i)
+			widths = append(widths, w)
+			break
+		}
+		cols = append(cols, i)
+		widths = append(widths, w)
+		used += w + 2
+	}
+	return cols, widths
+}
+
+func (b *Browser) tableHeight(rows int) int {
+	height := rows - 3
+	if b.detail {
+		height = height / 2
+	}
+	return max(height, 1)
+}
+
+func (b *Browser) draw(w io.Writer, rows, cols int) {
+	height := b.tableHeight(rows)
+	if b.cursor < b.top {
+		b.top = b.cursor
+	}
+	if b.cursor >= b.top+height {
+		b.top = b.cursor - height + 1
+	}
+
+	fmt.Fprint(w, "\x1b[H\x1b[2J")
+
+	// Title bar
+	title := fmt.Sprintf(" %s  %d record(s), %d shown, %d page(s)", b.title, len(b.recs), len(b.view), b.pages)
+	if b.done {
+		title += ", all loaded"
+	}
+	if b.sortCol >= 0 && b.sortCol < len(b.columns) {
+		dir := "asc"
+		if b.sortDesc {
+			dir = "desc"
+		}
+		title += fmt.Sprintf("  sort: %s %s", b.columns[b.sortCol], dir)
+	}
+	if b.query != "" {
+		title += fmt.Sprintf("  filter: %q", b.query)
+	}
+	fmt.Fprintf(w, "\x1b[7m%s\x1b[0m\r\n", fit(title, cols))
+
+	// Header and rows
+	visible, widths := b.layout(cols)
+	header := make([]string, len(visible))
+	for i, c := range visible {
+		header[i] = fit(b.columns[c], widths[i])
+	}
+	fmt.Fprintf(w, "\x1b[1m%s\x1b[0m\r\n", fit(strings.Join(header, "  "), cols))
+
+	for line := 0; line < height; line++ {
+		i := b.top + line
+		if i >= len(b.view) {
+			fmt.Fprint(w, "\r\n")
+			continue
+		}
+		rec := b.recs[b.view[i]]
+		cells := make([]string, len(visible))
+		for j, c := range visible {
+			cells[j] = fit(cell(rec, b.columns[c]), widths[j])
+		}
+		text := fit(strings.Join(cells, "  "), cols)
+		if i == b.cursor {
+			text = "\x1b[7m" + text + "\x1b[0m"
+		}
+		fmt.Fprintf(w, "%s\r\n", text)
+	}
+
+	// Detail pane with the selected record
+	if b.detail {
+		fmt.Fprintf(w, "%s\r\n", strings.Repeat("─", cols))
+		lines := []string{"(no record selected)"}
+		if b.cursor < len(b.view) {
+			data, _ := json.MarshalIndent(b.recs[b.view[b.cursor]], "", "  ")
+			lines = strings.Split(string(data), "\n")
+		}
+		for i := 0; i < rows-3-height-1; i++ {
+			if i < len(lines) {
+				fmt.Fprint(w, fit(lines[i], cols))
+			}
+			fmt.Fprint(w, "\r\n")
+		}
+	}
+
+	// Status line
+	switch {
+	case b.searching:
+		fmt.Fprintf(w, "/%s\x1b[7m \x1b[0m", printable(b.query))
+	case b.status != "":
+		fmt.Fprint(w, fit(b.status, cols))
+		b.status = ""
+	default:
+		fmt.Fprintf(w, "\x1b[2m%s\x1b[0m", fit(helpLine, cols))
+	}
+}
+
+// cell renders a field on a single line, showing timestamps in UTC
+func cell(rec records.Record, key string) string {
+	s := rec.String(key)
+	if t, ok := records.ParseTime(rec[key]); ok && !isNumber(s) {
+		return t.UTC().Format(time.DateTime)
+	}
+	return printable(strings.Join(strings.Fields(s), " "))
+}
+
+// printable drops control characters, so that text from the API cannot
+// send escape sequences to the terminal
+func printable(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// fit pads or truncates s to exactly width runes, dropping control
+// characters
+func fit(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	s = printable(s)
+	n := utf8.RuneCountInString(s)
+	if n <= width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	runes := []rune(s)
+	return string(runes[:width-1]) + "…"
+}
@@ -0,0 +1,159 @@
+package tui
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/records"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"2024-01-02T00:00:00Z", "2024-01-10T00:00:00Z", -1},
+		{"2024-01-10 00:00:00", "2024-01-02 00:00:00", 1},
+		{"9", "10", -1},
+		{"10", "10.0", 0},
+		{"apple", "Banana", -1},
+		{"Apple", "apple", 0},
+		{"10", "apple", -1},
+	}
+	for _, tt := range tests {
+		if got := compare(tt.a, tt.b); got != tt.want {
+			t.Errorf("compare(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestFit(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+		want  string
+	}{
+		{"abc", 5, "abc  "},
+		{"abcdef", 4, "abc…"},
+		{"héllo", 5, "héllo"},
+		{"abc", 0, ""},
+		{"a\x1b]0;pwned\x07b", 12, "a]0;pwnedb  "},
+		{"x\u009b31my", 5, "x31my"},
+	}
+	for _, tt := range tests {
+		if got := fit(tt.s, tt.width); got != tt.want {
+			t.Errorf("fit(%q, %d) = %q, want %q", tt.s, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestCellStripsControls(t *testing.T) {
+	rec := records.Record{
+		"message":   "hi\x1b[2J\nthere\x1b]52;c;ZXZpbA==\x07",
+		"timestamp": "2024-01-02T03:04:05+01:00",
+	}
+	if got, want := cell(rec, "message"), "hi[2J there]52;c;ZXZpbA=="; got != want {
+		t.Errorf("cell(message) = %q, want %q", got, want)
+	}
+	if got, want := cell(rec, "timestamp"), "2024-01-02 02:04:05"; got != want {
+		t.Errorf("cell(timestamp) = %q, want %q", got, want)
+	}
+}
+
+func TestLayout(t *testing.T) {
+	b := &Browser{columns: []string{"a", "b", "c"}, widths: []int{10, 20, 30}}
+
+	tests := []struct {
+		name      string
+		offset    int
+		width     int
+		wantCols  []int
+		wantWidth []int
+	}{
+		{"everything fits", 0, 80, []int{0, 1, 2}, []int{10, 20, 46}},
+		{"last column squeezed", 0, 40, []int{0, 1, 2}, []int{10, 20, 6}},
+		{"last column dropped", 0, 34, []int{0, 1}, []int{10, 20}},
+		{"scrolled", 1, 40, []int{1, 2}, []int{20, 18}},
+		{"narrow", 0, 3, []int{0}, []int{3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b.colOffset = tt.offset
+			cols, widths := b.layout(tt.width)
+			if !reflect.DeepEqual(cols, tt.wantCols) || !reflect.DeepEqual(widths, tt.wantWidth) {
+				t.Errorf("layout(%d) = %v, %v; want %v, %v", tt.width, cols, widths, tt.wantCols, tt.wantWidth)
+			}
+		})
+	}
+}
+
+func newTestBrowser(recs ...records.Record) *Browser {
+	b := NewBrowser("test", func() ([]records.Record, error) { return nil, nil })
+	b.recs = recs
+	b.columns = columnsOf(recs)
+	b.refresh()
+	return b
+}
+
+func TestRefreshKeepsCursor(t *testing.T) {
+	b := newTestBrowser(
+		records.Record{"id": "1", "message": "hello"},
+		records.Record{"id": "2", "message": "world"},
+		records.Record{"id": "3", "message": "hello again"},
+	)
+	b.cursor = 2
+
+	// Filtering keeps the selected record under the cursor
+	b.query = "hello"
+	b.refresh()
+	if got := b.recs[b.view[b.cursor]]["id"]; got != "3" {
+		t.Errorf("cursor on %v after filtering, want 3", got)
+	}
+
+	// Sorting in reverse moves it to the top
+	b.query = ""
+	b.sortCol = 1
+	b.sortDesc = true
+	b.refresh()
+	if b.cursor != 0 || b.recs[b.view[0]]["id"] != "3" {
+		t.Errorf("cursor %d on %v after sorting, want 0 on 3", b.cursor, b.recs[b.view[b.cursor]]["id"])
+	}
+
+	// A filter hiding the record resets the cursor
+	b.query = "world"
+	b.refresh()
+	if b.cursor != 0 || len(b.view) != 1 {
+		t.Errorf("cursor %d with view %v, want 0 with one record", b.cursor, b.view)
+	}
+}
+
+func TestSearchKey(t *testing.T) {
+	b := newTestBrowser(
+		records.Record{"message": "héllo"},
+		records.Record{"message": "bye"},
+	)
+	b.searching = true
+
+	for _, key := range []string{"h", "é", KeyUp, "x"} {
+		b.searchKey(key)
+	}
+	if b.query != "héx" || len(b.view) != 0 {
+		t.Errorf("query %q with %d shown, want \"héx\" with none", b.query, len(b.view))
+	}
+
+	b.searchKey(KeyBackspace)
+	if b.query != "hé" || len(b.view) != 1 {
+		t.Errorf("query %q with %d shown, want \"hé\" with one", b.query, len(b.view))
+	}
+
+	b.searchKey(KeyEnter)
+	if b.searching || b.query != "hé" {
+		t.Errorf("enter: searching %v, query %q; want false, \"hé\"", b.searching, b.query)
+	}
+
+	b.searching = true
+	b.searchKey(KeyEscape)
+	if b.searching || b.query != "" || len(b.view) != 2 {
+		t.Errorf("escape: searching %v, query %q, %d shown; want false, empty, 2", b.searching, b.query, len(b.view))
+	}
+}
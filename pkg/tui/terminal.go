@@ -0,0 +1,157 @@
+// Package tui implements a minimal full-screen terminal browser for API
+// records using ANSI escape sequences and stty, without third-party
+// dependencies.
+package tui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrNotTerminal is returned by Open when standard input is not a terminal
+var ErrNotTerminal = errors.New("the interactive view needs a terminal")
+
+// Key names returned by ReadKey for keys that are not printable characters
+const (
+	KeyUp        = "up"
+	KeyDown      = "down"
+	KeyLeft      = "left"
+	KeyRight     = "right"
+	KeyPageUp    = "pgup"
+	KeyPageDown  = "pgdn"
+	KeyHome      = "home"
+	KeyEnd       = "end"
+	KeyEnter     = "enter"
+	KeyEscape    = "esc"
+	KeyBackspace = "backspace"
+	KeyInterrupt = "ctrl-c"
+)
+
+// escapeKeys maps the sequences following ESC to key names
+var escapeKeys = map[string]string{
+	"[A": KeyUp, "[B": KeyDown, "[C": KeyRight, "[D": KeyLeft,
+	"OA": KeyUp, "OB": KeyDown, "OC": KeyRight, "OD": KeyLeft,
+	"[5~": KeyPageUp, "[6~": KeyPageDown,
+	"[H": KeyHome, "[F": KeyEnd, "OH": KeyHome, "OF": KeyEnd,
+	"[1~": KeyHome, "[4~": KeyEnd, "[7~": KeyHome, "[8~": KeyEnd,
+}
+
+// Terminal is the controlling terminal switched to raw mode and the
+// alternate screen
+type Terminal struct {
+	in      *os.File
+	out     *bufio.Writer
+	saved   string
+	pending []byte
+}
+
+// Open switches the terminal on standard input to raw mode and enters the
+// alternate screen. Close must be called to restore it.
+func Open() (*Terminal, error) {
+	for _, f := range []*os.File{os.Stdin, os.Stdout} {
+		info, err := f.Stat()
+		if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+			return nil, ErrNotTerminal
+		}
+	}
+
+	// stty fails on character devices that are not terminals, e.g. /dev/null
+	t := &Terminal{in: os.Stdin, out: bufio.NewWriter(os.Stdout)}
+	saved, err := t.stty("-g")
+	if err != nil {
+		return nil, ErrNotTerminal
+	}
+	t.saved = strings.TrimSpace(saved)
+	if _, err := t.stty("raw", "-echo"); err != nil {
+		return nil, fmt.Errorf("failed to switch terminal to raw mode: %w", err)
+	}
+
+	// Alternate screen, hidden cursor
+	t.out.WriteString("\x1b[?1049h\x1b[?25l")
+	return t, t.out.Flush()
+}
+
+// Close leaves the alternate screen and restores the terminal settings
+func (t *Terminal) Close() error {
+	t.out.WriteString("\x1b[?25h\x1b[?1049l")
+	t.out.Flush()
+	if _, err := t.stty(t.saved); err != nil {
+		return fmt.Errorf("failed to restore terminal settings: %w", err)
+	}
+	return nil
+}
+
+func (t *Terminal) stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = t.in
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+// Size returns the number of rows and columns, falling back to 24x80 when
+// the size cannot be determined
+func (t *Terminal) Size() (int, int) {
+	out, err := t.stty("size")
+	if err == nil {
+		fields := strings.Fields(out)
+		if len(fields) == 2 {
+			rows, err1 := strconv.Atoi(fields[0])
+			cols, err2 := strconv.Atoi(fields[1])
+			if err1 == nil && err2 == nil && rows > 0 && cols > 0 {
+				return rows, cols
+			}
+		}
+	}
+	return 24, 80
+}
+
+// ReadKey blocks until a key is pressed and returns either one of the Key
+// names or the typed character
+func (t *Terminal) ReadKey() (string, error) {
+	if len(t.pending) == 0 {
+		buf := make([]byte, 64)
+		n, err := t.in.Read(buf)
+		if err != nil {
+			return "", err
+		}
+		t.pending = buf[:n]
+	}
+
+	b := t.pending
+	switch b[0] {
+	case 0x1b:
+		for seq, name := range escapeKeys {
+			if strings.HasPrefix(string(b[1:]), seq) {
+				t.pending = b[1+len(seq):]
+				return name, nil
+			}
+		}
+		t.pending = b[1:]
+		return KeyEscape, nil
+	case '\r', '\n':
+		t.pending = b[1:]
+		return KeyEnter, nil
+	case 0x7f, 0x08:
+		t.pending = b[1:]
+		return KeyBackspace, nil
+	case 0x03:
+		t.pending = b[1:]
+		return KeyInterrupt, nil
+	}
+
+	r, size := utf8.DecodeRune(b)
+	t.pending = b[size:]
+	return string(r), nil
+}
+
+// Writer returns the buffered screen writer. Flush must be called after
+// drawing.
+func (t *Terminal) Writer() *bufio.Writer {
+	return t.out
+}
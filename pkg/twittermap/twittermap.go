@@ -0,0 +1,260 @@
+// Package twittermap merges the handle histories of many Twitter accounts
+// into one row per account and handle, flagging handles that moved between
+// accounts.
+package twittermap
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/records"
+)
+
+// Fields checked, in order, in Twitter history responses
+var (
+	IDKeys     = []string{"id", "id_str", "user_id", "rest_id"}
+	HandleKeys = []string{"screen_name", "handle", "username", "name"}
+)
+
+// UnknownID groups sightings that do not name an account ID
+const UnknownID = "(unknown)"
+
+// idPrefix marks an input as an account ID rather than a handle
+const idPrefix = "id:"
+
+// Input is an account to look up, named either by handle or by ID
+type Input struct {
+	Handle string
+	ID     int64
+}
+
+// ParseInput reads "id:123" as an account ID and "@name" or "name" as a
+// handle. Handles may be all digits, so an ID always needs the prefix.
+func ParseInput(s string) (Input, error) {
+	s = strings.TrimSpace(s)
+	if rest, ok := strings.CutPrefix(strings.ToLower(s), idPrefix); ok {
+		id, err := strconv.ParseInt(strings.TrimSpace(rest), 10, 64)
+		if err != nil || id <= 0 {
+			return Input{}, fmt.Errorf("invalid account ID %q", s)
+		}
+		return Input{ID: id}, nil
+	}
+	handle := strings.TrimPrefix(s, "@")
+	if handle == "" {
+		return Input{}, fmt.Errorf("empty handle")
+	}
+	return Input{Handle: handle}, nil
+}
+
+// Key identifies the input for de-duplication; handles compare case
+// insensitively
+func (in Input) Key() string {
+	if in.Handle == "" {
+		return idPrefix + strconv.FormatInt(in.ID, 10)
+	}
+	return strings.ToLower(in.Handle)
+}
+
+// AccountID returns the account ID of an input named by ID, or ""
+func (in Input) AccountID() string {
+	if in.Handle != "" {
+		return ""
+	}
+	return strconv.FormatInt(in.ID, 10)
+}
+
+// String returns the input as it would be typed
+func (in Input) String() string {
+	if in.Handle == "" {
+		return idPrefix + strconv.FormatInt(in.ID, 10)
+	}
+	return "@" + in.Handle
+}
+
+// Sighting is one handle seen on an account
+type Sighting struct {
+	ID     string
+	Handle string
+	Time   *time.Time
+}
+
+// Mapping is a handle used by an account, with the dates it was seen
+type Mapping struct {
+	ID         string     `json:"id"`
+	Handle     string     `json:"handle"`
+	FirstSeen  *time.Time `json:"first_seen,omitempty"`
+	LastSeen   *time.Time `json:"last_seen,omitempty"`
+	Sightings  int        `json:"sightings"`
+	SharedWith []string   `json:"shared_with,omitempty"`
+}
+
+// Map is the tabular form of the mapping
+type Map []*Mapping
+
+// Columns implements output.Tabular
+func (m Map) Columns() []string {
+	return []string{"ID", "HANDLE", "FIRST_SEEN", "LAST_SEEN", "SIGHTINGS", "SHARED_WITH"}
+}
+
+// Rows implements output.Tabular
+func (m Map) Rows() [][]string {
+	rows := make([][]string, 0, len(m))
+	for _, e := range m {
+		rows = append(rows, []string{
+			e.ID,
+			e.Handle,
+			formatDate(e.FirstSeen),
+			formatDate(e.LastSeen),
+			strconv.Itoa(e.Sightings),
+			strings.Join(e.SharedWith, " "),
+		})
+	}
+	return rows
+}
+
+// Reused returns the IDs that used each handle seen on more than one
+// account, keyed by the lowercased handle
+func (m Map) Reused() map[string][]string {
+	reused := make(map[string][]string)
+	for _, e := range m {
+		if len(e.SharedWith) > 0 {
+			handle := strings.ToLower(e.Handle)
+			reused[handle] = append(reused[handle], e.ID)
+		}
+	}
+	return reused
+}
+
+// Parse extracts every (ID, handle, date) sighting from a history response,
+// which may describe one account or a list of accounts, each with its
+// handle history. Sightings whose records name no ID are given fallbackID,
+// the ID that was looked up, if any.
+func Parse(body []byte, fallbackID string) ([]Sighting, error) {
+	var v interface{}
+	if err := records.Decode(body, &v); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	var accounts []interface{}
+	switch t := v.(type) {
+	case []interface{}:
+		accounts = t
+	case map[string]interface{}:
+		accounts = []interface{}{t}
+	}
+
+	var sightings []Sighting
+	for _, account := range accounts {
+		obj, ok := account.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		accountID := records.Record(obj).String(IDKeys...)
+		if accountID == "" {
+			accountID = fallbackID
+		}
+		data, err := json.Marshal(obj)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode account: %w", err)
+		}
+		history, err := records.Extract(data)
+		if err != nil {
+			return nil, err
+		}
+		for _, rec := range history {
+			s := Sighting{ID: rec.String(IDKeys...), Handle: rec.String(HandleKeys...)}
+			if s.ID == "" {
+				s.ID = accountID
+			}
+			if s.Handle == "" {
+				continue
+			}
+			if t, ok := rec.Time(records.TimeKeys...); ok {
+				s.Time = &t
+			}
+			sightings = append(sightings, s)
+		}
+	}
+	return sightings, nil
+}
+
+// Build merges sightings into one row per (ID, handle) and marks handles
+// that were seen on more than one ID. Handles compare case insensitively,
+// as on Twitter. The same sighting returned by several lookups is counted
+// once. Sightings without an ID are listed but never count as another owner
+// of a handle, since they may be any of them.
+func Build(sightings []Sighting) Map {
+	entries := make(map[string]*Mapping)
+	owners := make(map[string]map[string]bool)
+	counted := make(map[string]bool)
+	for _, s := range sightings {
+		id := s.ID
+		if id == "" {
+			id = UnknownID
+		}
+		handle := strings.ToLower(s.Handle)
+		key := id + "\x00" + handle
+		sighting := key
+		if s.Time != nil {
+			sighting += "\x00" + s.Time.UTC().Format(time.RFC3339)
+		}
+		if counted[sighting] {
+			continue
+		}
+		counted[sighting] = true
+		e, ok := entries[key]
+		if !ok {
+			e = &Mapping{ID: id, Handle: s.Handle}
+			entries[key] = e
+		}
+		e.Sightings++
+		if s.Time != nil {
+			if e.FirstSeen == nil || s.Time.Before(*e.FirstSeen) {
+				e.FirstSeen = s.Time
+			}
+			if e.LastSeen == nil || s.Time.After(*e.LastSeen) {
+				e.LastSeen = s.Time
+			}
+		}
+		if id == UnknownID {
+			continue
+		}
+		if owners[handle] == nil {
+			owners[handle] = make(map[string]bool)
+		}
+		owners[handle][id] = true
+	}
+
+	m := make(Map, 0, len(entries))
+	for _, e := range entries {
+		for id := range owners[strings.ToLower(e.Handle)] {
+			if id != e.ID && e.ID != UnknownID {
+				e.SharedWith = append(e.SharedWith, id)
+			}
+		}
+		sort.Strings(e.SharedWith)
+		m = append(m, e)
+	}
+	sort.Slice(m, func(i, j int) bool {
+		if m[i].ID != m[j].ID {
+			return m[i].ID < m[j].ID
+		}
+		a, b := m[i].FirstSeen, m[j].FirstSeen
+		if a != nil && b != nil && !a.Equal(*b) {
+			return a.Before(*b)
+		}
+		return m[i].Handle < m[j].Handle
+	})
+	return m
+}
+
+func formatDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.DateOnly)
+}
@@ -0,0 +1,147 @@
+package twittermap
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func at(date string) *time.Time {
+	t, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		panic(err)
+	}
+	return &t
+}
+
+func TestParseInput(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Input
+		ok   bool
+	}{
+		{"id:783214", Input{ID: 783214}, true},
+		{" ID: 783214 ", Input{ID: 783214}, true},
+		{"@SomeUser", Input{Handle: "SomeUser"}, true},
+		{"SomeUser", Input{Handle: "SomeUser"}, true},
+		// Handles may be all digits, so a bare number is not an ID
+		{"123456", Input{Handle: "123456"}, true},
+		{"@123456", Input{Handle: "123456"}, true},
+		{"id:", Input{}, false},
+		{"id:12ab", Input{}, false},
+		{"id:-5", Input{}, false},
+		{"@", Input{}, false},
+		{"  ", Input{}, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseInput(tt.in)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseInput(%q) error = %v, want ok %v", tt.in, err, tt.ok)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseInput(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+
+	a, _ := ParseInput("@SomeUser")
+	b, _ := ParseInput("someuser")
+	c, _ := ParseInput("id:123")
+	d, _ := ParseInput("123")
+	if a.Key() != b.Key() {
+		t.Errorf("handle keys differ by case: %q, %q", a.Key(), b.Key())
+	}
+	if c.Key() == d.Key() {
+		t.Errorf("ID and numeric handle share key %q", c.Key())
+	}
+}
+
+func TestParse(t *testing.T) {
+	body := []byte(`[
+		{"id": "1", "history": [
+			{"screen_name": "alpha", "date": "2020-01-01T00:00:00Z"},
+			{"screen_name": "beta", "date": "2021-06-01T00:00:00Z"},
+			{"date": "2022-01-01T00:00:00Z"}
+		]},
+		{"id": "2", "history": [
+			{"id": "3", "screen_name": "gamma"}
+		]}
+	]`)
+	got, err := Parse(body, "")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	want := []Sighting{
+		{ID: "1", Handle: "alpha", Time: at("2020-01-01")},
+		{ID: "1", Handle: "beta", Time: at("2021-06-01")},
+		{ID: "3", Handle: "gamma"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse = %+v, want %+v", got, want)
+	}
+
+	if _, err := Parse([]byte("not json"), ""); err == nil {
+		t.Errorf("Parse of invalid JSON returned no error")
+	}
+}
+
+func TestParseFallbackID(t *testing.T) {
+	// A lookup by ID whose records do not repeat the ID
+	body := []byte(`{"results": [
+		{"screen_name": "alpha", "date": "2020-01-01T00:00:00Z"},
+		{"id": "9", "screen_name": "beta"}
+	]}`)
+	in, _ := ParseInput("id:123")
+
+	got, err := Parse(body, in.AccountID())
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	want := []Sighting{
+		{ID: "123", Handle: "alpha", Time: at("2020-01-01")},
+		{ID: "9", Handle: "beta"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse = %+v, want %+v", got, want)
+	}
+
+	// A handle lookup has no ID to fall back on
+	handle, _ := ParseInput("@alpha")
+	if got, _ := Parse(body, handle.AccountID()); got[0].ID != "" {
+		t.Errorf("sighting of a handle lookup has ID %q, want none", got[0].ID)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	sightings := []Sighting{
+		{ID: "1", Handle: "Alpha", Time: at("2020-03-01")},
+		{ID: "1", Handle: "alpha", Time: at("2020-01-01")},
+		{ID: "1", Handle: "alpha", Time: at("2020-06-01")},
+		// The same sighting returned by a second lookup
+		{ID: "1", Handle: "ALPHA", Time: at("2020-06-01")},
+		{ID: "2", Handle: "alpha", Time: at("2022-01-01")},
+		{ID: "2", Handle: "beta"},
+		{ID: "", Handle: "beta", Time: at("2023-01-01")},
+	}
+	m := Build(sightings)
+
+	want := []Mapping{
+		{ID: UnknownID, Handle: "beta", FirstSeen: at("2023-01-01"), LastSeen: at("2023-01-01"), Sightings: 1},
+		{ID: "1", Handle: "Alpha", FirstSeen: at("2020-01-01"), LastSeen: at("2020-06-01"), Sightings: 3, SharedWith: []string{"2"}},
+		{ID: "2", Handle: "alpha", FirstSeen: at("2022-01-01"), LastSeen: at("2022-01-01"), Sightings: 1, SharedWith: []string{"1"}},
+		{ID: "2", Handle: "beta", Sightings: 1},
+	}
+	if len(m) != len(want) {
+		t.Fatalf("Build returned %d rows, want %d: %v", len(m), len(want), m.Rows())
+	}
+	for i, e := range m {
+		if !reflect.DeepEqual(*e, want[i]) {
+			t.Errorf("row %d = %+v, want %+v", i, *e, want[i])
+		}
+	}
+
+	reused := m.Reused()
+	if !reflect.DeepEqual(reused, map[string][]string{"alpha": {"1", "2"}}) {
+		t.Errorf("Reused = %v, want alpha on 1 and 2", reused)
+	}
+}
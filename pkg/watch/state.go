@@ -0,0 +1,206 @@
+// Package watch keeps track of which records have already been seen by a
+// polling watcher so that only new records are reported.
+package watch
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/config"
+	"github.com/ivan9253/lolarchiver-cli/pkg/records"
+)
+
+const stateDir = "watch"
+
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// State represents the persisted state of a single watch
+type State struct {
+	Name        string               `json:"name"`
+	LastPoll    time.Time            `json:"last_poll"`
+	CreditsUsed int                  `json:"credits_used"`
+	Seen        map[string]time.Time `json:"seen"`
+
+	// Times holds the timestamps of seen records that carry one, and
+	// Horizon the newest of those forgotten by Prune. Records no newer than
+	// the horizon count as seen, so that pruned records deeper in the
+	// history are not reported again once paging reaches them.
+	Times   map[string]time.Time `json:"times,omitempty"`
+	Horizon time.Time            `json:"horizon,omitempty"`
+
+	// Printed holds records that were printed but whose notification has
+	// not succeeded yet, so that retries do not print them again
+	Printed map[string]time.Time `json:"printed,omitempty"`
+
+	// Delivered names the notifiers that already received a printed record
+	// whose delivery to another notifier failed
+	Delivered map[string][]string `json:"delivered,omitempty"`
+
+	path string
+}
+
+// Load loads the state for the named watch, returning an empty state if
+// the watch has never run
+func Load(name string) (*State, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(dir, stateDir, unsafeChars.ReplaceAllString(name, "_")+".json")
+	state := &State{Name: name, path: path}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			state.init()
+			return state, nil
+		}
+		return nil, fmt.Errorf("failed to read watch state: %w", err)
+	}
+
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse watch state: %w", err)
+	}
+	state.init()
+	return state, nil
+}
+
+func (s *State) init() {
+	if s.Seen == nil {
+		s.Seen = map[string]time.Time{}
+	}
+	if s.Times == nil {
+		s.Times = map[string]time.Time{}
+	}
+	if s.Printed == nil {
+		s.Printed = map[string]time.Time{}
+	}
+	if s.Delivered == nil {
+		s.Delivered = map[string][]string{}
+	}
+}
+
+// Save writes the state back to disk
+func (s *State) Save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create watch directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal watch state: %w", err)
+	}
+
+	if err := os.WriteFile(s.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write watch state: %w", err)
+	}
+	return nil
+}
+
+// Filter returns the records that have not been seen before, dropping
+// duplicates within recs. The records are not marked as seen; call MarkSeen
+// once they have been delivered. Records seen before have their timestamp
+// refreshed, so that Prune only forgets records the API stopped returning,
+// and records no newer than the horizon are treated as seen.
+func (s *State) Filter(recs []records.Record) []records.Record {
+	var fresh []records.Record
+	batch := make(map[string]bool)
+	now := time.Now().UTC()
+	for _, rec := range recs {
+		key := rec.Key()
+		if _, ok := s.Seen[key]; ok {
+			s.Seen[key] = now
+			continue
+		}
+		if t, ok := rec.Time(records.TimeKeys...); ok && !s.Horizon.IsZero() && !t.After(s.Horizon) {
+			continue
+		}
+		if batch[key] {
+			continue
+		}
+		batch[key] = true
+		fresh = append(fresh, rec)
+	}
+	return fresh
+}
+
+// MarkSeen records recs as seen so that later polls skip them
+func (s *State) MarkSeen(recs ...records.Record) {
+	now := time.Now().UTC()
+	for _, rec := range recs {
+		s.Seen[rec.Key()] = now
+		if t, ok := rec.Time(records.TimeKeys...); ok {
+			s.Times[rec.Key()] = t
+		}
+		delete(s.Printed, rec.Key())
+		delete(s.Delivered, rec.Key())
+	}
+}
+
+// Deliveries returns the notifiers that already received rec, as a set to
+// pass to notify.Multi.Deliver
+func (s *State) Deliveries(rec records.Record) map[string]bool {
+	delivered := make(map[string]bool)
+	for _, name := range s.Delivered[rec.Key()] {
+		delivered[name] = true
+	}
+	return delivered
+}
+
+// MarkDelivered records the notifiers that received rec
+func (s *State) MarkDelivered(rec records.Record, delivered map[string]bool) {
+	if len(delivered) == 0 {
+		delete(s.Delivered, rec.Key())
+		return
+	}
+	names := make([]string, 0, len(delivered))
+	for name := range delivered {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	s.Delivered[rec.Key()] = names
+}
+
+// MarkPrinted records that rec was printed, reporting whether it had not
+// been printed before
+func (s *State) MarkPrinted(rec records.Record) bool {
+	key := rec.Key()
+	if _, ok := s.Printed[key]; ok {
+		return false
+	}
+	s.Printed[key] = time.Now().UTC()
+	return true
+}
+
+// Prune forgets the records last returned by the API longer than retention
+// ago, and returns how many were removed. The horizon advances to the newest
+// timestamp among the forgotten records.
+func (s *State) Prune(retention time.Duration) int {
+	cutoff := time.Now().UTC().Add(-retention)
+	removed := 0
+	for key, t := range s.Seen {
+		if !t.Before(cutoff) {
+			continue
+		}
+		if rt, ok := s.Times[key]; ok && rt.After(s.Horizon) {
+			s.Horizon = rt
+		}
+		delete(s.Seen, key)
+		delete(s.Times, key)
+		removed++
+	}
+	for key, t := range s.Printed {
+		if t.Before(cutoff) {
+			delete(s.Printed, key)
+			delete(s.Delivered, key)
+			removed++
+		}
+	}
+	return removed
+}
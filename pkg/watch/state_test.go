@@ -0,0 +1,142 @@
+package watch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ivan9253/lolarchiver-cli/pkg/records"
+)
+
+func rec(id string) records.Record {
+	return records.Record{"id": id}
+}
+
+func TestStateRoundTrip(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	s, err := Load("twitch-messages-bob")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	s.MarkSeen(rec("m1"))
+	s.MarkPrinted(rec("m2"))
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	s, err = Load("twitch-messages-bob")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if _, ok := s.Seen[rec("m1").Key()]; !ok {
+		t.Errorf("m1 not seen after reload")
+	}
+	if s.MarkPrinted(rec("m2")) {
+		t.Errorf("m2 reported as not printed after reload")
+	}
+}
+
+func TestStateFilter(t *testing.T) {
+	s := &State{Seen: map[string]time.Time{}, Times: map[string]time.Time{}, Printed: map[string]time.Time{}}
+	s.MarkSeen(rec("m1"))
+
+	fresh := s.Filter([]records.Record{rec("m1"), rec("m2"), rec("m2"), rec("m3")})
+	if len(fresh) != 2 || fresh[0]["id"] != "m2" || fresh[1]["id"] != "m3" {
+		t.Errorf("Filter = %v, want m2 and m3", fresh)
+	}
+	if len(s.Seen) != 1 {
+		t.Errorf("Filter marked records as seen: %v", s.Seen)
+	}
+}
+
+func TestStatePrinted(t *testing.T) {
+	s := &State{Seen: map[string]time.Time{}, Times: map[string]time.Time{}, Printed: map[string]time.Time{}}
+	if !s.MarkPrinted(rec("m1")) {
+		t.Errorf("first MarkPrinted = false, want true")
+	}
+	if s.MarkPrinted(rec("m1")) {
+		t.Errorf("second MarkPrinted = true, want false")
+	}
+
+	// A delivered record no longer needs its printed marker
+	s.MarkSeen(rec("m1"))
+	if len(s.Printed) != 0 {
+		t.Errorf("Printed = %v after MarkSeen, want empty", s.Printed)
+	}
+}
+
+func TestStateDeliveries(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	s, err := Load("kick-messages-bob")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	s.MarkDelivered(rec("m1"), map[string]bool{"webhook a": true})
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	s, err = Load("kick-messages-bob")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := s.Deliveries(rec("m1")); !got["webhook a"] || len(got) != 1 {
+		t.Errorf("Deliveries = %v after reload, want webhook a", got)
+	}
+
+	s.MarkSeen(rec("m1"))
+	if got := s.Deliveries(rec("m1")); len(got) != 0 {
+		t.Errorf("Deliveries = %v after MarkSeen, want none", got)
+	}
+}
+
+func TestStatePrune(t *testing.T) {
+	now := time.Now().UTC()
+	s := &State{
+		Seen: map[string]time.Time{
+			rec("old").Key():    now.Add(-48 * time.Hour),
+			rec("recent").Key(): now.Add(-time.Hour),
+		},
+		Printed: map[string]time.Time{rec("stuck").Key(): now.Add(-72 * time.Hour)},
+	}
+
+	// Seeing a record again keeps it from being pruned
+	s.Filter([]records.Record{rec("old")})
+
+	if n := s.Prune(24 * time.Hour); n != 1 {
+		t.Errorf("Prune removed %d, want 1", n)
+	}
+	for _, id := range []string{"old", "recent"} {
+		if _, ok := s.Seen[rec(id).Key()]; !ok {
+			t.Errorf("%s pruned, want kept", id)
+		}
+	}
+	if len(s.Printed) != 0 {
+		t.Errorf("Printed = %v, want stuck pruned", s.Printed)
+	}
+}
+
+func TestStatePruneHorizon(t *testing.T) {
+	now := time.Now().UTC()
+	stamped := func(id string, age time.Duration) records.Record {
+		return records.Record{"id": id, "timestamp": now.Add(-age).Format(time.RFC3339)}
+	}
+	s := &State{Seen: map[string]time.Time{}, Times: map[string]time.Time{}, Printed: map[string]time.Time{}}
+
+	// Both pages were delivered, but later polls only fetched the first
+	// page, so the deeper record stopped being refreshed
+	s.MarkSeen(stamped("page1", 40*24*time.Hour), stamped("page2", 60*24*time.Hour))
+	s.Seen[stamped("page2", 0).Key()] = now.Add(-45 * 24 * time.Hour)
+
+	if n := s.Prune(30 * 24 * time.Hour); n != 1 {
+		t.Fatalf("Prune removed %d, want 1", n)
+	}
+
+	// A new post makes the watcher page past the first page again
+	fresh := s.Filter([]records.Record{stamped("new", time.Minute), stamped("page1", 40*24*time.Hour)})
+	fresh = append(fresh, s.Filter([]records.Record{stamped("page2", 60*24*time.Hour)})...)
+	if len(fresh) != 1 || fresh[0]["id"] != "new" {
+		t.Errorf("Filter = %v, want only the new record", fresh)
+	}
+}